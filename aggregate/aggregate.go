@@ -0,0 +1,196 @@
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+const (
+	MAX_LISTED_PODS = 20
+)
+
+type groupKey struct {
+	Namespace string
+	Kind      string
+	Name      string
+	Reason    string
+}
+
+// The pod notifications for one workload and reason seen during a window
+type group struct {
+	owner  api.ObjectReference
+	pods   []string
+	latest map[string]*deps.Notification
+}
+
+// Aggregator collapses notifications about pods of the same workload with the same
+// reason into one, so a failing Deployment with 30 replicas notifies once rather than
+// 30 times. Notifications are held for Window after the first one of a group, then
+// the group is passed on to Next. Anything not about an owned pod passes straight on.
+// Once Run's context is done windows are no longer waited for, and Flush passes on
+// whatever is still held.
+type Aggregator struct {
+	Owners *owners.Resolver
+	Next   deps.Sink
+	Window time.Duration
+	Clock  clock.Clock
+
+	// Runs the waits for windows to end; the watcher sets it so Shutdown waits for them
+	Spawn func(func())
+
+	lock    sync.Mutex
+	groups  map[groupKey]*group
+	stopped chan struct{}
+}
+
+func New(cfg *config.Config, o *owners.Resolver, next deps.Sink) *Aggregator {
+	return &Aggregator{
+		Owners:  o,
+		Next:    next,
+		Window:  time.Duration(cfg.AggregateWindow) * time.Second,
+		Clock:   clock.RealClock{},
+		Spawn:   func(f func()) { go f() },
+		groups:  make(map[groupKey]*group),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Stops waiting for windows to end once the context is done
+func (a *Aggregator) Run(ctx context.Context) {
+	<-ctx.Done()
+	close(a.stopped)
+}
+
+func (a *Aggregator) SendAll(n *deps.Notification) {
+	if n.Event.InvolvedObject.Kind != "Pod" {
+		a.Next.SendAll(n)
+		return
+	}
+
+	pod := n.Event.InvolvedObject
+	if pod.Namespace == "" {
+		pod.Namespace = n.Event.ObjectMeta.Namespace
+	}
+	owner, err := a.Owners.Root(pod)
+	if err != nil {
+		log.Warnf("Unable to resolve the owner of pod %s, not aggregating: %v", pod.Name, err.Error())
+		a.Next.SendAll(n)
+		return
+	}
+	if owner.Kind == "Pod" {
+		a.Next.SendAll(n)
+		return
+	}
+
+	key := groupKey{
+		Namespace: pod.Namespace,
+		Kind:      owner.Kind,
+		Name:      owner.Name,
+		Reason:    n.Event.Reason,
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	g, ok := a.groups[key]
+	if !ok {
+		g = &group{
+			owner:  owner,
+			latest: make(map[string]*deps.Notification),
+		}
+		a.groups[key] = g
+
+		log.Debugf("Aggregating %s notifications for pods of %s %s for %v", key.Reason, key.Kind, key.Name, a.Window)
+		after := a.Clock.After(a.Window)
+		a.Spawn(func() { a.flush(key, g, after) })
+	}
+	if _, ok := g.latest[pod.Name]; !ok {
+		g.pods = append(g.pods, pod.Name)
+	}
+	g.latest[pod.Name] = n
+}
+
+// Passes the group on once the window is over, unless it was already flushed or
+// the aggregator stopped, which leaves it to Flush
+func (a *Aggregator) flush(key groupKey, g *group, after <-chan time.Time) {
+	select {
+	case <-after:
+	case <-a.stopped:
+		return
+	}
+
+	a.lock.Lock()
+	if a.groups[key] != g {
+		a.lock.Unlock()
+		return
+	}
+	delete(a.groups, key)
+	a.lock.Unlock()
+
+	a.Next.SendAll(g.notification())
+}
+
+// Flush passes every group on straight away rather than waiting for its window to
+// end, so nothing held back is lost when shutting down
+func (a *Aggregator) Flush() {
+	a.lock.Lock()
+	groups := a.groups
+	a.groups = make(map[groupKey]*group)
+	a.lock.Unlock()
+
+	for key, g := range groups {
+		log.Debugf("Flushing %s notifications for pods of %s %s", key.Reason, key.Kind, key.Name)
+		a.Next.SendAll(g.notification())
+	}
+}
+
+// A single pod's latest notification is passed on as is. Otherwise the notification
+// is about the owner, listing the affected pods and the total count of their events.
+func (g *group) notification() *deps.Notification {
+	first := g.latest[g.pods[0]]
+	if len(g.pods) == 1 {
+		return first
+	}
+
+	n := *first
+	n.Event.ObjectMeta.Name = g.owner.Name
+	n.Event.InvolvedObject = g.owner
+
+	n.Event.Count = 0
+	for _, pod := range g.pods {
+		e := g.latest[pod].Event
+		n.Event.Count += e.Count
+		if e.FirstTimestamp.Time.Before(n.Event.FirstTimestamp.Time) {
+			n.Event.FirstTimestamp = e.FirstTimestamp
+		}
+		if e.LastTimestamp.Time.After(n.Event.LastTimestamp.Time) {
+			n.Event.LastTimestamp = e.LastTimestamp
+		}
+	}
+
+	n.Event.Message = fmt.Sprintf("%s\n%d events across %d pods of %s %s: %s", first.Event.Message, n.Event.Count, len(g.pods), g.owner.Kind, g.owner.Name, listPods(g.pods))
+	return &n
+}
+
+func listPods(pods []string) string {
+	sorted := make([]string, len(pods))
+	copy(sorted, pods)
+	sort.Strings(sorted)
+
+	if len(sorted) <= MAX_LISTED_PODS {
+		return strings.Join(sorted, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(sorted[:MAX_LISTED_PODS], ", "), len(sorted)-MAX_LISTED_PODS)
+}
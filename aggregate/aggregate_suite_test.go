@@ -0,0 +1,13 @@
+package aggregate
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAggregateSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Aggregate Suite")
+}
@@ -0,0 +1,198 @@
+// +build unit
+
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/fakes/notifiersfakes"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+var base = time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC)
+
+func podNotification(pod, reason string, count int32, minutes int) *deps.Notification {
+	return &deps.Notification{
+		Cluster:   "prod",
+		Namespace: "default",
+		Level:     "ERROR",
+		Event: api.Event{
+			ObjectMeta:     api.ObjectMeta{Name: pod + ".1", Namespace: "default"},
+			InvolvedObject: api.ObjectReference{Kind: "Pod", Namespace: "default", Name: pod},
+			Reason:         reason,
+			Message:        "Back-off restarting failed container",
+			Count:          count,
+			FirstTimestamp: unversioned.NewTime(base.Add(time.Duration(minutes) * time.Minute)),
+			LastTimestamp:  unversioned.NewTime(base.Add(time.Duration(minutes) * time.Minute)),
+		},
+	}
+}
+
+var _ = Describe("Aggregator", func() {
+	var (
+		fakeGetter *depsfakes.FakeIObjectGetter
+		sink       *notifiersfakes.FakeSink
+		sent       func() []*deps.Notification
+		clk        *clock.FakeClock
+		a          *Aggregator
+	)
+
+	BeforeEach(func() {
+		controller := true
+		fakeGetter = &depsfakes.FakeIObjectGetter{}
+		fakeGetter.GetStub = func(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+			switch {
+			case kind == "Pod" && name == "bare":
+				return &api.ObjectMeta{Name: name}, nil
+			case kind == "Pod" && name == "missing":
+				return nil, fmt.Errorf("not found")
+			case kind == "Pod":
+				return &api.ObjectMeta{Name: name, OwnerReferences: []api.OwnerReference{{Kind: "ReplicaSet", Name: "web-1234", Controller: &controller}}}, nil
+			case kind == "ReplicaSet":
+				return &api.ObjectMeta{Name: name, OwnerReferences: []api.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &controller}}}, nil
+			}
+			return &api.ObjectMeta{Name: name}, nil
+		}
+
+		cfg := config.New()
+		cfg.AggregateWindow = 30
+		sink = &notifiersfakes.FakeSink{}
+		sent = notifiersfakes.SentTo(sink)
+		clk = clock.NewFakeClock(base)
+
+		a = New(cfg, owners.New(fakeGetter), sink)
+		a.Clock = clk
+	})
+
+	It("should pass on notifications that are not about pods", func() {
+		n := podNotification("node-1", "NodeNotReady", 1, 0)
+		n.Event.InvolvedObject.Kind = "Node"
+		a.SendAll(n)
+
+		Expect(sent()).To(ConsistOf(n))
+	})
+
+	It("should pass on notifications about pods nothing controls", func() {
+		n := podNotification("bare", "BackOff", 1, 0)
+		a.SendAll(n)
+
+		Expect(sent()).To(ConsistOf(n))
+	})
+
+	It("should pass on notifications about pods whose owner cannot be resolved", func() {
+		n := podNotification("missing", "BackOff", 1, 0)
+		a.SendAll(n)
+
+		Expect(sent()).To(ConsistOf(n))
+	})
+
+	It("should hold notifications until the window is over", func() {
+		a.SendAll(podNotification("web-1234-a", "BackOff", 1, 0))
+
+		clk.Step(29 * time.Second)
+		Consistently(sent).Should(BeEmpty())
+		clk.Step(time.Second)
+		Eventually(sent).Should(HaveLen(1))
+	})
+
+	It("should pass on a single pod's latest notification as is", func() {
+		a.SendAll(podNotification("web-1234-a", "BackOff", 1, 0))
+		latest := podNotification("web-1234-a", "BackOff", 2, 1)
+		a.SendAll(latest)
+
+		clk.Step(30 * time.Second)
+		Eventually(sent).Should(ConsistOf(latest))
+	})
+
+	It("should collapse pods of the same workload with the same reason into one notification", func() {
+		a.SendAll(podNotification("web-1234-b", "BackOff", 2, 1))
+		a.SendAll(podNotification("web-1234-a", "BackOff", 3, 2))
+		a.SendAll(podNotification("web-1234-c", "BackOff", 1, 0))
+
+		clk.Step(30 * time.Second)
+		Eventually(sent).Should(HaveLen(1))
+
+		n := sent()[0]
+		Expect(n.Event.InvolvedObject.Kind).To(Equal("Deployment"))
+		Expect(n.Event.InvolvedObject.Name).To(Equal("web"))
+		Expect(n.Event.InvolvedObject.Namespace).To(Equal("default"))
+		Expect(n.Event.ObjectMeta.Name).To(Equal("web"))
+		Expect(n.Event.Count).To(Equal(int32(6)))
+		Expect(n.Event.FirstTimestamp.Time).To(Equal(base))
+		Expect(n.Event.LastTimestamp.Time).To(Equal(base.Add(2 * time.Minute)))
+		Expect(n.Event.Message).To(HaveSuffix("6 events across 3 pods of Deployment web: web-1234-a, web-1234-b, web-1234-c"))
+	})
+
+	It("should keep different reasons apart", func() {
+		a.SendAll(podNotification("web-1234-a", "BackOff", 1, 0))
+		a.SendAll(podNotification("web-1234-b", "Unhealthy", 1, 0))
+
+		clk.Step(30 * time.Second)
+		Eventually(sent).Should(HaveLen(2))
+	})
+
+	It("should start a new group after the window", func() {
+		a.SendAll(podNotification("web-1234-a", "BackOff", 1, 0))
+		clk.Step(30 * time.Second)
+		Eventually(sent).Should(HaveLen(1))
+
+		a.SendAll(podNotification("web-1234-a", "BackOff", 2, 1))
+		clk.Step(30 * time.Second)
+		Eventually(sent).Should(HaveLen(2))
+	})
+
+	It("should pass on every group straight away when flushed", func() {
+		a.SendAll(podNotification("web-1234-a", "BackOff", 1, 0))
+		a.SendAll(podNotification("web-1234-b", "Unhealthy", 1, 0))
+
+		a.Flush()
+		Expect(sent()).To(HaveLen(2))
+
+		// The window ending afterwards doesn't send them again
+		clk.Step(30 * time.Second)
+		Consistently(sent).Should(HaveLen(2))
+	})
+
+	It("should stop waiting for windows once the context is done, leaving the groups to Flush", func() {
+		var waiting sync.WaitGroup
+		a.Spawn = func(f func()) {
+			waiting.Add(1)
+			go func() {
+				defer waiting.Done()
+				f()
+			}()
+		}
+		a.SendAll(podNotification("web-1234-a", "BackOff", 1, 0))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		a.Run(ctx)
+		waiting.Wait()
+		Expect(sent()).To(BeEmpty())
+
+		a.Flush()
+		Expect(sent()).To(HaveLen(1))
+	})
+
+	It("should only list the first pods", func() {
+		for i := 0; i < MAX_LISTED_PODS+5; i++ {
+			a.SendAll(podNotification(fmt.Sprintf("web-1234-%02d", i), "BackOff", 1, 0))
+		}
+
+		clk.Step(30 * time.Second)
+		Eventually(sent).Should(HaveLen(1))
+		Expect(sent()[0].Event.Message).To(HaveSuffix("web-1234-19 and 5 more"))
+	})
+})
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	// "errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	// "net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -26,6 +28,9 @@ type Api struct {
 	Config       *config.Config
 	Version      string
 	Dependencies *deps.Dependencies
+
+	lock   sync.Mutex
+	server *http.Server
 }
 
 type JSONStatus struct {
@@ -115,8 +120,35 @@ func (a *Api) HomeHandler(rw http.ResponseWriter, r *http.Request) *DetailedErro
 }
 
 func (a *Api) HealthHandler(rw http.ResponseWriter, r *http.Request) *DetailedError {
+	// Without leader election the only instance is always the leader
+	role := "leader"
+	if a.Dependencies.Leader != nil && !a.Dependencies.Leader.IsLeader() {
+		role = "standby"
+	}
+
+	// Each cluster is watched independently, so one being unreachable doesn't make us
+	// unhealthy, and with leader election we may only be watching some of them
+	status := "Everything is peechy!"
+	var clusters []string
+	for _, c := range a.Dependencies.Clusters {
+		clusterRole := "leader"
+		if a.Dependencies.Leader != nil && !a.Dependencies.Leader.IsLeaderOf(c.Name()) {
+			clusterRole = "standby"
+		}
+
+		if err := c.Health(); err != nil {
+			status = "Some clusters are unhealthy!"
+			clusters = append(clusters, fmt.Sprintf("Cluster %s (%s): unhealthy: %v", c.Name(), clusterRole, err.Error()))
+		} else {
+			clusters = append(clusters, fmt.Sprintf("Cluster %s (%s): healthy", c.Name(), clusterRole))
+		}
+	}
+
 	rw.WriteHeader(200)
-	rw.Write([]byte("Everything is peechy!"))
+	rw.Write([]byte(fmt.Sprintf("%s Role: %s", status, role)))
+	for _, c := range clusters {
+		rw.Write([]byte("\n" + c))
+	}
 	return nil
 }
 
@@ -142,5 +174,28 @@ func (a *Api) Run() error {
 		"HealthHandler": a.HealthHandler,
 	})).Methods("GET")
 
-	return http.ListenAndServe(a.Config.ListenAddress, routes)
+	a.lock.Lock()
+	a.server = &http.Server{
+		Addr:    a.Config.ListenAddress,
+		Handler: routes,
+	}
+	server := a.server
+	a.lock.Unlock()
+
+	return server.ListenAndServe()
+}
+
+// Shutdown closes the listener and waits for requests being served to finish, or
+// for the context to be done. Run then returns http.ErrServerClosed.
+func (a *Api) Shutdown(ctx context.Context) error {
+	a.lock.Lock()
+	server := a.server
+	a.lock.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	log.Infof("Stopping API server on %v", a.Config.ListenAddress)
+	return server.Shutdown(ctx)
 }
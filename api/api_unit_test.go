@@ -3,6 +3,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 
@@ -11,6 +13,7 @@ import (
 
 	"github.com/InVisionApp/kit-overwatch/config"
 	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
 )
 
 var _ = Describe("API", func() {
@@ -90,5 +93,98 @@ var _ = Describe("API", func() {
 		It("should have a friendly and positive message", func() {
 			Expect(response.Body).To(ContainSubstring("peechy"))
 		})
+
+		It("should report the leader role without leader election", func() {
+			Expect(response.Body).To(ContainSubstring("Role: leader"))
+		})
+	})
+
+	Describe("GET /healthcheck with leader election", func() {
+		var fakeLeader *depsfakes.FakeILeaderStatus
+
+		BeforeEach(func() {
+			fakeLeader = &depsfakes.FakeILeaderStatus{}
+			d.Leader = fakeLeader
+			request, _ = http.NewRequest("GET", "/healthcheck", nil)
+		})
+
+		It("should report leader when holding the lock", func() {
+			fakeLeader.IsLeaderReturns(true)
+			api.HealthHandler(response, request)
+			Expect(response.Code).To(Equal(200))
+			Expect(response.Body).To(ContainSubstring("Role: leader"))
+		})
+
+		It("should report standby when not holding the lock", func() {
+			fakeLeader.IsLeaderReturns(false)
+			api.HealthHandler(response, request)
+			Expect(response.Code).To(Equal(200))
+			Expect(response.Body).To(ContainSubstring("Role: standby"))
+		})
+	})
+
+	Describe("GET /healthcheck with several clusters", func() {
+		var healthy, unhealthy *depsfakes.FakeIClusterStatus
+
+		BeforeEach(func() {
+			healthy = &depsfakes.FakeIClusterStatus{}
+			healthy.NameReturns("prod")
+			unhealthy = &depsfakes.FakeIClusterStatus{}
+			unhealthy.NameReturns("staging")
+			request, _ = http.NewRequest("GET", "/healthcheck", nil)
+		})
+
+		It("should report each cluster as healthy", func() {
+			d.Clusters = []deps.IClusterStatus{healthy}
+			api.HealthHandler(response, request)
+			Expect(response.Code).To(Equal(200))
+			Expect(response.Body.String()).To(Equal("Everything is peechy! Role: leader\nCluster prod (leader): healthy"))
+		})
+
+		It("should report an unhealthy cluster without failing", func() {
+			unhealthy.HealthReturns(errors.New("Unable to list events: connection refused"))
+			d.Clusters = []deps.IClusterStatus{healthy, unhealthy}
+			api.HealthHandler(response, request)
+			Expect(response.Code).To(Equal(200))
+			Expect(response.Body).To(ContainSubstring("Some clusters are unhealthy!"))
+			Expect(response.Body).To(ContainSubstring("Cluster prod (leader): healthy"))
+			Expect(response.Body).To(ContainSubstring("Cluster staging (leader): unhealthy: Unable to list events: connection refused"))
+		})
+
+		It("should report the role in each cluster with leader election", func() {
+			fakeLeader := &depsfakes.FakeILeaderStatus{}
+			fakeLeader.IsLeaderReturns(true)
+			fakeLeader.IsLeaderOfStub = func(cluster string) bool {
+				return cluster == "prod"
+			}
+			d.Leader = fakeLeader
+			d.Clusters = []deps.IClusterStatus{healthy, unhealthy}
+			api.HealthHandler(response, request)
+			Expect(response.Code).To(Equal(200))
+			Expect(response.Body.String()).To(Equal("Everything is peechy! Role: leader\nCluster prod (leader): healthy\nCluster staging (standby): healthy"))
+		})
+	})
+
+	Describe("Shutdown", func() {
+		It("should close the listener so Run returns", func() {
+			cfg.ListenAddress = "127.0.0.1:0"
+			errs := make(chan error, 1)
+			go func() {
+				errs <- api.Run()
+			}()
+
+			Eventually(func() *http.Server {
+				api.lock.Lock()
+				defer api.lock.Unlock()
+				return api.server
+			}).ShouldNot(BeNil())
+
+			Expect(api.Shutdown(context.Background())).To(BeNil())
+			Eventually(errs).Should(Receive(Equal(http.ErrServerClosed)))
+		})
+
+		It("should do nothing when not running", func() {
+			Expect(api.Shutdown(context.Background())).To(BeNil())
+		})
 	})
 })
@@ -0,0 +1,146 @@
+package catchup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/types"
+
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+const (
+	REASON      = "EventsDuringDowntime"
+	COMPONENT   = "kit-overwatch"
+	MAX_REASONS = 10
+)
+
+// Summary collects the events that happened while we weren't watching so they
+// can be sent as a single notification instead of one each.
+type Summary struct {
+	events map[types.UID]api.Event
+	levels map[types.UID]string
+}
+
+func New() *Summary {
+	return &Summary{
+		events: make(map[types.UID]api.Event),
+		levels: make(map[types.UID]string),
+	}
+}
+
+// Adds an event to the summary with the level it would have been notified at.
+// Adding the same event again replaces it.
+func (s *Summary) Add(e api.Event, level string) {
+	s.events[e.ObjectMeta.UID] = e
+	s.levels[e.ObjectMeta.UID] = level
+}
+
+// The number of events in the summary
+func (s *Summary) Len() int {
+	return len(s.events)
+}
+
+// Builds the summary notification. It carries a synthetic event spanning the
+// summarised events, at the most severe of their levels.
+func (s *Summary) Notification(cluster, mention string) *deps.Notification {
+	var first, last time.Time
+	var namespaces []string
+	seenNamespaces := make(map[string]bool)
+	reasons := make(map[string]int)
+	eventType := api.EventTypeNormal
+	level := "DEBUG"
+
+	for uid, e := range s.events {
+		if first.IsZero() || e.LastTimestamp.Time.Before(first) {
+			first = e.LastTimestamp.Time
+		}
+		if e.LastTimestamp.Time.After(last) {
+			last = e.LastTimestamp.Time
+		}
+
+		if !seenNamespaces[e.ObjectMeta.Namespace] {
+			seenNamespaces[e.ObjectMeta.Namespace] = true
+			namespaces = append(namespaces, e.ObjectMeta.Namespace)
+		}
+
+		reasons[e.Reason]++
+		if e.Type == api.EventTypeWarning {
+			eventType = api.EventTypeWarning
+		}
+		if deps.Severity(s.levels[uid]) > deps.Severity(level) {
+			level = s.levels[uid]
+		}
+	}
+
+	// Only attribute the summary to a namespace if every event happened in it
+	namespace := ""
+	if len(namespaces) == 1 {
+		namespace = namespaces[0]
+	}
+
+	return &deps.Notification{
+		Cluster:   cluster,
+		Namespace: namespace,
+		Event: api.Event{
+			ObjectMeta: api.ObjectMeta{
+				Name:      COMPONENT,
+				Namespace: namespace,
+			},
+			Reason:         REASON,
+			Message:        fmt.Sprintf("%d events happened while kit-overwatch was not watching: %s", len(s.events), summariseReasons(reasons)),
+			Source:         api.EventSource{Component: COMPONENT},
+			FirstTimestamp: unversioned.NewTime(first),
+			LastTimestamp:  unversioned.NewTime(last),
+			Count:          int32(len(s.events)),
+			Type:           eventType,
+		},
+		Level:   level,
+		Mention: mention,
+	}
+}
+
+type reasonCount struct {
+	reason string
+	count  int
+}
+
+type byCount []reasonCount
+
+func (r byCount) Len() int      { return len(r) }
+func (r byCount) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r byCount) Less(i, j int) bool {
+	if r[i].count != r[j].count {
+		return r[i].count > r[j].count
+	}
+	return r[i].reason < r[j].reason
+}
+
+// Lists the most common reasons first, eg. `5 BackOff, 2 Killing and 3 more`
+func summariseReasons(reasons map[string]int) string {
+	counts := make([]reasonCount, 0, len(reasons))
+	for reason, count := range reasons {
+		counts = append(counts, reasonCount{reason, count})
+	}
+	sort.Sort(byCount(counts))
+
+	var parts []string
+	more := 0
+	for i, c := range counts {
+		if i >= MAX_REASONS {
+			more += c.count
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", c.count, c.reason))
+	}
+
+	summary := strings.Join(parts, ", ")
+	if more > 0 {
+		summary = fmt.Sprintf("%s and %d more", summary, more)
+	}
+	return summary
+}
@@ -0,0 +1,13 @@
+package catchup
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCatchUpSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CatchUp Suite")
+}
@@ -0,0 +1,82 @@
+// +build unit
+
+package catchup
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/types"
+)
+
+var base = time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC)
+
+func newEvent(uid, namespace, reason, eventType string, minutes int) api.Event {
+	return api.Event{
+		ObjectMeta: api.ObjectMeta{
+			UID:       types.UID(uid),
+			Namespace: namespace,
+		},
+		Reason:        reason,
+		Type:          eventType,
+		LastTimestamp: unversioned.NewTime(base.Add(time.Duration(minutes) * time.Minute)),
+	}
+}
+
+var _ = Describe("Summary", func() {
+	var s *Summary
+
+	BeforeEach(func() {
+		s = New()
+		s.Add(newEvent("a", "default", "BackOff", api.EventTypeWarning, 5), "ERROR")
+		s.Add(newEvent("b", "default", "Killing", api.EventTypeNormal, 1), "INFO")
+		s.Add(newEvent("c", "default", "BackOff", api.EventTypeWarning, 3), "ERROR")
+	})
+
+	It("should count each event once", func() {
+		s.Add(newEvent("a", "default", "BackOff", api.EventTypeWarning, 6), "ERROR")
+		Expect(s.Len()).To(Equal(3))
+	})
+
+	It("should summarise the reasons, most common first", func() {
+		n := s.Notification("prod", "here")
+		Expect(n.Event.Reason).To(Equal(REASON))
+		Expect(n.Event.Message).To(Equal("3 events happened while kit-overwatch was not watching: 2 BackOff, 1 Killing"))
+		Expect(n.Event.Count).To(Equal(int32(3)))
+		Expect(n.Cluster).To(Equal("prod"))
+		Expect(n.Mention).To(Equal("here"))
+	})
+
+	It("should span the summarised events", func() {
+		n := s.Notification("prod", "here")
+		Expect(n.Event.FirstTimestamp.Time).To(Equal(base.Add(time.Minute)))
+		Expect(n.Event.LastTimestamp.Time).To(Equal(base.Add(5 * time.Minute)))
+	})
+
+	It("should use the most severe level and type", func() {
+		n := s.Notification("prod", "here")
+		Expect(n.Level).To(Equal("ERROR"))
+		Expect(n.Event.Type).To(Equal(api.EventTypeWarning))
+	})
+
+	It("should use the namespace when every event happened in it", func() {
+		Expect(s.Notification("prod", "here").Namespace).To(Equal("default"))
+
+		s.Add(newEvent("d", "kube-system", "Killing", api.EventTypeNormal, 2), "INFO")
+		Expect(s.Notification("prod", "here").Namespace).To(BeEmpty())
+	})
+
+	It("should only list the most common reasons", func() {
+		s = New()
+		for i := 0; i < MAX_REASONS+2; i++ {
+			s.Add(newEvent(fmt.Sprintf("uid-%d", i), "default", fmt.Sprintf("Reason%02d", i), api.EventTypeNormal, i), "INFO")
+		}
+
+		n := s.Notification("prod", "here")
+		Expect(n.Event.Message).To(HaveSuffix("1 Reason09 and 2 more"))
+	})
+})
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+)
+
+// Cluster is one of the clusters listed in KIT_OVERWATCH_CLUSTERS_FILE. How to reach
+// it is only taken from here, apart from the kubeconfig which defaults to
+// KIT_OVERWATCH_KUBECONFIG. Its namespaces and where its notifications go default
+// to the environment's settings.
+type Cluster struct {
+	Name       string `json:"name"`
+	InCluster  bool   `json:"inCluster"`
+	Host       string `json:"host"`
+	Kubeconfig string `json:"kubeconfig"`
+	Context    string `json:"context"`
+	Token      string `json:"token"`
+	Cert       string `json:"cert"`
+	Key        string `json:"key"`
+	CA         string `json:"ca"`
+	Insecure   bool   `json:"insecure"`
+
+	Namespaces        []string `json:"namespaces"`
+	NamespaceSelector string   `json:"namespaceSelector"`
+	NamespaceInclude  []string `json:"namespaceInclude"`
+	NamespaceExclude  []string `json:"namespaceExclude"`
+	EventAPI          string   `json:"eventAPI"`
+
+	NotificationLevel string `json:"notificationLevel"`
+	MentionDefault    string `json:"mentionDefault"`
+	SlackChannel      string `json:"slackChannel"`
+}
+
+// Clusters returns the config of each cluster to watch: this config with the
+// settings of each cluster in KIT_OVERWATCH_CLUSTERS_FILE on top, or just this config
+// when there is no such file.
+func (c *Config) Clusters() ([]*Config, error) {
+	if c.ClustersFile == "" {
+		return []*Config{c}, nil
+	}
+
+	raw, err := ioutil.ReadFile(c.ClustersFile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read clusters file: %v", err.Error())
+	}
+
+	var clusters []Cluster
+	if err := yaml.Unmarshal(raw, &clusters); err != nil {
+		return nil, fmt.Errorf("Unable to parse clusters file %s: %v", c.ClustersFile, err.Error())
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("clusters file %s lists no clusters", c.ClustersFile)
+	}
+
+	seen := make(map[string]bool)
+	configs := make([]*Config, 0, len(clusters))
+	for i, cluster := range clusters {
+		if cluster.Name == "" {
+			return nil, fmt.Errorf("cluster %d in %s has no name", i+1, c.ClustersFile)
+		}
+		if seen[cluster.Name] {
+			return nil, fmt.Errorf("cluster '%s' is listed more than once in %s", cluster.Name, c.ClustersFile)
+		}
+		seen[cluster.Name] = true
+
+		cfg := c.withCluster(cluster)
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("cluster '%s': %v", cluster.Name, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
+
+func (c *Config) withCluster(cluster Cluster) *Config {
+	cfg := *c
+	cfg.ClustersFile = ""
+	cfg.ClusterName = cluster.Name
+	cfg.InCluster = cluster.InCluster
+	cfg.ClusterHost = cluster.Host
+	cfg.KubeContext = cluster.Context
+	cfg.ClusterToken = cluster.Token
+	cfg.ClusterCert = cluster.Cert
+	cfg.ClusterKey = cluster.Key
+	cfg.ClusterCA = cluster.CA
+	cfg.ClusterInsecure = cluster.Insecure
+	if cluster.Kubeconfig != "" {
+		cfg.Kubeconfig = cluster.Kubeconfig
+	}
+
+	if len(cluster.Namespaces) != 0 {
+		cfg.Namespaces = cluster.Namespaces
+	}
+	if cluster.NamespaceSelector != "" {
+		cfg.NamespaceSelector = cluster.NamespaceSelector
+	}
+	if len(cluster.NamespaceInclude) != 0 {
+		cfg.NamespaceInclude = cluster.NamespaceInclude
+	}
+	if len(cluster.NamespaceExclude) != 0 {
+		cfg.NamespaceExclude = cluster.NamespaceExclude
+	}
+	if cluster.EventAPI != "" {
+		cfg.EventAPI = cluster.EventAPI
+	}
+
+	if cluster.NotificationLevel != "" {
+		cfg.NotificationLevel = cluster.NotificationLevel
+	}
+	if cluster.MentionDefault != "" {
+		cfg.MentionDefault = cluster.MentionDefault
+	}
+	if cluster.SlackChannel != "" {
+		cfg.NotifySlackChannel = cluster.SlackChannel
+	}
+
+	return &cfg
+}
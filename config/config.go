@@ -2,32 +2,87 @@ package config
 
 import (
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
 
 	"gopkg.in/caarlos0/env.v2"
 )
 
+const (
+	NAMESPACE_ALL = "*"
+)
+
 type Config struct {
-	Debug               bool   `env:"KIT_OVERWATCH_DEBUG" envDefault:"true"`
-	ListenAddress       string `env:"KIT_OVERWATCH_LISTEN_ADDRESS" envDefault:":8080"`
-	StatsDAddress       string `env:"KIT_OVERWATCH_STATSD_ADDRESS" envDefault:"localhost:8125"`
-	StatsDPrefix        string `env:"KIT_OVERWATCH_STATSD_PREFIX" envDefault:"statsd.kit-overwatch.dev"`
-	Namespace           string `env:"KIT_OVERWATCH_NAMESPACE" envDefault:"default"`
-	InCluster           bool   `env:"KIT_OVERWATCH_IN_CLUSTER" envDefault:"false"`
-	ClusterName         string `env:"KIT_OVERWATCH_CLUSTER_NAME" envDefault:"local"`
-	ClusterHost         string `env:"KIT_OVERWATCH_CLUSTER_HOST" envDefault:"http://127.0.0.1:8001"`
-	NotificationLevel   string `env:"KIT_OVERWATCH_NOTIFICATION_LEVEL" envDefault:"DEBUG"`
-	MentionLabel        string `env:"KIT_OVERWATCH_MENTION_LABEL" envDefault:""`
-	MentionDefault      string `env:"KIT_OVERWATCH_MENTION_DEFAULT" envDefault:"here"`
-	NotifyLog           bool   `env:"KIT_OVERWATCH_NOTIFY_LOG" envDefault:"true"`
-	NotifySlack         bool   `env:"KIT_OVERWATCH_NOTIFY_SLACK" envDefault:"false"`
-	NotifySlackToken    string `env:"KIT_OVERWATCH_NOTIFY_SLACK_TOKEN" envDefault:""`
-	NotifySlackAsUser   bool   `env:"KIT_OVERWATCH_NOTIFY_SLACK_AS_USER" envDefault:"false"`
-	NotifySlackChannel  string `env:"KIT_OVERWATCH_NOTIFY_SLACK_CHANNEL" envDefault:""`
-	NotifyDataDog       bool   `env:"KIT_OVERWATCH_NOTIFY_DATADOG" envDefault:"false"`
-	NotifyDataDogApiKey string `env:"KIT_OVERWATCH_NOTIFY_DATADOG_APIKEY" envDefault:""`
-	NotifyDataDogAppKey string `env:"KIT_OVERWATCH_NOTIFY_DATADOG_APPKEY" envDefault:""`
+	Debug                    bool     `env:"KIT_OVERWATCH_DEBUG" envDefault:"true"`
+	ListenAddress            string   `env:"KIT_OVERWATCH_LISTEN_ADDRESS" envDefault:":8080"`
+	ShutdownTimeout          int      `env:"KIT_OVERWATCH_SHUTDOWN_TIMEOUT" envDefault:"30"`
+	StatsDAddress            string   `env:"KIT_OVERWATCH_STATSD_ADDRESS" envDefault:"localhost:8125"`
+	StatsDPrefix             string   `env:"KIT_OVERWATCH_STATSD_PREFIX" envDefault:"statsd.kit-overwatch.dev"`
+	Namespaces               []string `env:"KIT_OVERWATCH_NAMESPACE" envDefault:"default"`
+	NamespaceSelector        string   `env:"KIT_OVERWATCH_NAMESPACE_SELECTOR" envDefault:""`
+	NamespaceInclude         []string `env:"KIT_OVERWATCH_NAMESPACE_INCLUDE" envDefault:""`
+	NamespaceExclude         []string `env:"KIT_OVERWATCH_NAMESPACE_EXCLUDE" envDefault:""`
+	EventAPI                 string   `env:"KIT_OVERWATCH_EVENT_API" envDefault:"core"`
+	InCluster                bool     `env:"KIT_OVERWATCH_IN_CLUSTER" envDefault:"false"`
+	ClusterName              string   `env:"KIT_OVERWATCH_CLUSTER_NAME" envDefault:"local"`
+	ClustersFile             string   `env:"KIT_OVERWATCH_CLUSTERS_FILE" envDefault:""`
+	ClusterHost              string   `env:"KIT_OVERWATCH_CLUSTER_HOST" envDefault:""`
+	Kubeconfig               string   `env:"KIT_OVERWATCH_KUBECONFIG" envDefault:""`
+	KubeContext              string   `env:"KIT_OVERWATCH_KUBE_CONTEXT" envDefault:""`
+	ClusterToken             string   `env:"KIT_OVERWATCH_CLUSTER_TOKEN" envDefault:""`
+	ClusterCert              string   `env:"KIT_OVERWATCH_CLUSTER_CERT" envDefault:""`
+	ClusterKey               string   `env:"KIT_OVERWATCH_CLUSTER_KEY" envDefault:""`
+	ClusterCA                string   `env:"KIT_OVERWATCH_CLUSTER_CA" envDefault:""`
+	ClusterInsecure          bool     `env:"KIT_OVERWATCH_CLUSTER_INSECURE" envDefault:"false"`
+	NotificationLevel        string   `env:"KIT_OVERWATCH_NOTIFICATION_LEVEL" envDefault:"DEBUG"`
+	MentionLabel             string   `env:"KIT_OVERWATCH_MENTION_LABEL" envDefault:""`
+	MentionAnnotation        string   `env:"KIT_OVERWATCH_MENTION_ANNOTATION" envDefault:""`
+	MentionChain             []string `env:"KIT_OVERWATCH_MENTION_CHAIN" envDefault:"label,annotation,owner-label,owner-annotation,namespace-label,namespace-annotation"`
+	MentionDefault           string   `env:"KIT_OVERWATCH_MENTION_DEFAULT" envDefault:"here"`
+	LeaderElect              bool     `env:"KIT_OVERWATCH_LEADER_ELECT" envDefault:"false"`
+	LeaderElectNamespace     string   `env:"KIT_OVERWATCH_LEADER_ELECT_NAMESPACE" envDefault:"default"`
+	LeaderElectName          string   `env:"KIT_OVERWATCH_LEADER_ELECT_NAME" envDefault:"kit-overwatch"`
+	LeaderElectIdentity      string   `env:"KIT_OVERWATCH_LEADER_ELECT_IDENTITY" envDefault:""`
+	LeaderElectLeaseDuration int      `env:"KIT_OVERWATCH_LEADER_ELECT_LEASE_DURATION" envDefault:"15"`
+	LeaderElectRenewDeadline int      `env:"KIT_OVERWATCH_LEADER_ELECT_RENEW_DEADLINE" envDefault:"10"`
+	LeaderElectRetryPeriod   int      `env:"KIT_OVERWATCH_LEADER_ELECT_RETRY_PERIOD" envDefault:"2"`
+	ObjectCacheKinds         []string `env:"KIT_OVERWATCH_OBJECT_CACHE_KINDS" envDefault:"Pod,ReplicaSet,Deployment,DaemonSet,Job,Node,Namespace"`
+	StateStore               string   `env:"KIT_OVERWATCH_STATE_STORE" envDefault:"memory"`
+	StateStorePath           string   `env:"KIT_OVERWATCH_STATE_STORE_PATH" envDefault:"kit-overwatch.db"`
+	StateStoreMaxEvents      int      `env:"KIT_OVERWATCH_STATE_STORE_MAX_EVENTS" envDefault:"10000"`
+	StateStoreOverflow       string   `env:"KIT_OVERWATCH_STATE_STORE_OVERFLOW" envDefault:"evict"`
+	StateStoreRedisAddress   string   `env:"KIT_OVERWATCH_STATE_STORE_REDIS_ADDRESS" envDefault:"localhost:6379"`
+	StateStoreRedisPassword  string   `env:"KIT_OVERWATCH_STATE_STORE_REDIS_PASSWORD" envDefault:""`
+	StateStoreRedisDB        int      `env:"KIT_OVERWATCH_STATE_STORE_REDIS_DB" envDefault:"0"`
+	StateStoreRedisPrefix    string   `env:"KIT_OVERWATCH_STATE_STORE_REDIS_PREFIX" envDefault:"kit-overwatch"`
+	EventTTL                 int      `env:"KIT_OVERWATCH_EVENT_TTL" envDefault:"3600"`
+	Lookback                 int      `env:"KIT_OVERWATCH_LOOKBACK" envDefault:"60"`
+	CatchUp                  bool     `env:"KIT_OVERWATCH_CATCH_UP" envDefault:"false"`
+	AggregateWindow          int      `env:"KIT_OVERWATCH_AGGREGATE_WINDOW" envDefault:"0"`
+	StormThreshold           int      `env:"KIT_OVERWATCH_STORM_THRESHOLD" envDefault:"0"`
+	StormWindow              int      `env:"KIT_OVERWATCH_STORM_WINDOW" envDefault:"60"`
+	StormUpdate              int      `env:"KIT_OVERWATCH_STORM_UPDATE" envDefault:"300"`
+	FlapThreshold            int      `env:"KIT_OVERWATCH_FLAP_THRESHOLD" envDefault:"0"`
+	FlapWindow               int      `env:"KIT_OVERWATCH_FLAP_WINDOW" envDefault:"600"`
+	FlapStable               int      `env:"KIT_OVERWATCH_FLAP_STABLE" envDefault:"300"`
+	Incidents                bool     `env:"KIT_OVERWATCH_INCIDENTS" envDefault:"false"`
+	IncidentQuiet            int      `env:"KIT_OVERWATCH_INCIDENT_QUIET" envDefault:"600"`
+	IncidentByOwner          bool     `env:"KIT_OVERWATCH_INCIDENT_BY_OWNER" envDefault:"true"`
+	Resolutions              bool     `env:"KIT_OVERWATCH_RESOLUTIONS" envDefault:"false"`
+	PodMonitor               bool     `env:"KIT_OVERWATCH_POD_MONITOR" envDefault:"false"`
+	RolloutMonitor           bool     `env:"KIT_OVERWATCH_ROLLOUT_MONITOR" envDefault:"false"`
+	Throttle                 string   `env:"KIT_OVERWATCH_THROTTLE" envDefault:"linear:1m"`
+	ThrottleReasons          []string `env:"KIT_OVERWATCH_THROTTLE_REASON" envDefault:""`
+	ThrottleKinds            []string `env:"KIT_OVERWATCH_THROTTLE_KIND" envDefault:""`
+	NotifyLog                bool     `env:"KIT_OVERWATCH_NOTIFY_LOG" envDefault:"true"`
+	NotifySlack              bool     `env:"KIT_OVERWATCH_NOTIFY_SLACK" envDefault:"false"`
+	NotifySlackToken         string   `env:"KIT_OVERWATCH_NOTIFY_SLACK_TOKEN" envDefault:""`
+	NotifySlackAsUser        bool     `env:"KIT_OVERWATCH_NOTIFY_SLACK_AS_USER" envDefault:"false"`
+	NotifySlackChannel       string   `env:"KIT_OVERWATCH_NOTIFY_SLACK_CHANNEL" envDefault:""`
+	NotifyDataDog            bool     `env:"KIT_OVERWATCH_NOTIFY_DATADOG" envDefault:"false"`
+	NotifyDataDogApiKey      string   `env:"KIT_OVERWATCH_NOTIFY_DATADOG_APIKEY" envDefault:""`
+	NotifyDataDogAppKey      string   `env:"KIT_OVERWATCH_NOTIFY_DATADOG_APPKEY" envDefault:""`
 }
 
 func New() *Config {
@@ -39,6 +94,11 @@ func (c *Config) LoadEnvVars() error {
 		return fmt.Errorf("Unable to fetch env vars: %v", err.Error())
 	}
 
+	return c.Validate()
+}
+
+// Validate checks every setting, returning all the problems found in one error
+func (c *Config) Validate() error {
 	var errorList []string
 
 	// Verify we have a valid listen address
@@ -51,9 +111,131 @@ func (c *Config) LoadEnvVars() error {
 		errorList = append(errorList, "invalid 'KIT_OVERWATCH_LISTEN_ADDRESS'")
 	}
 
+	// Verify namespace patterns are valid globs
+	for _, p := range append(c.NamespaceInclude, c.NamespaceExclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			errorList = append(errorList, fmt.Sprintf("invalid namespace pattern '%s'", p))
+		}
+	}
+
+	// Verify the out of cluster credentials are complete and consistent
+	if (c.ClusterCert == "") != (c.ClusterKey == "") {
+		errorList = append(errorList, "'KIT_OVERWATCH_CLUSTER_CERT' and 'KIT_OVERWATCH_CLUSTER_KEY' must be set together")
+	}
+	if c.ClusterInsecure && c.ClusterCA != "" {
+		errorList = append(errorList, "'KIT_OVERWATCH_CLUSTER_INSECURE' can't be enabled with a 'KIT_OVERWATCH_CLUSTER_CA'")
+	}
+	if c.KubeContext != "" && c.Kubeconfig == "" {
+		errorList = append(errorList, "'KIT_OVERWATCH_KUBE_CONTEXT' requires a 'KIT_OVERWATCH_KUBECONFIG'")
+	}
+
+	switch c.NotificationLevel {
+	case "DEBUG", "INFO", "WARN", "ERROR":
+	default:
+		errorList = append(errorList, fmt.Sprintf("invalid 'KIT_OVERWATCH_NOTIFICATION_LEVEL' '%s'", c.NotificationLevel))
+	}
+
+	// Verify we know the API to consume events from
+	switch c.EventAPI {
+	case "core", "events.k8s.io/v1", "events.k8s.io/v1beta1":
+	default:
+		errorList = append(errorList, fmt.Sprintf("invalid 'KIT_OVERWATCH_EVENT_API' '%s'", c.EventAPI))
+	}
+
+	// Verify we know where to look for mentions
+	for _, source := range c.MentionChain {
+		switch source {
+		case "label", "annotation", "owner-label", "owner-annotation", "namespace-label", "namespace-annotation":
+		default:
+			errorList = append(errorList, fmt.Sprintf("invalid 'KIT_OVERWATCH_MENTION_CHAIN' source '%s'", source))
+		}
+	}
+
+	// Verify we know the state store
+	switch c.StateStore {
+	case "memory", "bolt", "redis":
+	default:
+		errorList = append(errorList, fmt.Sprintf("invalid 'KIT_OVERWATCH_STATE_STORE' '%s'", c.StateStore))
+	}
+
+	switch c.StateStoreOverflow {
+	case "evict", "reject":
+	default:
+		errorList = append(errorList, fmt.Sprintf("invalid 'KIT_OVERWATCH_STATE_STORE_OVERFLOW' '%s'", c.StateStoreOverflow))
+	}
+
+	if c.StateStoreMaxEvents < 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_STATE_STORE_MAX_EVENTS' must not be negative")
+	}
+
+	if c.Lookback < 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_LOOKBACK' must not be negative")
+	}
+
+	if c.AggregateWindow < 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_AGGREGATE_WINDOW' must not be negative")
+	}
+
+	if c.StormThreshold < 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_STORM_THRESHOLD' must not be negative")
+	}
+
+	if c.StormWindow <= 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_STORM_WINDOW' must be greater than 0")
+	}
+
+	if c.StormUpdate < 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_STORM_UPDATE' must not be negative")
+	}
+
+	if c.FlapThreshold < 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_FLAP_THRESHOLD' must not be negative")
+	}
+
+	if c.FlapWindow <= 0 || c.FlapStable <= 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_FLAP_WINDOW' and 'KIT_OVERWATCH_FLAP_STABLE' must be greater than 0")
+	}
+
+	if c.IncidentQuiet <= 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_INCIDENT_QUIET' must be greater than 0")
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_SHUTDOWN_TIMEOUT' must be greater than 0")
+	}
+
+	if c.EventTTL <= 0 {
+		errorList = append(errorList, "'KIT_OVERWATCH_EVENT_TTL' must be greater than 0")
+	}
+
+	// Verify the leader lease outlasts renewals, and renewals can be retried
+	if c.LeaderElect {
+		if c.LeaderElectLeaseDuration <= c.LeaderElectRenewDeadline {
+			errorList = append(errorList, "'KIT_OVERWATCH_LEADER_ELECT_LEASE_DURATION' must be greater than 'KIT_OVERWATCH_LEADER_ELECT_RENEW_DEADLINE'")
+		}
+		if c.LeaderElectRenewDeadline <= c.LeaderElectRetryPeriod {
+			errorList = append(errorList, "'KIT_OVERWATCH_LEADER_ELECT_RENEW_DEADLINE' must be greater than 'KIT_OVERWATCH_LEADER_ELECT_RETRY_PERIOD'")
+		}
+	}
+
 	if len(errorList) != 0 {
 		return fmt.Errorf(strings.Join(errorList, "; "))
 	}
 
 	return nil
 }
+
+// Reports whether events should be watched across the whole cluster rather than
+// in each configured namespace. A namespace selector needs the whole cluster too.
+func (c *Config) WatchAllNamespaces() bool {
+	if c.NamespaceSelector != "" {
+		return true
+	}
+
+	for _, ns := range c.Namespaces {
+		if ns == NAMESPACE_ALL {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,9 @@
 package config
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -31,4 +33,250 @@ var _ = Describe("New", func() {
 			Expect(err.Error()).To(ContainSubstring("invalid 'KIT_OVERWATCH_LISTEN_ADDRESS'"))
 		})
 	})
+
+	Context("when an invalid namespace pattern is specified", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_NAMESPACE_EXCLUDE", "kube-[")
+			defer os.Unsetenv("KIT_OVERWATCH_NAMESPACE_EXCLUDE")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid namespace pattern 'kube-['"))
+		})
+	})
+
+	Context("when a list of namespaces is specified", func() {
+		It("should watch each of them", func() {
+			os.Setenv("KIT_OVERWATCH_NAMESPACE", "default,kube-system")
+			defer os.Unsetenv("KIT_OVERWATCH_NAMESPACE")
+			cfg.LoadEnvVars()
+
+			Expect(cfg.Namespaces).To(Equal([]string{"default", "kube-system"}))
+			Expect(cfg.WatchAllNamespaces()).To(BeFalse())
+		})
+	})
+
+	Context("when all namespaces are specified", func() {
+		It("should watch the whole cluster", func() {
+			os.Setenv("KIT_OVERWATCH_NAMESPACE", "*")
+			defer os.Unsetenv("KIT_OVERWATCH_NAMESPACE")
+			cfg.LoadEnvVars()
+
+			Expect(cfg.WatchAllNamespaces()).To(BeTrue())
+		})
+	})
+
+	Context("when a namespace selector is specified", func() {
+		It("should watch the whole cluster", func() {
+			cfg.NamespaceSelector = "team=payments"
+
+			Expect(cfg.WatchAllNamespaces()).To(BeTrue())
+		})
+	})
+
+	Context("when the optional notifications aren't enabled", func() {
+		It("should not send storm, flapping or resolved notifications", func() {
+			cfg.LoadEnvVars()
+
+			Expect(cfg.StormThreshold).To(Equal(0))
+			Expect(cfg.FlapThreshold).To(Equal(0))
+			Expect(cfg.Resolutions).To(BeFalse())
+		})
+	})
+
+	Context("when the leader lease is shorter than the renew deadline", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_LEADER_ELECT", "true")
+			os.Setenv("KIT_OVERWATCH_LEADER_ELECT_LEASE_DURATION", "5")
+			defer os.Unsetenv("KIT_OVERWATCH_LEADER_ELECT")
+			defer os.Unsetenv("KIT_OVERWATCH_LEADER_ELECT_LEASE_DURATION")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'KIT_OVERWATCH_LEADER_ELECT_LEASE_DURATION' must be greater"))
+		})
+	})
+
+	Context("when the event TTL is not positive", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_EVENT_TTL", "0")
+			defer os.Unsetenv("KIT_OVERWATCH_EVENT_TTL")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'KIT_OVERWATCH_EVENT_TTL' must be greater than 0"))
+		})
+	})
+
+	Context("when a client certificate is specified without its key", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_CLUSTER_CERT", "/etc/client.crt")
+			defer os.Unsetenv("KIT_OVERWATCH_CLUSTER_CERT")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'KIT_OVERWATCH_CLUSTER_CERT' and 'KIT_OVERWATCH_CLUSTER_KEY' must be set together"))
+		})
+	})
+
+	Context("when skipping verification with a CA", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_CLUSTER_INSECURE", "true")
+			os.Setenv("KIT_OVERWATCH_CLUSTER_CA", "/etc/ca.crt")
+			defer os.Unsetenv("KIT_OVERWATCH_CLUSTER_INSECURE")
+			defer os.Unsetenv("KIT_OVERWATCH_CLUSTER_CA")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'KIT_OVERWATCH_CLUSTER_INSECURE' can't be enabled"))
+		})
+	})
+
+	Context("when the shutdown timeout is not positive", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_SHUTDOWN_TIMEOUT", "0")
+			defer os.Unsetenv("KIT_OVERWATCH_SHUTDOWN_TIMEOUT")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'KIT_OVERWATCH_SHUTDOWN_TIMEOUT' must be greater than 0"))
+		})
+	})
+
+	Context("when an unknown state store overflow policy is specified", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_STATE_STORE_OVERFLOW", "panic")
+			defer os.Unsetenv("KIT_OVERWATCH_STATE_STORE_OVERFLOW")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid 'KIT_OVERWATCH_STATE_STORE_OVERFLOW' 'panic'"))
+		})
+	})
+
+	Context("when an unknown notification level is specified", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_NOTIFICATION_LEVEL", "info")
+			defer os.Unsetenv("KIT_OVERWATCH_NOTIFICATION_LEVEL")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid 'KIT_OVERWATCH_NOTIFICATION_LEVEL' 'info'"))
+		})
+	})
+
+	Context("when an unknown mention source is specified", func() {
+		It("should return an error", func() {
+			os.Setenv("KIT_OVERWATCH_MENTION_CHAIN", "label,owner-label,team-label")
+			defer os.Unsetenv("KIT_OVERWATCH_MENTION_CHAIN")
+			err := cfg.LoadEnvVars()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid 'KIT_OVERWATCH_MENTION_CHAIN' source 'team-label'"))
+		})
+	})
+})
+
+var _ = Describe("Clusters", func() {
+	var (
+		cfg  *Config
+		dir  string
+		file string
+	)
+
+	write := func(clusters string) {
+		Expect(ioutil.WriteFile(file, []byte(clusters), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "clusters")
+		Expect(err).To(BeNil())
+		file = filepath.Join(dir, "clusters.yaml")
+
+		cfg = New()
+		cfg.LoadEnvVars()
+		cfg.ListenAddress = ":80"
+		cfg.ClusterName = "local"
+		cfg.Kubeconfig = "/etc/kubeconfig"
+		cfg.Namespaces = []string{"default"}
+		cfg.NotifySlackChannel = "#ops"
+		cfg.ClustersFile = file
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should return just the config without a clusters file", func() {
+		cfg.ClustersFile = ""
+		clusters, err := cfg.Clusters()
+		Expect(err).To(BeNil())
+		Expect(clusters).To(Equal([]*Config{cfg}))
+	})
+
+	It("should return a config per cluster with its settings on top", func() {
+		write(`
+- name: prod
+  context: prod-admin
+  namespaces: ["*"]
+  slackChannel: "#prod-alerts"
+- name: staging
+  host: https://staging.example.com
+  token: s3cr3t
+  notificationLevel: ERROR
+`)
+		clusters, err := cfg.Clusters()
+		Expect(err).To(BeNil())
+		Expect(clusters).To(HaveLen(2))
+
+		Expect(clusters[0].ClusterName).To(Equal("prod"))
+		Expect(clusters[0].Kubeconfig).To(Equal("/etc/kubeconfig"))
+		Expect(clusters[0].KubeContext).To(Equal("prod-admin"))
+		Expect(clusters[0].Namespaces).To(Equal([]string{"*"}))
+		Expect(clusters[0].NotifySlackChannel).To(Equal("#prod-alerts"))
+		Expect(clusters[0].ClustersFile).To(BeEmpty())
+
+		Expect(clusters[1].ClusterName).To(Equal("staging"))
+		Expect(clusters[1].ClusterHost).To(Equal("https://staging.example.com"))
+		Expect(clusters[1].ClusterToken).To(Equal("s3cr3t"))
+		Expect(clusters[1].Namespaces).To(Equal([]string{"default"}))
+		Expect(clusters[1].NotifySlackChannel).To(Equal("#ops"))
+		Expect(clusters[1].NotificationLevel).To(Equal("ERROR"))
+	})
+
+	It("should not take credentials from the environment", func() {
+		cfg.ClusterToken = "env-token"
+		write(`[{"name": "prod"}]`)
+		clusters, err := cfg.Clusters()
+		Expect(err).To(BeNil())
+		Expect(clusters[0].ClusterToken).To(BeEmpty())
+	})
+
+	It("should error when a cluster's settings are invalid", func() {
+		write(`[{"name": "prod", "cert": "/etc/client.crt"}]`)
+		_, err := cfg.Clusters()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cluster 'prod': 'KIT_OVERWATCH_CLUSTER_CERT' and 'KIT_OVERWATCH_CLUSTER_KEY' must be set together"))
+	})
+
+	It("should error when a cluster is listed twice", func() {
+		write(`[{"name": "prod"}, {"name": "prod"}]`)
+		_, err := cfg.Clusters()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("listed more than once"))
+	})
+
+	It("should error when a cluster has no name", func() {
+		write(`[{"host": "https://prod.example.com"}]`)
+		_, err := cfg.Clusters()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("has no name"))
+	})
+
+	It("should error when no clusters are listed", func() {
+		write(`[]`)
+		_, err := cfg.Clusters()
+		Expect(err).To(HaveOccurred())
+	})
 })
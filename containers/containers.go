@@ -0,0 +1,302 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/util/clock"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/informer"
+	notifiers "github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+// The reasons of the synthetic events, most telling first
+const (
+	REASON_OOM_KILLED = "OOMKilled"
+	REASON_CRASH_LOOP = "CrashLoopBackOff"
+	REASON_FAILED     = "ContainerFailed"
+	REASON_RESTARTED  = "ContainerRestarted"
+
+	COMPONENT = "kit-overwatch"
+)
+
+// What we last saw of a container
+type container struct {
+	restarts    int32
+	waiting     string
+	termination string
+	notified    string
+}
+
+// Monitor watches the pods in a namespace (or all of them) and emits a synthetic
+// event on Deltas when a container is OOMKilled, enters CrashLoopBackOff, exits
+// with an error or restarts, which events alone often don't say. Only the most
+// telling of these is emitted per change to a container. The pods listed when it
+// starts are taken as they are, only later changes are emitted.
+//
+// Synthetic events about the same container and reason share a UID, and are
+// counted by the container's terminations, so they are deduplicated and throttled
+// like any other event, including after a restart.
+type Monitor struct {
+	Lister    deps.IObjectLister
+	Namespace string
+	Deltas    chan informer.Delta
+	Clock     clock.Clock
+
+	reflector  *informer.Reflector
+	lock       sync.Mutex
+	containers map[string]*container
+	primed     bool
+}
+
+func New(l deps.IObjectLister, namespace string) *Monitor {
+	m := &Monitor{
+		Lister:     l,
+		Namespace:  namespace,
+		Deltas:     make(chan informer.Delta, informer.DELTA_BUFFER),
+		Clock:      clock.RealClock{},
+		containers: make(map[string]*container),
+	}
+	m.reflector = &informer.Reflector{
+		Lister:  informer.ForKind(l, "Pod", namespace),
+		Handler: m,
+		Name:    "pods",
+	}
+	return m
+}
+
+// Run watches pods until the context is done, then closes Deltas
+func (m *Monitor) Run(ctx context.Context) {
+	defer close(m.Deltas)
+	m.reflector.Run(ctx)
+}
+
+// Replace is given the listed pods. Changes while we weren't watching are still
+// noticed, just not before we first knew the pods, and pods no longer listed were
+// deleted meanwhile.
+func (m *Monitor) Replace(ctx context.Context, items []runtime.Object) {
+	listed := make(map[types.UID]bool, len(items))
+	for _, item := range items {
+		pod, ok := item.(*api.Pod)
+		if !ok {
+			log.Warnf("Skip: unexpected object in pod list: %T", item)
+			continue
+		}
+		listed[pod.UID] = true
+		m.observe(ctx, pod)
+	}
+
+	m.lock.Lock()
+	for k := range m.containers {
+		if !listed[podOf(k)] {
+			delete(m.containers, k)
+		}
+	}
+	m.primed = true
+	m.lock.Unlock()
+
+	log.Debugf("Monitoring the containers of %d pods in namespace '%s'", len(listed), m.Namespace)
+}
+
+// Update is given each watched change to a pod
+func (m *Monitor) Update(ctx context.Context, t watch.EventType, obj runtime.Object) {
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		log.Warnf("Skip: unexpected object in pod watch: %T", obj)
+		return
+	}
+
+	if t == watch.Deleted {
+		m.forget(pod)
+	} else {
+		m.observe(ctx, pod)
+	}
+}
+
+// Compares the pod's containers with what we last saw of them, emitting an event for each that changed for the worse
+func (m *Monitor) observe(ctx context.Context, pod *api.Pod) {
+	statuses := append(append([]api.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+
+	var deltas []informer.Delta
+	m.lock.Lock()
+	for _, s := range statuses {
+		k := key(pod.UID, s.Name)
+		prev, known := m.containers[k]
+		if !known {
+			prev = &container{}
+		}
+
+		cur := &container{
+			restarts: s.RestartCount,
+			notified: prev.notified,
+		}
+		if s.State.Waiting != nil {
+			cur.waiting = s.State.Waiting.Reason
+		}
+		t := termination(s)
+		if t != nil {
+			cur.termination = terminationID(t)
+		}
+		m.containers[k] = cur
+
+		// The pods first listed are taken as they are
+		if !known && !m.primed {
+			continue
+		}
+
+		reason := change(prev, cur, t, s)
+		if reason == "" {
+			continue
+		}
+		if t != nil {
+			cur.notified = cur.termination
+		}
+		deltas = append(deltas, m.delta(pod, s, t, reason))
+	}
+	m.lock.Unlock()
+
+	for _, d := range deltas {
+		log.Debugf("Container %s of pod %s: %s", d.Container.Name, pod.Name, d.Event.Reason)
+		select {
+		case m.Deltas <- d:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Monitor) forget(pod *api.Pod) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	prefix := key(pod.UID, "")
+	for k := range m.containers {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.containers, k)
+		}
+	}
+}
+
+// The reason to emit an event for a container's change, or empty if there's nothing worth it
+func change(prev, cur *container, t *api.ContainerStateTerminated, s api.ContainerStatus) string {
+	terminated := t != nil && cur.termination != prev.termination
+
+	switch {
+	case terminated && t.Reason == REASON_OOM_KILLED:
+		return REASON_OOM_KILLED
+	case cur.waiting == REASON_CRASH_LOOP && prev.waiting != REASON_CRASH_LOOP:
+		return REASON_CRASH_LOOP
+	case terminated && (t.ExitCode != 0 || t.Signal != 0):
+		return REASON_FAILED
+	case cur.restarts > prev.restarts && (t == nil || cur.termination != prev.notified):
+		// A restart is only news if the termination behind it wasn't already notified
+		return REASON_RESTARTED
+	}
+	return ""
+}
+
+// Builds the synthetic event about a container
+func (m *Monitor) delta(pod *api.Pod, s api.ContainerStatus, t *api.ContainerStateTerminated, reason string) informer.Delta {
+	c := &notifiers.Container{
+		Name:         s.Name,
+		RestartCount: s.RestartCount,
+	}
+
+	// Terminations so far, which the current state only counts once the container has restarted
+	count := s.RestartCount
+	if s.State.Terminated != nil {
+		count++
+	}
+	if t != nil {
+		c.Reason = t.Reason
+		c.ExitCode = t.ExitCode
+		c.Signal = t.Signal
+		c.Message = strings.TrimSpace(t.Message)
+	}
+
+	now := unversioned.NewTime(m.Clock.Now())
+	e := api.Event{
+		ObjectMeta: api.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%s.%s", pod.Name, s.Name, strings.ToLower(reason)),
+			Namespace: pod.Namespace,
+			UID:       types.UID(fmt.Sprintf("%s/%s", key(pod.UID, s.Name), reason)),
+		},
+		InvolvedObject: api.ObjectReference{
+			Kind:            "Pod",
+			Namespace:       pod.Namespace,
+			Name:            pod.Name,
+			UID:             pod.UID,
+			ResourceVersion: pod.ResourceVersion,
+			FieldPath:       fmt.Sprintf("spec.containers{%s}", s.Name),
+		},
+		Reason:         reason,
+		Message:        message(reason, c),
+		Source:         api.EventSource{Component: COMPONENT, Host: pod.Spec.NodeName},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          count,
+		Type:           api.EventTypeWarning,
+	}
+
+	return informer.Delta{Type: watch.Added, Event: e, Container: c}
+}
+
+// Describes what happened to a container, eg. Container web was OOMKilled (exit code 137), restarted 3 times
+func message(reason string, c *notifiers.Container) string {
+	var what string
+	switch reason {
+	case REASON_OOM_KILLED:
+		what = "was OOMKilled"
+	case REASON_CRASH_LOOP:
+		what = "is in CrashLoopBackOff"
+	case REASON_FAILED:
+		what = "failed"
+	case REASON_RESTARTED:
+		what = "has restarted"
+	}
+
+	msg := fmt.Sprintf("Container %s %s", c.Name, what)
+	if c.Reason != "" || c.ExitCode != 0 || c.Signal != 0 {
+		msg = fmt.Sprintf("%s (exit code %d", msg, c.ExitCode)
+		if c.Signal != 0 {
+			msg = fmt.Sprintf("%s, signal %d", msg, c.Signal)
+		}
+		msg += ")"
+	}
+	msg = fmt.Sprintf("%s, restarted %d times", msg, c.RestartCount)
+	if c.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, c.Message)
+	}
+	return msg
+}
+
+// How the container last terminated: now if it is terminated, otherwise before it was last restarted
+func termination(s api.ContainerStatus) *api.ContainerStateTerminated {
+	if s.State.Terminated != nil {
+		return s.State.Terminated
+	}
+	return s.LastTerminationState.Terminated
+}
+
+// Tells terminations apart; the same one moves from State to LastTerminationState on restart
+func terminationID(t *api.ContainerStateTerminated) string {
+	return fmt.Sprintf("%s@%d", t.ContainerID, t.FinishedAt.Unix())
+}
+
+func key(pod types.UID, container string) string {
+	return string(pod) + "/" + container
+}
+
+// The pod a container's key belongs to
+func podOf(k string) types.UID {
+	return types.UID(strings.SplitN(k, "/", 2)[0])
+}
@@ -0,0 +1,13 @@
+package containers
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestContainersSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Containers Suite")
+}
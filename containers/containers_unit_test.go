@@ -0,0 +1,317 @@
+// +build unit
+
+package containers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/util/clock"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/informer"
+	notifiers "github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+func newPod(statuses ...api.ContainerStatus) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web-1", Namespace: "default", UID: types.UID("uid-1"), ResourceVersion: "5"},
+		Spec:       api.PodSpec{NodeName: "node-1"},
+		Status:     api.PodStatus{ContainerStatuses: statuses},
+	}
+}
+
+func running(name string, restarts int32) api.ContainerStatus {
+	return api.ContainerStatus{
+		Name:         name,
+		RestartCount: restarts,
+		State:        api.ContainerState{Running: &api.ContainerStateRunning{}},
+	}
+}
+
+func terminated(reason string, exitCode, signal int32, finished time.Time) *api.ContainerStateTerminated {
+	return &api.ContainerStateTerminated{
+		Reason:      reason,
+		ExitCode:    exitCode,
+		Signal:      signal,
+		Message:     "out of memory\n",
+		FinishedAt:  unversioned.NewTime(finished),
+		ContainerID: "docker://abc",
+	}
+}
+
+var _ = Describe("Monitor", func() {
+	var (
+		fakeLister *depsfakes.FakeIObjectLister
+		fakeWatch  *watch.FakeWatcher
+		clk        *clock.FakeClock
+		now        time.Time
+		m          *Monitor
+		ctx        context.Context
+		cancel     context.CancelFunc
+	)
+
+	// Returns the deltas emitted so far
+	emitted := func() []informer.Delta {
+		var deltas []informer.Delta
+		for {
+			select {
+			case d := <-m.Deltas:
+				deltas = append(deltas, d)
+			default:
+				return deltas
+			}
+		}
+	}
+
+	reasons := func(deltas []informer.Delta) []string {
+		var reasons []string
+		for _, d := range deltas {
+			reasons = append(reasons, d.Event.Reason)
+		}
+		return reasons
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		now = time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC)
+		clk = clock.NewFakeClock(now)
+
+		fakeWatch = watch.NewFake()
+		fakeLister = &depsfakes.FakeIObjectLister{}
+		fakeLister.ListReturns(&api.PodList{ListMeta: unversioned.ListMeta{ResourceVersion: "1"}}, nil)
+		fakeLister.WatchReturns(fakeWatch, nil)
+
+		m = New(fakeLister, "default")
+		m.Clock = clk
+		m.primed = true
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should emit OOMKilled with how the container terminated", func() {
+		m.observe(ctx, newPod(running("web", 0)))
+
+		c := running("web", 0)
+		c.State = api.ContainerState{Terminated: terminated("OOMKilled", 137, 9, now)}
+		m.observe(ctx, newPod(c))
+
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_OOM_KILLED}))
+
+		d := deltas[0]
+		Expect(d.Type).To(Equal(watch.Added))
+		Expect(d.Container).To(Equal(&notifiers.Container{
+			Name:         "web",
+			RestartCount: 0,
+			Reason:       "OOMKilled",
+			ExitCode:     137,
+			Signal:       9,
+			Message:      "out of memory",
+		}))
+
+		e := d.Event
+		Expect(string(e.ObjectMeta.UID)).To(Equal("uid-1/web/OOMKilled"))
+		Expect(e.ObjectMeta.Namespace).To(Equal("default"))
+		Expect(e.InvolvedObject.Kind).To(Equal("Pod"))
+		Expect(e.InvolvedObject.Name).To(Equal("web-1"))
+		Expect(e.InvolvedObject.FieldPath).To(Equal("spec.containers{web}"))
+		Expect(e.Source).To(Equal(api.EventSource{Component: COMPONENT, Host: "node-1"}))
+		Expect(e.Type).To(Equal(api.EventTypeWarning))
+		Expect(e.Count).To(Equal(int32(1)))
+		Expect(e.LastTimestamp.Time).To(Equal(now))
+		Expect(e.Message).To(Equal("Container web was OOMKilled (exit code 137, signal 9), restarted 0 times: out of memory"))
+	})
+
+	It("should not emit again when the container restarts after a termination already emitted", func() {
+		m.observe(ctx, newPod(running("web", 0)))
+
+		c := running("web", 0)
+		c.State = api.ContainerState{Terminated: terminated("OOMKilled", 137, 0, now)}
+		m.observe(ctx, newPod(c))
+
+		c = running("web", 1)
+		c.LastTerminationState = api.ContainerState{Terminated: terminated("OOMKilled", 137, 0, now)}
+		m.observe(ctx, newPod(c))
+
+		Expect(reasons(emitted())).To(Equal([]string{REASON_OOM_KILLED}))
+	})
+
+	It("should count a termination the same before and after the restart", func() {
+		c := running("web", 1)
+		c.LastTerminationState = api.ContainerState{Terminated: terminated("OOMKilled", 137, 0, now)}
+		m.observe(ctx, newPod(c))
+
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_OOM_KILLED}))
+		Expect(deltas[0].Event.Count).To(Equal(int32(1)))
+	})
+
+	It("should emit CrashLoopBackOff with the last termination", func() {
+		m.observe(ctx, newPod(running("web", 2)))
+		emitted()
+
+		c := running("web", 3)
+		c.State = api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+		c.LastTerminationState = api.ContainerState{Terminated: terminated("Error", 1, 0, now)}
+		m.observe(ctx, newPod(c))
+		m.observe(ctx, newPod(c))
+
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_CRASH_LOOP}))
+		Expect(deltas[0].Container.ExitCode).To(Equal(int32(1)))
+		Expect(deltas[0].Event.Count).To(Equal(int32(3)))
+	})
+
+	It("should emit a failure for a non-zero exit code", func() {
+		m.observe(ctx, newPod(running("web", 0)))
+
+		c := running("web", 0)
+		c.State = api.ContainerState{Terminated: terminated("Error", 2, 0, now)}
+		m.observe(ctx, newPod(c))
+
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_FAILED}))
+		Expect(deltas[0].Event.Message).To(HavePrefix("Container web failed (exit code 2), restarted 0 times"))
+	})
+
+	It("should not emit anything when a container completes", func() {
+		m.observe(ctx, newPod(running("web", 0)))
+
+		c := running("web", 0)
+		c.State = api.ContainerState{Terminated: terminated("Completed", 0, 0, now)}
+		m.observe(ctx, newPod(c))
+
+		Expect(emitted()).To(BeEmpty())
+	})
+
+	It("should emit a restart that isn't explained by a failure", func() {
+		m.observe(ctx, newPod(running("web", 0)))
+
+		c := running("web", 1)
+		c.LastTerminationState = api.ContainerState{Terminated: terminated("Completed", 0, 0, now)}
+		m.observe(ctx, newPod(c))
+
+		// Restarts can also jump without us seeing why
+		m.observe(ctx, newPod(running("web", 4)))
+
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_RESTARTED, REASON_RESTARTED}))
+		Expect(deltas[1].Event.Count).To(Equal(int32(4)))
+		Expect(deltas[1].Event.Message).To(Equal("Container web has restarted, restarted 4 times"))
+	})
+
+	It("should monitor init containers too", func() {
+		pod := newPod()
+		m.observe(ctx, pod)
+
+		c := running("migrate", 0)
+		c.State = api.ContainerState{Terminated: terminated("Error", 1, 0, now)}
+		pod.Status.InitContainerStatuses = []api.ContainerStatus{c}
+		m.observe(ctx, pod)
+
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_FAILED}))
+		Expect(deltas[0].Container.Name).To(Equal("migrate"))
+	})
+
+	It("should take the pods first listed as they are", func() {
+		m.primed = false
+		c := running("web", 3)
+		c.State = api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+		m.observe(ctx, newPod(c))
+
+		Expect(emitted()).To(BeEmpty())
+	})
+
+	It("should compare pods it didn't know before from scratch", func() {
+		c := running("web", 0)
+		c.State = api.ContainerState{Terminated: terminated("OOMKilled", 137, 0, now)}
+		m.observe(ctx, newPod(c))
+
+		Expect(reasons(emitted())).To(Equal([]string{REASON_OOM_KILLED}))
+	})
+
+	Context("when running", func() {
+		BeforeEach(func() {
+			m.primed = false
+			c := running("web", 3)
+			c.State = api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+			fakeLister.ListReturns(&api.PodList{
+				ListMeta: unversioned.ListMeta{ResourceVersion: "1"},
+				Items:    []api.Pod{*newPod(c)},
+			}, nil)
+		})
+
+		It("should list then watch pods in its namespace", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			kind, namespace, opts := fakeLister.ListArgsForCall(0)
+			Expect(kind).To(Equal("Pod"))
+			Expect(namespace).To(Equal("default"))
+			Expect(opts.ResourceVersion).To(Equal("0"))
+
+			kind, namespace, opts = fakeLister.WatchArgsForCall(0)
+			Expect(kind).To(Equal("Pod"))
+			Expect(namespace).To(Equal("default"))
+			Expect(opts.ResourceVersion).To(Equal("1"))
+		})
+
+		It("should emit changes to watched pods", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			c := running("web", 3)
+			c.State = api.ContainerState{Terminated: terminated("OOMKilled", 137, 0, now)}
+			fakeWatch.Modify(newPod(c))
+
+			var d informer.Delta
+			Eventually(m.Deltas).Should(Receive(&d))
+			Expect(d.Event.Reason).To(Equal(REASON_OOM_KILLED))
+		})
+
+		It("should forget deleted pods", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			fakeWatch.Delete(newPod(running("web", 3)))
+			Eventually(func() int {
+				m.lock.Lock()
+				defer m.lock.Unlock()
+				return len(m.containers)
+			}).Should(Equal(0))
+		})
+
+		It("should forget pods that are no longer listed when relisting", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			fakeLister.ListReturns(&api.PodList{ListMeta: unversioned.ListMeta{ResourceVersion: "2"}}, nil)
+			fakeWatch.Error(&errors.NewGone("too old resource version").ErrStatus)
+			Eventually(fakeLister.ListCallCount).Should(Equal(2))
+			Eventually(func() int {
+				m.lock.Lock()
+				defer m.lock.Unlock()
+				return len(m.containers)
+			}).Should(Equal(0))
+		})
+
+		It("should close Deltas once stopped", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			cancel()
+			Eventually(m.Deltas).Should(BeClosed())
+		})
+	})
+})
@@ -0,0 +1,12 @@
+package deps
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_iclusterstatus.go . IClusterStatus
+
+// Interface for reporting how watching one of the clusters is going
+type IClusterStatus interface {
+	// The cluster's display name
+	Name() string
+
+	// Returns why the cluster can't currently be watched, or nil if it can
+	Health() error
+}
@@ -0,0 +1,13 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_idiscoveryclient.go . IDiscoveryClient
+
+// Interface for faking discovery of the API groups and resources the cluster serves
+type IDiscoveryClient interface {
+	ServerGroups() (*unversioned.APIGroupList, error)
+	ServerResourcesForGroupVersion(groupVersion string) (*unversioned.APIResourceList, error)
+}
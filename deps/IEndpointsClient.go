@@ -0,0 +1,14 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_iendpointsclient.go . IEndpointsClient
+
+// Interface for faking the subset of the Kubernetes endpoints client used as a leader election lock
+type IEndpointsClient interface {
+	Get(name string) (*api.Endpoints, error)
+	Create(endpoints *api.Endpoints) (*api.Endpoints, error)
+	Update(endpoints *api.Endpoints) (*api.Endpoints, error)
+}
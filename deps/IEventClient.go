@@ -0,0 +1,14 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_ieventclient.go . IEventClient
+
+// Interface for faking the subset of the Kubernetes events client we list and watch with
+type IEventClient interface {
+	List(opts api.ListOptions) (*api.EventList, error)
+	Watch(opts api.ListOptions) (watch.Interface, error)
+}
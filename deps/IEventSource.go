@@ -0,0 +1,15 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_ieventsource.go . IEventSource
+
+// Interface for the API events are listed and watched from, eg. core/v1 or events.k8s.io
+type IEventSource interface {
+	List(opts api.ListOptions) (runtime.Object, error)
+	Watch(opts api.ListOptions) (watch.Interface, error)
+}
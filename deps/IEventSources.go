@@ -0,0 +1,8 @@
+package deps
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_ieventsources.go . IEventSources
+
+// Interface for getting the API a namespace's events are listed and watched from
+type IEventSources interface {
+	Events(namespace string) IEventSource
+}
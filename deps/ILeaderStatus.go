@@ -0,0 +1,12 @@
+package deps
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_ileaderstatus.go . ILeaderStatus
+
+// Interface for reporting whether this instance currently holds the leader election lock
+type ILeaderStatus interface {
+	// Whether the lock is held in any cluster
+	IsLeader() bool
+
+	// Whether the lock is held in the named cluster
+	IsLeaderOf(cluster string) bool
+}
@@ -0,0 +1,12 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_imentionresolver.go . IMentionResolver
+
+// Interface for working out who to mention about the object an event involves
+type IMentionResolver interface {
+	ResolveEvent(e api.Event) string
+}
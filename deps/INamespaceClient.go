@@ -0,0 +1,12 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_inamespaceclient.go . INamespaceClient
+
+// Interface for faking the Kubernetes namespaces client
+type INamespaceClient interface {
+	List(opts api.ListOptions) (*api.NamespaceList, error)
+}
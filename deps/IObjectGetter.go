@@ -0,0 +1,14 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_iobjectgetter.go . IObjectGetter
+
+// Interface for faking lookups of any kind of Kubernetes object's metadata. The
+// apiVersion tells apart kinds of the same name in different groups, eg.
+// stable.example.com/v1; when empty the kind is looked up by name alone.
+type IObjectGetter interface {
+	Get(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error)
+}
@@ -0,0 +1,15 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_iobjectlister.go . IObjectLister
+
+// Interface for faking lists and watches of any kind of Kubernetes object
+type IObjectLister interface {
+	List(kind, namespace string, opts api.ListOptions) (runtime.Object, error)
+	Watch(kind, namespace string, opts api.ListOptions) (watch.Interface, error)
+}
@@ -0,0 +1,12 @@
+package deps
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_ipodgetter.go . IPodGetter
+
+// Interface for faking lookups of a pod, including its status
+type IPodGetter interface {
+	Pod(namespace, name string) (*api.Pod, error)
+}
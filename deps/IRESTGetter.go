@@ -0,0 +1,13 @@
+package deps
+
+import (
+	"io"
+)
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_irestgetter.go . IRESTGetter
+
+// Interface for faking raw GETs against the Kubernetes API
+type IRESTGetter interface {
+	GetRaw(path string, params map[string]string) ([]byte, error)
+	StreamRaw(path string, params map[string]string) (io.ReadCloser, error)
+}
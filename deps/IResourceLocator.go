@@ -0,0 +1,8 @@
+package deps
+
+//go:generate counterfeiter -o ../fakes/depsfakes/fake_iresourcelocator.go . IResourceLocator
+
+// Interface for finding the REST path a kind of object is served at, eg. through discovery
+type IResourceLocator interface {
+	Collection(kind, namespace string) (string, error)
+}
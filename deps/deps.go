@@ -5,9 +5,14 @@ package deps
 
 import (
 	"github.com/cactus/go-statsd-client/statsd"
+
+	"github.com/InVisionApp/kit-overwatch/state"
 )
 
 type Dependencies struct {
 	StatsD   statsd.Statter
 	DDClient IDataDogClient
+	Leader   ILeaderStatus
+	State    state.StateStore
+	Clusters []IClusterStatus
 }
@@ -0,0 +1,139 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+// An events.k8s.io event. The client we build against predates this API, so only
+// the fields we use are declared.
+type Event struct {
+	unversioned.TypeMeta `json:",inline"`
+	ObjectMeta           api.ObjectMeta `json:"metadata,omitempty"`
+
+	EventTime           unversioned.Time     `json:"eventTime,omitempty"`
+	Series              *EventSeries         `json:"series,omitempty"`
+	ReportingController string               `json:"reportingController,omitempty"`
+	ReportingInstance   string               `json:"reportingInstance,omitempty"`
+	Action              string               `json:"action,omitempty"`
+	Reason              string               `json:"reason,omitempty"`
+	Regarding           api.ObjectReference  `json:"regarding,omitempty"`
+	Related             *api.ObjectReference `json:"related,omitempty"`
+	Note                string               `json:"note,omitempty"`
+	Type                string               `json:"type,omitempty"`
+
+	DeprecatedSource         api.EventSource  `json:"deprecatedSource,omitempty"`
+	DeprecatedFirstTimestamp unversioned.Time `json:"deprecatedFirstTimestamp,omitempty"`
+	DeprecatedLastTimestamp  unversioned.Time `json:"deprecatedLastTimestamp,omitempty"`
+	DeprecatedCount          int32            `json:"deprecatedCount,omitempty"`
+}
+
+// How often an event has been observed and when it last was
+type EventSeries struct {
+	Count            int32            `json:"count"`
+	LastObservedTime unversioned.Time `json:"lastObservedTime"`
+}
+
+type EventList struct {
+	unversioned.TypeMeta `json:",inline"`
+	unversioned.ListMeta `json:"metadata,omitempty"`
+
+	Items []Event `json:"items"`
+}
+
+// API lists and watches events from an events.k8s.io version over raw REST
+type API struct {
+	REST         deps.IRESTGetter
+	GroupVersion string
+	Namespace    string
+}
+
+func New(r deps.IRESTGetter, groupVersion, namespace string) *API {
+	return &API{
+		REST:         r,
+		GroupVersion: groupVersion,
+		Namespace:    namespace,
+	}
+}
+
+func (a *API) List(opts api.ListOptions) (runtime.Object, error) {
+	raw, err := a.REST.GetRaw(a.path(), params(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	list := &EventList{}
+	if err := json.Unmarshal(raw, list); err != nil {
+		return nil, fmt.Errorf("Unable to read %s events: %v", a.GroupVersion, err.Error())
+	}
+	return list, nil
+}
+
+func (a *API) Watch(opts api.ListOptions) (watch.Interface, error) {
+	p := params(opts)
+	p["watch"] = "true"
+
+	body, err := a.REST.StreamRaw(a.path(), p)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch.NewStreamWatcher(&decoder{
+		body:    body,
+		decoder: json.NewDecoder(body),
+	}), nil
+}
+
+// The events collection, eg. /apis/events.k8s.io/v1/namespaces/default/events
+func (a *API) path() string {
+	if a.Namespace == api.NamespaceAll {
+		return path.Join("/apis", a.GroupVersion, "events")
+	}
+	return path.Join("/apis", a.GroupVersion, "namespaces", a.Namespace, "events")
+}
+
+func params(opts api.ListOptions) map[string]string {
+	p := make(map[string]string)
+	if opts.ResourceVersion != "" {
+		p["resourceVersion"] = opts.ResourceVersion
+	}
+	return p
+}
+
+// Decodes the JSON stream of a watch into events, or statuses for errors
+type decoder struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+}
+
+func (d *decoder) Decode() (watch.EventType, runtime.Object, error) {
+	var we struct {
+		Type   watch.EventType `json:"type"`
+		Object json.RawMessage `json:"object"`
+	}
+	if err := d.decoder.Decode(&we); err != nil {
+		return "", nil, err
+	}
+
+	var obj runtime.Object = &Event{}
+	if we.Type == watch.Error {
+		obj = &unversioned.Status{}
+	}
+	if err := json.Unmarshal(we.Object, obj); err != nil {
+		return "", nil, fmt.Errorf("Unable to read %s watch event: %v", we.Type, err.Error())
+	}
+	return we.Type, obj, nil
+}
+
+func (d *decoder) Close() {
+	d.body.Close()
+}
@@ -0,0 +1,32 @@
+package events
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+// Core lists and watches events from the core/v1 API
+type Core struct {
+	Client deps.IEventClient
+}
+
+func NewCore(c deps.IEventClient) *Core {
+	return &Core{
+		Client: c,
+	}
+}
+
+func (c *Core) List(opts api.ListOptions) (runtime.Object, error) {
+	list, err := c.Client.List(opts)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Core) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.Client.Watch(opts)
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"fmt"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+// The APIs events can be consumed from
+const (
+	API_CORE           = "core"
+	API_EVENTS_V1      = "events.k8s.io/v1"
+	API_EVENTS_V1BETA1 = "events.k8s.io/v1beta1"
+)
+
+// The count of an events.k8s.io event that has no series, ie. has happened once
+const SINGLE_COUNT = 1
+
+// Turns an event from either API into a core event, along with the details only
+// events.k8s.io events carry. Their series is folded into Count and LastTimestamp so
+// repeats are noticed the same way as for core events.
+func Normalize(obj runtime.Object) (api.Event, *deps.EventDetails, error) {
+	switch e := obj.(type) {
+	case *api.Event:
+		return *e, nil, nil
+	case *Event:
+		return normalize(e)
+	}
+	return api.Event{}, nil, fmt.Errorf("unexpected object %T", obj)
+}
+
+func normalize(e *Event) (api.Event, *deps.EventDetails, error) {
+	ne := api.Event{
+		ObjectMeta:     e.ObjectMeta,
+		InvolvedObject: e.Regarding,
+		Reason:         e.Reason,
+		Message:        e.Note,
+		Type:           e.Type,
+		Source: api.EventSource{
+			Component: e.ReportingController,
+			Host:      e.DeprecatedSource.Host,
+		},
+		FirstTimestamp: e.EventTime,
+		Count:          SINGLE_COUNT,
+	}
+	if ne.Source.Component == "" {
+		ne.Source.Component = e.DeprecatedSource.Component
+	}
+	if ne.FirstTimestamp.IsZero() {
+		ne.FirstTimestamp = e.DeprecatedFirstTimestamp
+	}
+	ne.LastTimestamp = ne.FirstTimestamp
+
+	switch {
+	case e.Series != nil:
+		ne.Count = e.Series.Count
+		ne.LastTimestamp = e.Series.LastObservedTime
+	case e.DeprecatedCount > 0:
+		// Events converted from the core API keep their count here
+		ne.Count = e.DeprecatedCount
+		if !e.DeprecatedLastTimestamp.IsZero() {
+			ne.LastTimestamp = e.DeprecatedLastTimestamp
+		}
+	}
+
+	return ne, &deps.EventDetails{
+		Action:              e.Action,
+		ReportingController: e.ReportingController,
+		ReportingInstance:   e.ReportingInstance,
+		Related:             e.Related,
+	}, nil
+}
@@ -0,0 +1,146 @@
+// +build unit
+
+package events
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+)
+
+var _ = Describe("Normalize", func() {
+	var (
+		first = unversioned.NewTime(time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC))
+		last  = unversioned.NewTime(time.Date(2017, 1, 1, 12, 5, 0, 0, time.UTC))
+	)
+
+	It("should pass core events through without details", func() {
+		e, details, err := Normalize(&api.Event{Reason: "BackOff", Count: 4})
+		Expect(err).To(BeNil())
+		Expect(e.Reason).To(Equal("BackOff"))
+		Expect(e.Count).To(Equal(int32(4)))
+		Expect(details).To(BeNil())
+	})
+
+	It("should map an events.k8s.io event and its series onto a core event", func() {
+		e, details, err := Normalize(&Event{
+			ObjectMeta:          api.ObjectMeta{Name: "web-1.1", Namespace: "default"},
+			EventTime:           first,
+			Series:              &EventSeries{Count: 3, LastObservedTime: last},
+			ReportingController: "kubelet",
+			ReportingInstance:   "node-1",
+			Action:              "Restarting",
+			Reason:              "BackOff",
+			Regarding:           api.ObjectReference{Kind: "Pod", Name: "web-1"},
+			Related:             &api.ObjectReference{Kind: "Node", Name: "node-1"},
+			Note:                "Back-off restarting failed container",
+			Type:                "Warning",
+		})
+		Expect(err).To(BeNil())
+		Expect(e.InvolvedObject.Name).To(Equal("web-1"))
+		Expect(e.Message).To(Equal("Back-off restarting failed container"))
+		Expect(e.Source.Component).To(Equal("kubelet"))
+		Expect(e.Count).To(Equal(int32(3)))
+		Expect(e.FirstTimestamp).To(Equal(first))
+		Expect(e.LastTimestamp).To(Equal(last))
+		Expect(details.Action).To(Equal("Restarting"))
+		Expect(details.ReportingInstance).To(Equal("node-1"))
+		Expect(details.Related.Name).To(Equal("node-1"))
+	})
+
+	It("should count an event without a series once", func() {
+		e, _, err := Normalize(&Event{EventTime: first})
+		Expect(err).To(BeNil())
+		Expect(e.Count).To(Equal(int32(SINGLE_COUNT)))
+		Expect(e.LastTimestamp).To(Equal(first))
+	})
+
+	It("should fall back to the deprecated fields of converted core events", func() {
+		e, _, err := Normalize(&Event{
+			DeprecatedSource:         api.EventSource{Component: "scheduler", Host: "node-2"},
+			DeprecatedFirstTimestamp: first,
+			DeprecatedLastTimestamp:  last,
+			DeprecatedCount:          7,
+		})
+		Expect(err).To(BeNil())
+		Expect(e.Source.Component).To(Equal("scheduler"))
+		Expect(e.Source.Host).To(Equal("node-2"))
+		Expect(e.Count).To(Equal(int32(7)))
+		Expect(e.FirstTimestamp).To(Equal(first))
+		Expect(e.LastTimestamp).To(Equal(last))
+	})
+
+	It("should error on other objects", func() {
+		_, _, err := Normalize(&api.Pod{})
+		Expect(err).ToNot(BeNil())
+	})
+})
+
+var _ = Describe("API", func() {
+	var (
+		fakeREST *depsfakes.FakeIRESTGetter
+		a        *API
+	)
+
+	BeforeEach(func() {
+		fakeREST = &depsfakes.FakeIRESTGetter{}
+		a = New(fakeREST, API_EVENTS_V1, "default")
+	})
+
+	It("should list events in the namespace", func() {
+		fakeREST.GetRawReturns([]byte(`{"metadata":{"resourceVersion":"42"},"items":[{"metadata":{"name":"web-1.1"},"reason":"BackOff"}]}`), nil)
+
+		list, err := a.List(api.ListOptions{ResourceVersion: "0"})
+		Expect(err).To(BeNil())
+		Expect(list.(*EventList).ResourceVersion).To(Equal("42"))
+		Expect(list.(*EventList).Items[0].Reason).To(Equal("BackOff"))
+
+		path, params := fakeREST.GetRawArgsForCall(0)
+		Expect(path).To(Equal("/apis/events.k8s.io/v1/namespaces/default/events"))
+		Expect(params).To(Equal(map[string]string{"resourceVersion": "0"}))
+	})
+
+	It("should list events in all namespaces", func() {
+		fakeREST.GetRawReturns([]byte(`{"items":[]}`), nil)
+		a.Namespace = api.NamespaceAll
+
+		_, err := a.List(api.ListOptions{})
+		Expect(err).To(BeNil())
+		path, _ := fakeREST.GetRawArgsForCall(0)
+		Expect(path).To(Equal("/apis/events.k8s.io/v1/events"))
+	})
+
+	It("should decode watched events and errors", func() {
+		stream := `{"type":"ADDED","object":{"metadata":{"name":"web-1.1","resourceVersion":"43"},"note":"Pulled"}}
+{"type":"ERROR","object":{"kind":"Status","code":410,"reason":"Gone"}}
+`
+		fakeREST.StreamRawStub = func(string, map[string]string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(stream)), nil
+		}
+
+		wi, err := a.Watch(api.ListOptions{ResourceVersion: "42"})
+		Expect(err).To(BeNil())
+		defer wi.Stop()
+
+		_, params := fakeREST.StreamRawArgsForCall(0)
+		Expect(params).To(Equal(map[string]string{"resourceVersion": "42", "watch": "true"}))
+
+		var we watch.Event
+		Eventually(wi.ResultChan()).Should(Receive(&we))
+		Expect(we.Type).To(Equal(watch.Added))
+		Expect(we.Object.(*Event).Note).To(Equal("Pulled"))
+
+		Eventually(wi.ResultChan()).Should(Receive(&we))
+		Expect(we.Type).To(Equal(watch.Error))
+		Expect(we.Object.(*unversioned.Status).Code).To(Equal(int32(410)))
+	})
+})
@@ -0,0 +1,29 @@
+package events
+
+import (
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/rest"
+)
+
+// Sources lists and watches each namespace's events from the configured API
+type Sources struct {
+	Client *client.Client
+	API    string
+}
+
+func NewSources(cfg *config.Config, c *client.Client) *Sources {
+	return &Sources{
+		Client: c,
+		API:    cfg.EventAPI,
+	}
+}
+
+func (s *Sources) Events(namespace string) deps.IEventSource {
+	if s.API == API_CORE {
+		return NewCore(s.Client.Events(namespace))
+	}
+	return New(rest.New(s.Client.RESTClient), s.API, namespace)
+}
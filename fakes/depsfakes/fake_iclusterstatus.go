@@ -0,0 +1,99 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+type FakeIClusterStatus struct {
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct{}
+	nameReturns     struct {
+		result1 string
+	}
+	HealthStub        func() error
+	healthMutex       sync.RWMutex
+	healthArgsForCall []struct{}
+	healthReturns     struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIClusterStatus) Name() string {
+	fake.nameMutex.Lock()
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct{}{})
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if fake.NameStub != nil {
+		return fake.NameStub()
+	} else {
+		return fake.nameReturns.result1
+	}
+}
+
+func (fake *FakeIClusterStatus) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeIClusterStatus) NameReturns(result1 string) {
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeIClusterStatus) Health() error {
+	fake.healthMutex.Lock()
+	fake.healthArgsForCall = append(fake.healthArgsForCall, struct{}{})
+	fake.recordInvocation("Health", []interface{}{})
+	fake.healthMutex.Unlock()
+	if fake.HealthStub != nil {
+		return fake.HealthStub()
+	} else {
+		return fake.healthReturns.result1
+	}
+}
+
+func (fake *FakeIClusterStatus) HealthCallCount() int {
+	fake.healthMutex.RLock()
+	defer fake.healthMutex.RUnlock()
+	return len(fake.healthArgsForCall)
+}
+
+func (fake *FakeIClusterStatus) HealthReturns(result1 error) {
+	fake.HealthStub = nil
+	fake.healthReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClusterStatus) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	fake.healthMutex.RLock()
+	defer fake.healthMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIClusterStatus) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IClusterStatus = new(FakeIClusterStatus)
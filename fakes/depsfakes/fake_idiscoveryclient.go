@@ -0,0 +1,114 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+type FakeIDiscoveryClient struct {
+	ServerGroupsStub        func() (*unversioned.APIGroupList, error)
+	serverGroupsMutex       sync.RWMutex
+	serverGroupsArgsForCall []struct{}
+	serverGroupsReturns     struct {
+		result1 *unversioned.APIGroupList
+		result2 error
+	}
+	ServerResourcesForGroupVersionStub        func(groupVersion string) (*unversioned.APIResourceList, error)
+	serverResourcesForGroupVersionMutex       sync.RWMutex
+	serverResourcesForGroupVersionArgsForCall []struct {
+		groupVersion string
+	}
+	serverResourcesForGroupVersionReturns struct {
+		result1 *unversioned.APIResourceList
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIDiscoveryClient) ServerGroups() (*unversioned.APIGroupList, error) {
+	fake.serverGroupsMutex.Lock()
+	fake.serverGroupsArgsForCall = append(fake.serverGroupsArgsForCall, struct{}{})
+	fake.recordInvocation("ServerGroups", []interface{}{})
+	fake.serverGroupsMutex.Unlock()
+	if fake.ServerGroupsStub != nil {
+		return fake.ServerGroupsStub()
+	} else {
+		return fake.serverGroupsReturns.result1, fake.serverGroupsReturns.result2
+	}
+}
+
+func (fake *FakeIDiscoveryClient) ServerGroupsCallCount() int {
+	fake.serverGroupsMutex.RLock()
+	defer fake.serverGroupsMutex.RUnlock()
+	return len(fake.serverGroupsArgsForCall)
+}
+
+func (fake *FakeIDiscoveryClient) ServerGroupsReturns(result1 *unversioned.APIGroupList, result2 error) {
+	fake.ServerGroupsStub = nil
+	fake.serverGroupsReturns = struct {
+		result1 *unversioned.APIGroupList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIDiscoveryClient) ServerResourcesForGroupVersion(groupVersion string) (*unversioned.APIResourceList, error) {
+	fake.serverResourcesForGroupVersionMutex.Lock()
+	fake.serverResourcesForGroupVersionArgsForCall = append(fake.serverResourcesForGroupVersionArgsForCall, struct {
+		groupVersion string
+	}{groupVersion})
+	fake.recordInvocation("ServerResourcesForGroupVersion", []interface{}{groupVersion})
+	fake.serverResourcesForGroupVersionMutex.Unlock()
+	if fake.ServerResourcesForGroupVersionStub != nil {
+		return fake.ServerResourcesForGroupVersionStub(groupVersion)
+	} else {
+		return fake.serverResourcesForGroupVersionReturns.result1, fake.serverResourcesForGroupVersionReturns.result2
+	}
+}
+
+func (fake *FakeIDiscoveryClient) ServerResourcesForGroupVersionCallCount() int {
+	fake.serverResourcesForGroupVersionMutex.RLock()
+	defer fake.serverResourcesForGroupVersionMutex.RUnlock()
+	return len(fake.serverResourcesForGroupVersionArgsForCall)
+}
+
+func (fake *FakeIDiscoveryClient) ServerResourcesForGroupVersionArgsForCall(i int) string {
+	fake.serverResourcesForGroupVersionMutex.RLock()
+	defer fake.serverResourcesForGroupVersionMutex.RUnlock()
+	return fake.serverResourcesForGroupVersionArgsForCall[i].groupVersion
+}
+
+func (fake *FakeIDiscoveryClient) ServerResourcesForGroupVersionReturns(result1 *unversioned.APIResourceList, result2 error) {
+	fake.ServerResourcesForGroupVersionStub = nil
+	fake.serverResourcesForGroupVersionReturns = struct {
+		result1 *unversioned.APIResourceList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIDiscoveryClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.serverGroupsMutex.RLock()
+	defer fake.serverGroupsMutex.RUnlock()
+	fake.serverResourcesForGroupVersionMutex.RLock()
+	defer fake.serverResourcesForGroupVersionMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIDiscoveryClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IDiscoveryClient = new(FakeIDiscoveryClient)
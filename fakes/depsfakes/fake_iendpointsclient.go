@@ -0,0 +1,169 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api"
+)
+
+type FakeIEndpointsClient struct {
+	GetStub        func(name string) (*api.Endpoints, error)
+	getMutex       sync.RWMutex
+	getArgsForCall []struct {
+		name string
+	}
+	getReturns struct {
+		result1 *api.Endpoints
+		result2 error
+	}
+	CreateStub        func(endpoints *api.Endpoints) (*api.Endpoints, error)
+	createMutex       sync.RWMutex
+	createArgsForCall []struct {
+		endpoints *api.Endpoints
+	}
+	createReturns struct {
+		result1 *api.Endpoints
+		result2 error
+	}
+	UpdateStub        func(endpoints *api.Endpoints) (*api.Endpoints, error)
+	updateMutex       sync.RWMutex
+	updateArgsForCall []struct {
+		endpoints *api.Endpoints
+	}
+	updateReturns struct {
+		result1 *api.Endpoints
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIEndpointsClient) Get(name string) (*api.Endpoints, error) {
+	fake.getMutex.Lock()
+	fake.getArgsForCall = append(fake.getArgsForCall, struct {
+		name string
+	}{name})
+	fake.recordInvocation("Get", []interface{}{name})
+	fake.getMutex.Unlock()
+	if fake.GetStub != nil {
+		return fake.GetStub(name)
+	} else {
+		return fake.getReturns.result1, fake.getReturns.result2
+	}
+}
+
+func (fake *FakeIEndpointsClient) GetCallCount() int {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return len(fake.getArgsForCall)
+}
+
+func (fake *FakeIEndpointsClient) GetArgsForCall(i int) string {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return fake.getArgsForCall[i].name
+}
+
+func (fake *FakeIEndpointsClient) GetReturns(result1 *api.Endpoints, result2 error) {
+	fake.GetStub = nil
+	fake.getReturns = struct {
+		result1 *api.Endpoints
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIEndpointsClient) Create(endpoints *api.Endpoints) (*api.Endpoints, error) {
+	fake.createMutex.Lock()
+	fake.createArgsForCall = append(fake.createArgsForCall, struct {
+		endpoints *api.Endpoints
+	}{endpoints})
+	fake.recordInvocation("Create", []interface{}{endpoints})
+	fake.createMutex.Unlock()
+	if fake.CreateStub != nil {
+		return fake.CreateStub(endpoints)
+	} else {
+		return fake.createReturns.result1, fake.createReturns.result2
+	}
+}
+
+func (fake *FakeIEndpointsClient) CreateCallCount() int {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return len(fake.createArgsForCall)
+}
+
+func (fake *FakeIEndpointsClient) CreateArgsForCall(i int) *api.Endpoints {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return fake.createArgsForCall[i].endpoints
+}
+
+func (fake *FakeIEndpointsClient) CreateReturns(result1 *api.Endpoints, result2 error) {
+	fake.CreateStub = nil
+	fake.createReturns = struct {
+		result1 *api.Endpoints
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIEndpointsClient) Update(endpoints *api.Endpoints) (*api.Endpoints, error) {
+	fake.updateMutex.Lock()
+	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
+		endpoints *api.Endpoints
+	}{endpoints})
+	fake.recordInvocation("Update", []interface{}{endpoints})
+	fake.updateMutex.Unlock()
+	if fake.UpdateStub != nil {
+		return fake.UpdateStub(endpoints)
+	} else {
+		return fake.updateReturns.result1, fake.updateReturns.result2
+	}
+}
+
+func (fake *FakeIEndpointsClient) UpdateCallCount() int {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return len(fake.updateArgsForCall)
+}
+
+func (fake *FakeIEndpointsClient) UpdateArgsForCall(i int) *api.Endpoints {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return fake.updateArgsForCall[i].endpoints
+}
+
+func (fake *FakeIEndpointsClient) UpdateReturns(result1 *api.Endpoints, result2 error) {
+	fake.UpdateStub = nil
+	fake.updateReturns = struct {
+		result1 *api.Endpoints
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIEndpointsClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIEndpointsClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IEndpointsClient = new(FakeIEndpointsClient)
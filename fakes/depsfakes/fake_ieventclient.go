@@ -0,0 +1,125 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+type FakeIEventClient struct {
+	ListStub        func(opts api.ListOptions) (*api.EventList, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+		opts api.ListOptions
+	}
+	listReturns struct {
+		result1 *api.EventList
+		result2 error
+	}
+	WatchStub        func(opts api.ListOptions) (watch.Interface, error)
+	watchMutex       sync.RWMutex
+	watchArgsForCall []struct {
+		opts api.ListOptions
+	}
+	watchReturns struct {
+		result1 watch.Interface
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIEventClient) List(opts api.ListOptions) (*api.EventList, error) {
+	fake.listMutex.Lock()
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+		opts api.ListOptions
+	}{opts})
+	fake.recordInvocation("List", []interface{}{opts})
+	fake.listMutex.Unlock()
+	if fake.ListStub != nil {
+		return fake.ListStub(opts)
+	} else {
+		return fake.listReturns.result1, fake.listReturns.result2
+	}
+}
+
+func (fake *FakeIEventClient) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeIEventClient) ListArgsForCall(i int) api.ListOptions {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return fake.listArgsForCall[i].opts
+}
+
+func (fake *FakeIEventClient) ListReturns(result1 *api.EventList, result2 error) {
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 *api.EventList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIEventClient) Watch(opts api.ListOptions) (watch.Interface, error) {
+	fake.watchMutex.Lock()
+	fake.watchArgsForCall = append(fake.watchArgsForCall, struct {
+		opts api.ListOptions
+	}{opts})
+	fake.recordInvocation("Watch", []interface{}{opts})
+	fake.watchMutex.Unlock()
+	if fake.WatchStub != nil {
+		return fake.WatchStub(opts)
+	} else {
+		return fake.watchReturns.result1, fake.watchReturns.result2
+	}
+}
+
+func (fake *FakeIEventClient) WatchCallCount() int {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return len(fake.watchArgsForCall)
+}
+
+func (fake *FakeIEventClient) WatchArgsForCall(i int) api.ListOptions {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return fake.watchArgsForCall[i].opts
+}
+
+func (fake *FakeIEventClient) WatchReturns(result1 watch.Interface, result2 error) {
+	fake.WatchStub = nil
+	fake.watchReturns = struct {
+		result1 watch.Interface
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIEventClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIEventClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IEventClient = new(FakeIEventClient)
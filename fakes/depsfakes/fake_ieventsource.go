@@ -0,0 +1,126 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+type FakeIEventSource struct {
+	ListStub        func(opts api.ListOptions) (runtime.Object, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+		opts api.ListOptions
+	}
+	listReturns struct {
+		result1 runtime.Object
+		result2 error
+	}
+	WatchStub        func(opts api.ListOptions) (watch.Interface, error)
+	watchMutex       sync.RWMutex
+	watchArgsForCall []struct {
+		opts api.ListOptions
+	}
+	watchReturns struct {
+		result1 watch.Interface
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIEventSource) List(opts api.ListOptions) (runtime.Object, error) {
+	fake.listMutex.Lock()
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+		opts api.ListOptions
+	}{opts})
+	fake.recordInvocation("List", []interface{}{opts})
+	fake.listMutex.Unlock()
+	if fake.ListStub != nil {
+		return fake.ListStub(opts)
+	} else {
+		return fake.listReturns.result1, fake.listReturns.result2
+	}
+}
+
+func (fake *FakeIEventSource) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeIEventSource) ListArgsForCall(i int) api.ListOptions {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return fake.listArgsForCall[i].opts
+}
+
+func (fake *FakeIEventSource) ListReturns(result1 runtime.Object, result2 error) {
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 runtime.Object
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIEventSource) Watch(opts api.ListOptions) (watch.Interface, error) {
+	fake.watchMutex.Lock()
+	fake.watchArgsForCall = append(fake.watchArgsForCall, struct {
+		opts api.ListOptions
+	}{opts})
+	fake.recordInvocation("Watch", []interface{}{opts})
+	fake.watchMutex.Unlock()
+	if fake.WatchStub != nil {
+		return fake.WatchStub(opts)
+	} else {
+		return fake.watchReturns.result1, fake.watchReturns.result2
+	}
+}
+
+func (fake *FakeIEventSource) WatchCallCount() int {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return len(fake.watchArgsForCall)
+}
+
+func (fake *FakeIEventSource) WatchArgsForCall(i int) api.ListOptions {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return fake.watchArgsForCall[i].opts
+}
+
+func (fake *FakeIEventSource) WatchReturns(result1 watch.Interface, result2 error) {
+	fake.WatchStub = nil
+	fake.watchReturns = struct {
+		result1 watch.Interface
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIEventSource) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIEventSource) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IEventSource = new(FakeIEventSource)
@@ -0,0 +1,76 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+type FakeIEventSources struct {
+	EventsStub        func(namespace string) deps.IEventSource
+	eventsMutex       sync.RWMutex
+	eventsArgsForCall []struct {
+		namespace string
+	}
+	eventsReturns struct {
+		result1 deps.IEventSource
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIEventSources) Events(namespace string) deps.IEventSource {
+	fake.eventsMutex.Lock()
+	fake.eventsArgsForCall = append(fake.eventsArgsForCall, struct {
+		namespace string
+	}{namespace})
+	fake.recordInvocation("Events", []interface{}{namespace})
+	fake.eventsMutex.Unlock()
+	if fake.EventsStub != nil {
+		return fake.EventsStub(namespace)
+	} else {
+		return fake.eventsReturns.result1
+	}
+}
+
+func (fake *FakeIEventSources) EventsCallCount() int {
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return len(fake.eventsArgsForCall)
+}
+
+func (fake *FakeIEventSources) EventsArgsForCall(i int) string {
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return fake.eventsArgsForCall[i].namespace
+}
+
+func (fake *FakeIEventSources) EventsReturns(result1 deps.IEventSource) {
+	fake.EventsStub = nil
+	fake.eventsReturns = struct {
+		result1 deps.IEventSource
+	}{result1}
+}
+
+func (fake *FakeIEventSources) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIEventSources) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IEventSources = new(FakeIEventSources)
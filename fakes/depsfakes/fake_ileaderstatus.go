@@ -0,0 +1,109 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+type FakeILeaderStatus struct {
+	IsLeaderStub        func() bool
+	isLeaderMutex       sync.RWMutex
+	isLeaderArgsForCall []struct{}
+	isLeaderReturns     struct {
+		result1 bool
+	}
+	IsLeaderOfStub        func(cluster string) bool
+	isLeaderOfMutex       sync.RWMutex
+	isLeaderOfArgsForCall []struct {
+		cluster string
+	}
+	isLeaderOfReturns struct {
+		result1 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeILeaderStatus) IsLeader() bool {
+	fake.isLeaderMutex.Lock()
+	fake.isLeaderArgsForCall = append(fake.isLeaderArgsForCall, struct{}{})
+	fake.recordInvocation("IsLeader", []interface{}{})
+	fake.isLeaderMutex.Unlock()
+	if fake.IsLeaderStub != nil {
+		return fake.IsLeaderStub()
+	} else {
+		return fake.isLeaderReturns.result1
+	}
+}
+
+func (fake *FakeILeaderStatus) IsLeaderCallCount() int {
+	fake.isLeaderMutex.RLock()
+	defer fake.isLeaderMutex.RUnlock()
+	return len(fake.isLeaderArgsForCall)
+}
+
+func (fake *FakeILeaderStatus) IsLeaderReturns(result1 bool) {
+	fake.IsLeaderStub = nil
+	fake.isLeaderReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeILeaderStatus) IsLeaderOf(cluster string) bool {
+	fake.isLeaderOfMutex.Lock()
+	fake.isLeaderOfArgsForCall = append(fake.isLeaderOfArgsForCall, struct {
+		cluster string
+	}{cluster})
+	fake.recordInvocation("IsLeaderOf", []interface{}{cluster})
+	fake.isLeaderOfMutex.Unlock()
+	if fake.IsLeaderOfStub != nil {
+		return fake.IsLeaderOfStub(cluster)
+	} else {
+		return fake.isLeaderOfReturns.result1
+	}
+}
+
+func (fake *FakeILeaderStatus) IsLeaderOfCallCount() int {
+	fake.isLeaderOfMutex.RLock()
+	defer fake.isLeaderOfMutex.RUnlock()
+	return len(fake.isLeaderOfArgsForCall)
+}
+
+func (fake *FakeILeaderStatus) IsLeaderOfArgsForCall(i int) string {
+	fake.isLeaderOfMutex.RLock()
+	defer fake.isLeaderOfMutex.RUnlock()
+	return fake.isLeaderOfArgsForCall[i].cluster
+}
+
+func (fake *FakeILeaderStatus) IsLeaderOfReturns(result1 bool) {
+	fake.IsLeaderOfStub = nil
+	fake.isLeaderOfReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeILeaderStatus) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.isLeaderMutex.RLock()
+	defer fake.isLeaderMutex.RUnlock()
+	fake.isLeaderOfMutex.RLock()
+	defer fake.isLeaderOfMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeILeaderStatus) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.ILeaderStatus = new(FakeILeaderStatus)
@@ -0,0 +1,77 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api"
+)
+
+type FakeIMentionResolver struct {
+	ResolveEventStub        func(e api.Event) string
+	resolveEventMutex       sync.RWMutex
+	resolveEventArgsForCall []struct {
+		e api.Event
+	}
+	resolveEventReturns struct {
+		result1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIMentionResolver) ResolveEvent(e api.Event) string {
+	fake.resolveEventMutex.Lock()
+	fake.resolveEventArgsForCall = append(fake.resolveEventArgsForCall, struct {
+		e api.Event
+	}{e})
+	fake.recordInvocation("ResolveEvent", []interface{}{e})
+	fake.resolveEventMutex.Unlock()
+	if fake.ResolveEventStub != nil {
+		return fake.ResolveEventStub(e)
+	} else {
+		return fake.resolveEventReturns.result1
+	}
+}
+
+func (fake *FakeIMentionResolver) ResolveEventCallCount() int {
+	fake.resolveEventMutex.RLock()
+	defer fake.resolveEventMutex.RUnlock()
+	return len(fake.resolveEventArgsForCall)
+}
+
+func (fake *FakeIMentionResolver) ResolveEventArgsForCall(i int) api.Event {
+	fake.resolveEventMutex.RLock()
+	defer fake.resolveEventMutex.RUnlock()
+	return fake.resolveEventArgsForCall[i].e
+}
+
+func (fake *FakeIMentionResolver) ResolveEventReturns(result1 string) {
+	fake.ResolveEventStub = nil
+	fake.resolveEventReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeIMentionResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.resolveEventMutex.RLock()
+	defer fake.resolveEventMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIMentionResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IMentionResolver = new(FakeIMentionResolver)
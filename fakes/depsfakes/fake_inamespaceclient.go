@@ -0,0 +1,79 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api"
+)
+
+type FakeINamespaceClient struct {
+	ListStub        func(opts api.ListOptions) (*api.NamespaceList, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+		opts api.ListOptions
+	}
+	listReturns struct {
+		result1 *api.NamespaceList
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeINamespaceClient) List(opts api.ListOptions) (*api.NamespaceList, error) {
+	fake.listMutex.Lock()
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+		opts api.ListOptions
+	}{opts})
+	fake.recordInvocation("List", []interface{}{opts})
+	fake.listMutex.Unlock()
+	if fake.ListStub != nil {
+		return fake.ListStub(opts)
+	} else {
+		return fake.listReturns.result1, fake.listReturns.result2
+	}
+}
+
+func (fake *FakeINamespaceClient) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeINamespaceClient) ListArgsForCall(i int) api.ListOptions {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return fake.listArgsForCall[i].opts
+}
+
+func (fake *FakeINamespaceClient) ListReturns(result1 *api.NamespaceList, result2 error) {
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 *api.NamespaceList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeINamespaceClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeINamespaceClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.INamespaceClient = new(FakeINamespaceClient)
@@ -0,0 +1,85 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api"
+)
+
+type FakeIObjectGetter struct {
+	GetStub        func(apiVersion string, kind string, namespace string, name string) (*api.ObjectMeta, error)
+	getMutex       sync.RWMutex
+	getArgsForCall []struct {
+		apiVersion string
+		kind       string
+		namespace  string
+		name       string
+	}
+	getReturns struct {
+		result1 *api.ObjectMeta
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIObjectGetter) Get(apiVersion string, kind string, namespace string, name string) (*api.ObjectMeta, error) {
+	fake.getMutex.Lock()
+	fake.getArgsForCall = append(fake.getArgsForCall, struct {
+		apiVersion string
+		kind       string
+		namespace  string
+		name       string
+	}{apiVersion, kind, namespace, name})
+	fake.recordInvocation("Get", []interface{}{apiVersion, kind, namespace, name})
+	fake.getMutex.Unlock()
+	if fake.GetStub != nil {
+		return fake.GetStub(apiVersion, kind, namespace, name)
+	} else {
+		return fake.getReturns.result1, fake.getReturns.result2
+	}
+}
+
+func (fake *FakeIObjectGetter) GetCallCount() int {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return len(fake.getArgsForCall)
+}
+
+func (fake *FakeIObjectGetter) GetArgsForCall(i int) (string, string, string, string) {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return fake.getArgsForCall[i].apiVersion, fake.getArgsForCall[i].kind, fake.getArgsForCall[i].namespace, fake.getArgsForCall[i].name
+}
+
+func (fake *FakeIObjectGetter) GetReturns(result1 *api.ObjectMeta, result2 error) {
+	fake.GetStub = nil
+	fake.getReturns = struct {
+		result1 *api.ObjectMeta
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIObjectGetter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIObjectGetter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IObjectGetter = new(FakeIObjectGetter)
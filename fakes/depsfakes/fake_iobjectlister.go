@@ -0,0 +1,134 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+type FakeIObjectLister struct {
+	ListStub        func(kind string, namespace string, opts api.ListOptions) (runtime.Object, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+		kind      string
+		namespace string
+		opts      api.ListOptions
+	}
+	listReturns struct {
+		result1 runtime.Object
+		result2 error
+	}
+	WatchStub        func(kind string, namespace string, opts api.ListOptions) (watch.Interface, error)
+	watchMutex       sync.RWMutex
+	watchArgsForCall []struct {
+		kind      string
+		namespace string
+		opts      api.ListOptions
+	}
+	watchReturns struct {
+		result1 watch.Interface
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIObjectLister) List(kind string, namespace string, opts api.ListOptions) (runtime.Object, error) {
+	fake.listMutex.Lock()
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+		kind      string
+		namespace string
+		opts      api.ListOptions
+	}{kind, namespace, opts})
+	fake.recordInvocation("List", []interface{}{kind, namespace, opts})
+	fake.listMutex.Unlock()
+	if fake.ListStub != nil {
+		return fake.ListStub(kind, namespace, opts)
+	} else {
+		return fake.listReturns.result1, fake.listReturns.result2
+	}
+}
+
+func (fake *FakeIObjectLister) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeIObjectLister) ListArgsForCall(i int) (string, string, api.ListOptions) {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return fake.listArgsForCall[i].kind, fake.listArgsForCall[i].namespace, fake.listArgsForCall[i].opts
+}
+
+func (fake *FakeIObjectLister) ListReturns(result1 runtime.Object, result2 error) {
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 runtime.Object
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIObjectLister) Watch(kind string, namespace string, opts api.ListOptions) (watch.Interface, error) {
+	fake.watchMutex.Lock()
+	fake.watchArgsForCall = append(fake.watchArgsForCall, struct {
+		kind      string
+		namespace string
+		opts      api.ListOptions
+	}{kind, namespace, opts})
+	fake.recordInvocation("Watch", []interface{}{kind, namespace, opts})
+	fake.watchMutex.Unlock()
+	if fake.WatchStub != nil {
+		return fake.WatchStub(kind, namespace, opts)
+	} else {
+		return fake.watchReturns.result1, fake.watchReturns.result2
+	}
+}
+
+func (fake *FakeIObjectLister) WatchCallCount() int {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return len(fake.watchArgsForCall)
+}
+
+func (fake *FakeIObjectLister) WatchArgsForCall(i int) (string, string, api.ListOptions) {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return fake.watchArgsForCall[i].kind, fake.watchArgsForCall[i].namespace, fake.watchArgsForCall[i].opts
+}
+
+func (fake *FakeIObjectLister) WatchReturns(result1 watch.Interface, result2 error) {
+	fake.WatchStub = nil
+	fake.watchReturns = struct {
+		result1 watch.Interface
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIObjectLister) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIObjectLister) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IObjectLister = new(FakeIObjectLister)
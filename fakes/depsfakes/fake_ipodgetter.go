@@ -0,0 +1,81 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"k8s.io/kubernetes/pkg/api"
+)
+
+type FakeIPodGetter struct {
+	PodStub        func(namespace string, name string) (*api.Pod, error)
+	podMutex       sync.RWMutex
+	podArgsForCall []struct {
+		namespace string
+		name      string
+	}
+	podReturns struct {
+		result1 *api.Pod
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIPodGetter) Pod(namespace string, name string) (*api.Pod, error) {
+	fake.podMutex.Lock()
+	fake.podArgsForCall = append(fake.podArgsForCall, struct {
+		namespace string
+		name      string
+	}{namespace, name})
+	fake.recordInvocation("Pod", []interface{}{namespace, name})
+	fake.podMutex.Unlock()
+	if fake.PodStub != nil {
+		return fake.PodStub(namespace, name)
+	} else {
+		return fake.podReturns.result1, fake.podReturns.result2
+	}
+}
+
+func (fake *FakeIPodGetter) PodCallCount() int {
+	fake.podMutex.RLock()
+	defer fake.podMutex.RUnlock()
+	return len(fake.podArgsForCall)
+}
+
+func (fake *FakeIPodGetter) PodArgsForCall(i int) (string, string) {
+	fake.podMutex.RLock()
+	defer fake.podMutex.RUnlock()
+	return fake.podArgsForCall[i].namespace, fake.podArgsForCall[i].name
+}
+
+func (fake *FakeIPodGetter) PodReturns(result1 *api.Pod, result2 error) {
+	fake.PodStub = nil
+	fake.podReturns = struct {
+		result1 *api.Pod
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIPodGetter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.podMutex.RLock()
+	defer fake.podMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIPodGetter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IPodGetter = new(FakeIPodGetter)
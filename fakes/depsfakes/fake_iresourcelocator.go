@@ -0,0 +1,80 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+type FakeIResourceLocator struct {
+	CollectionStub        func(kind string, namespace string) (string, error)
+	collectionMutex       sync.RWMutex
+	collectionArgsForCall []struct {
+		kind      string
+		namespace string
+	}
+	collectionReturns struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIResourceLocator) Collection(kind string, namespace string) (string, error) {
+	fake.collectionMutex.Lock()
+	fake.collectionArgsForCall = append(fake.collectionArgsForCall, struct {
+		kind      string
+		namespace string
+	}{kind, namespace})
+	fake.recordInvocation("Collection", []interface{}{kind, namespace})
+	fake.collectionMutex.Unlock()
+	if fake.CollectionStub != nil {
+		return fake.CollectionStub(kind, namespace)
+	} else {
+		return fake.collectionReturns.result1, fake.collectionReturns.result2
+	}
+}
+
+func (fake *FakeIResourceLocator) CollectionCallCount() int {
+	fake.collectionMutex.RLock()
+	defer fake.collectionMutex.RUnlock()
+	return len(fake.collectionArgsForCall)
+}
+
+func (fake *FakeIResourceLocator) CollectionArgsForCall(i int) (string, string) {
+	fake.collectionMutex.RLock()
+	defer fake.collectionMutex.RUnlock()
+	return fake.collectionArgsForCall[i].kind, fake.collectionArgsForCall[i].namespace
+}
+
+func (fake *FakeIResourceLocator) CollectionReturns(result1 string, result2 error) {
+	fake.CollectionStub = nil
+	fake.collectionReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIResourceLocator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.collectionMutex.RLock()
+	defer fake.collectionMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIResourceLocator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IResourceLocator = new(FakeIResourceLocator)
@@ -0,0 +1,128 @@
+// This file was generated by counterfeiter
+package depsfakes
+
+import (
+	"io"
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+type FakeIRESTGetter struct {
+	GetRawStub        func(path string, params map[string]string) ([]byte, error)
+	getRawMutex       sync.RWMutex
+	getRawArgsForCall []struct {
+		path   string
+		params map[string]string
+	}
+	getRawReturns struct {
+		result1 []byte
+		result2 error
+	}
+	StreamRawStub        func(path string, params map[string]string) (io.ReadCloser, error)
+	streamRawMutex       sync.RWMutex
+	streamRawArgsForCall []struct {
+		path   string
+		params map[string]string
+	}
+	streamRawReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIRESTGetter) GetRaw(path string, params map[string]string) ([]byte, error) {
+	fake.getRawMutex.Lock()
+	fake.getRawArgsForCall = append(fake.getRawArgsForCall, struct {
+		path   string
+		params map[string]string
+	}{path, params})
+	fake.recordInvocation("GetRaw", []interface{}{path, params})
+	fake.getRawMutex.Unlock()
+	if fake.GetRawStub != nil {
+		return fake.GetRawStub(path, params)
+	} else {
+		return fake.getRawReturns.result1, fake.getRawReturns.result2
+	}
+}
+
+func (fake *FakeIRESTGetter) GetRawCallCount() int {
+	fake.getRawMutex.RLock()
+	defer fake.getRawMutex.RUnlock()
+	return len(fake.getRawArgsForCall)
+}
+
+func (fake *FakeIRESTGetter) GetRawArgsForCall(i int) (string, map[string]string) {
+	fake.getRawMutex.RLock()
+	defer fake.getRawMutex.RUnlock()
+	return fake.getRawArgsForCall[i].path, fake.getRawArgsForCall[i].params
+}
+
+func (fake *FakeIRESTGetter) GetRawReturns(result1 []byte, result2 error) {
+	fake.GetRawStub = nil
+	fake.getRawReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIRESTGetter) StreamRaw(path string, params map[string]string) (io.ReadCloser, error) {
+	fake.streamRawMutex.Lock()
+	fake.streamRawArgsForCall = append(fake.streamRawArgsForCall, struct {
+		path   string
+		params map[string]string
+	}{path, params})
+	fake.recordInvocation("StreamRaw", []interface{}{path, params})
+	fake.streamRawMutex.Unlock()
+	if fake.StreamRawStub != nil {
+		return fake.StreamRawStub(path, params)
+	} else {
+		return fake.streamRawReturns.result1, fake.streamRawReturns.result2
+	}
+}
+
+func (fake *FakeIRESTGetter) StreamRawCallCount() int {
+	fake.streamRawMutex.RLock()
+	defer fake.streamRawMutex.RUnlock()
+	return len(fake.streamRawArgsForCall)
+}
+
+func (fake *FakeIRESTGetter) StreamRawArgsForCall(i int) (string, map[string]string) {
+	fake.streamRawMutex.RLock()
+	defer fake.streamRawMutex.RUnlock()
+	return fake.streamRawArgsForCall[i].path, fake.streamRawArgsForCall[i].params
+}
+
+func (fake *FakeIRESTGetter) StreamRawReturns(result1 io.ReadCloser, result2 error) {
+	fake.StreamRawStub = nil
+	fake.streamRawReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIRESTGetter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getRawMutex.RLock()
+	defer fake.getRawMutex.RUnlock()
+	fake.streamRawMutex.RLock()
+	defer fake.streamRawMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeIRESTGetter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.IRESTGetter = new(FakeIRESTGetter)
@@ -0,0 +1,108 @@
+// This file was generated by counterfeiter
+package notifiersfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+type FakeDrainer struct {
+	SendAllStub        func(n *deps.Notification)
+	sendAllMutex       sync.RWMutex
+	sendAllArgsForCall []struct {
+		n *deps.Notification
+	}
+	ShutdownStub        func(ctx context.Context) error
+	shutdownMutex       sync.RWMutex
+	shutdownArgsForCall []struct {
+		ctx context.Context
+	}
+	shutdownReturns struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDrainer) SendAll(n *deps.Notification) {
+	fake.sendAllMutex.Lock()
+	fake.sendAllArgsForCall = append(fake.sendAllArgsForCall, struct {
+		n *deps.Notification
+	}{n})
+	fake.recordInvocation("SendAll", []interface{}{n})
+	fake.sendAllMutex.Unlock()
+	if fake.SendAllStub != nil {
+		fake.SendAllStub(n)
+	}
+}
+
+func (fake *FakeDrainer) SendAllCallCount() int {
+	fake.sendAllMutex.RLock()
+	defer fake.sendAllMutex.RUnlock()
+	return len(fake.sendAllArgsForCall)
+}
+
+func (fake *FakeDrainer) SendAllArgsForCall(i int) *deps.Notification {
+	fake.sendAllMutex.RLock()
+	defer fake.sendAllMutex.RUnlock()
+	return fake.sendAllArgsForCall[i].n
+}
+
+func (fake *FakeDrainer) Shutdown(ctx context.Context) error {
+	fake.shutdownMutex.Lock()
+	fake.shutdownArgsForCall = append(fake.shutdownArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.recordInvocation("Shutdown", []interface{}{ctx})
+	fake.shutdownMutex.Unlock()
+	if fake.ShutdownStub != nil {
+		return fake.ShutdownStub(ctx)
+	} else {
+		return fake.shutdownReturns.result1
+	}
+}
+
+func (fake *FakeDrainer) ShutdownCallCount() int {
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
+	return len(fake.shutdownArgsForCall)
+}
+
+func (fake *FakeDrainer) ShutdownArgsForCall(i int) context.Context {
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
+	return fake.shutdownArgsForCall[i].ctx
+}
+
+func (fake *FakeDrainer) ShutdownReturns(result1 error) {
+	fake.ShutdownStub = nil
+	fake.shutdownReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDrainer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.sendAllMutex.RLock()
+	defer fake.sendAllMutex.RUnlock()
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeDrainer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.Drainer = new(FakeDrainer)
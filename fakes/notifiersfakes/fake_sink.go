@@ -0,0 +1,64 @@
+// This file was generated by counterfeiter
+package notifiersfakes
+
+import (
+	"sync"
+
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+type FakeSink struct {
+	SendAllStub        func(n *deps.Notification)
+	sendAllMutex       sync.RWMutex
+	sendAllArgsForCall []struct {
+		n *deps.Notification
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSink) SendAll(n *deps.Notification) {
+	fake.sendAllMutex.Lock()
+	fake.sendAllArgsForCall = append(fake.sendAllArgsForCall, struct {
+		n *deps.Notification
+	}{n})
+	fake.recordInvocation("SendAll", []interface{}{n})
+	fake.sendAllMutex.Unlock()
+	if fake.SendAllStub != nil {
+		fake.SendAllStub(n)
+	}
+}
+
+func (fake *FakeSink) SendAllCallCount() int {
+	fake.sendAllMutex.RLock()
+	defer fake.sendAllMutex.RUnlock()
+	return len(fake.sendAllArgsForCall)
+}
+
+func (fake *FakeSink) SendAllArgsForCall(i int) *deps.Notification {
+	fake.sendAllMutex.RLock()
+	defer fake.sendAllMutex.RUnlock()
+	return fake.sendAllArgsForCall[i].n
+}
+
+func (fake *FakeSink) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.sendAllMutex.RLock()
+	defer fake.sendAllMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeSink) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ deps.Sink = new(FakeSink)
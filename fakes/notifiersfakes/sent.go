@@ -0,0 +1,16 @@
+package notifiersfakes
+
+import (
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+// SentTo returns every notification sent to the fake so far
+func SentTo(sink *FakeSink) func() []*deps.Notification {
+	return func() []*deps.Notification {
+		var sent []*deps.Notification
+		for i := 0; i < sink.SendAllCallCount(); i++ {
+			sent = append(sent, sink.SendAllArgsForCall(i))
+		}
+		return sent
+	}
+}
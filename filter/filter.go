@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+const (
+	SELECTOR_REFRESH_INTERVAL = time.Minute
+)
+
+// Filter decides which namespaces we send notifications for, based on include and
+// exclude glob patterns and an optional namespace label selector.
+type Filter struct {
+	Include  []string
+	Exclude  []string
+	Selector labels.Selector
+	Client   deps.INamespaceClient
+	Clock    clock.Clock
+
+	lock     sync.RWMutex
+	selected map[string]bool
+}
+
+func New(cfg *config.Config, c deps.INamespaceClient) (*Filter, error) {
+	f := &Filter{
+		Include: cfg.NamespaceInclude,
+		Exclude: cfg.NamespaceExclude,
+		Client:  c,
+		Clock:   clock.RealClock{},
+	}
+
+	if cfg.NamespaceSelector != "" {
+		selector, err := labels.Parse(cfg.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse namespace selector: %v", err.Error())
+		}
+		f.Selector = selector
+	}
+
+	return f, nil
+}
+
+// Reports whether events in the given namespace should be notified about
+func (f *Filter) Allowed(namespace string) bool {
+	if len(f.Include) != 0 && !matchAny(f.Include, namespace) {
+		return false
+	}
+
+	if matchAny(f.Exclude, namespace) {
+		return false
+	}
+
+	if f.Selector != nil {
+		f.lock.RLock()
+		defer f.lock.RUnlock()
+		return f.selected[namespace]
+	}
+
+	return true
+}
+
+// Periodically refreshes the namespaces matching the selector until the context is
+// done. Does nothing if no selector is set.
+func (f *Filter) Run(ctx context.Context) {
+	if f.Selector == nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.Clock.After(SELECTOR_REFRESH_INTERVAL):
+		}
+		if err := f.Refresh(); err != nil {
+			log.Errorf("Unable to refresh namespaces: %v", err.Error())
+		}
+	}
+}
+
+// Lists the namespaces currently matching the selector
+func (f *Filter) Refresh() error {
+	if f.Selector == nil {
+		return nil
+	}
+
+	list, err := f.Client.List(api.ListOptions{
+		LabelSelector: f.Selector,
+	})
+	if err != nil {
+		return err
+	}
+
+	selected := make(map[string]bool, len(list.Items))
+	for _, ns := range list.Items {
+		selected[ns.ObjectMeta.Name] = true
+	}
+
+	f.lock.Lock()
+	f.selected = selected
+	f.lock.Unlock()
+
+	log.Debugf("Namespaces matching selector '%s': %d", f.Selector, len(selected))
+	return nil
+}
+
+func matchAny(patterns []string, namespace string) bool {
+	for _, p := range patterns {
+		if matched, _ := path.Match(p, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
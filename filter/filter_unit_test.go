@@ -0,0 +1,120 @@
+// +build unit
+
+package filter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+)
+
+var _ = Describe("Filter", func() {
+	var (
+		cfg                 *config.Config
+		fakeNamespaceClient *depsfakes.FakeINamespaceClient
+		f                   *Filter
+		newErr              error
+	)
+
+	BeforeEach(func() {
+		cfg = config.New()
+		fakeNamespaceClient = &depsfakes.FakeINamespaceClient{}
+	})
+
+	JustBeforeEach(func() {
+		f, newErr = New(cfg, fakeNamespaceClient)
+	})
+
+	Context("when no patterns or selector are set", func() {
+		It("should allow every namespace", func() {
+			Expect(newErr).ToNot(HaveOccurred())
+			Expect(f.Allowed("default")).To(BeTrue())
+			Expect(f.Allowed("kube-system")).To(BeTrue())
+		})
+	})
+
+	Context("when include patterns are set", func() {
+		BeforeEach(func() {
+			cfg.NamespaceInclude = []string{"team-*", "default"}
+		})
+
+		It("should only allow matching namespaces", func() {
+			Expect(f.Allowed("team-payments")).To(BeTrue())
+			Expect(f.Allowed("default")).To(BeTrue())
+			Expect(f.Allowed("kube-system")).To(BeFalse())
+		})
+	})
+
+	Context("when exclude patterns are set", func() {
+		BeforeEach(func() {
+			cfg.NamespaceInclude = []string{"team-*"}
+			cfg.NamespaceExclude = []string{"*-sandbox"}
+		})
+
+		It("should exclude matching namespaces even when included", func() {
+			Expect(f.Allowed("team-payments")).To(BeTrue())
+			Expect(f.Allowed("team-payments-sandbox")).To(BeFalse())
+		})
+	})
+
+	Context("when a namespace selector is set", func() {
+		BeforeEach(func() {
+			cfg.NamespaceSelector = "overwatch=enabled"
+			fakeNamespaceClient.ListReturns(&api.NamespaceList{
+				Items: []api.Namespace{
+					{ObjectMeta: api.ObjectMeta{Name: "team-a"}},
+				},
+			}, nil)
+		})
+
+		It("should only allow namespaces matching the selector once refreshed", func() {
+			Expect(f.Allowed("team-a")).To(BeFalse())
+
+			Expect(f.Refresh()).To(Succeed())
+			Expect(fakeNamespaceClient.ListArgsForCall(0).LabelSelector.String()).To(Equal("overwatch=enabled"))
+			Expect(f.Allowed("team-a")).To(BeTrue())
+			Expect(f.Allowed("team-b")).To(BeFalse())
+		})
+
+		It("should refresh periodically while running, until the context is done", func() {
+			clk := clock.NewFakeClock(time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC))
+			f.Clock = clk
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan struct{})
+			go func() {
+				f.Run(ctx)
+				close(stopped)
+			}()
+
+			Eventually(clk.HasWaiters).Should(BeTrue())
+			clk.Step(SELECTOR_REFRESH_INTERVAL)
+			Eventually(func() bool { return f.Allowed("team-a") }).Should(BeTrue())
+
+			cancel()
+			Eventually(stopped).Should(BeClosed())
+		})
+
+		It("should return an error when listing namespaces fails", func() {
+			fakeNamespaceClient.ListReturns(nil, fmt.Errorf("boom"))
+			Expect(f.Refresh()).ToNot(Succeed())
+		})
+	})
+
+	Context("when the namespace selector is invalid", func() {
+		BeforeEach(func() {
+			cfg.NamespaceSelector = "!!!"
+		})
+
+		It("should return an error", func() {
+			Expect(newErr).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,269 @@
+package flap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	dependencies "github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+const (
+	REASON_STARTED = "Flapping"
+	REASON_ENDED   = "FlappingStopped"
+	COMPONENT      = "kit-overwatch"
+	CHECK_INTERVAL = 10 * time.Second
+)
+
+// What we know about the recent reasons of one involved object
+type object struct {
+	ref         api.ObjectReference
+	lastReason  string
+	lastEvent   api.Event
+	lastSeen    time.Time
+	transitions []time.Time
+
+	// When each reason was last seen, to tell oscillation from progress through new reasons
+	seen map[string]time.Time
+
+	// The reasons seen since it was last stable
+	reasons map[string]bool
+
+	// Set while the object is flapping
+	flapping   bool
+	since      time.Time
+	level      string
+	suppressed int
+	announced  bool
+}
+
+// Detector scores transitions back to a reason seen within Window (eg. NodeReady to
+// NodeNotReady and back) per involved object, so moving on through new reasons like
+// a pod starting up isn't one. When an object transitions Threshold times within
+// Window it is flapping: a single notification says so and further notifications
+// for it are held back until it has had no transitions for Stable, when a final one
+// says it stopped. Every event counts whatever its level, as flapping often goes
+// through one that isn't notified, eg. NodeReady, but the flapping notifications are
+// only sent once an event held back is at or above Level. They mention whoever the
+// object's own notifications would.
+type Detector struct {
+	Threshold int
+	Window    time.Duration
+	Stable    time.Duration
+	Level     string
+	Mentions  dependencies.IMentionResolver
+	Sink      deps.Sink
+	Cluster   string
+	Clock     clock.Clock
+
+	// Runs sends in the background; the watcher sets it so Shutdown waits for them
+	Spawn func(func())
+
+	lock    sync.Mutex
+	objects map[api.ObjectReference]*object
+}
+
+func New(cfg *config.Config, m dependencies.IMentionResolver, sink deps.Sink) *Detector {
+	return &Detector{
+		Threshold: cfg.FlapThreshold,
+		Window:    time.Duration(cfg.FlapWindow) * time.Second,
+		Stable:    time.Duration(cfg.FlapStable) * time.Second,
+		Level:     cfg.NotificationLevel,
+		Mentions:  m,
+		Sink:      sink,
+		Cluster:   cfg.ClusterName,
+		Clock:     clock.RealClock{},
+		Spawn:     func(f func()) { go f() },
+		objects:   make(map[api.ObjectReference]*object),
+	}
+}
+
+// Records an event about to be notified at the given level and reports whether its
+// object is flapping, in which case it should not be notified about on its own
+func (d *Detector) Observe(e api.Event, level string) bool {
+	ref := api.ObjectReference{
+		Kind:      e.InvolvedObject.Kind,
+		Namespace: e.InvolvedObject.Namespace,
+		Name:      e.InvolvedObject.Name,
+	}
+	if ref.Name == "" {
+		return false
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.Clock.Now()
+	o, ok := d.objects[ref]
+	if !ok {
+		o = &object{ref: ref, seen: make(map[string]time.Time), reasons: make(map[string]bool)}
+		d.objects[ref] = o
+	}
+
+	o.transitions = d.trim(o.transitions, now)
+	if len(o.transitions) == 0 && !o.flapping {
+		o.reasons = map[string]bool{}
+		if o.lastReason != "" {
+			o.reasons[o.lastReason] = true
+		}
+	}
+	cutoff := now.Add(-d.Window)
+	for r, t := range o.seen {
+		if !t.After(cutoff) {
+			delete(o.seen, r)
+		}
+	}
+	if _, back := o.seen[e.Reason]; back && o.lastReason != e.Reason {
+		o.transitions = append(o.transitions, now)
+	}
+	o.seen[e.Reason] = now
+	o.lastReason = e.Reason
+	o.lastEvent = e
+	o.lastSeen = now
+	o.reasons[e.Reason] = true
+
+	if o.flapping {
+		o.suppressed++
+		if deps.Severity(level) > deps.Severity(o.level) {
+			o.level = level
+		}
+		d.announce(o, now)
+		return true
+	}
+
+	if len(o.transitions) < d.Threshold {
+		return false
+	}
+
+	o.flapping = true
+	o.since = now
+	o.level = "WARN"
+	if deps.Severity(level) > deps.Severity(o.level) {
+		o.level = level
+	}
+	o.suppressed = 1
+	o.announced = false
+
+	log.Warnf("%s %s is flapping: %d transitions in %v, holding back notifications", ref.Kind, ref.Name, len(o.transitions), d.Window)
+	d.announce(o, now)
+	return true
+}
+
+// Sends the flapping notification once, when what is held back reaches the notification level
+func (d *Detector) announce(o *object, now time.Time) {
+	if o.announced || deps.Severity(o.level) < deps.Severity(d.Level) {
+		return
+	}
+	o.announced = true
+
+	n := d.notification(o, now, true)
+	d.Spawn(func() { d.send(n) })
+}
+
+// Resolves the mention outside the lock, as it may look the object up
+func (d *Detector) send(n *deps.Notification) {
+	n.Mention = d.Mentions.ResolveEvent(n.Event)
+	d.Sink.SendAll(n)
+}
+
+// Periodically ends flapping for objects that have become stable, and forgets the ones we no longer hear about, until the context is done
+func (d *Detector) Run(ctx context.Context) {
+	tick := d.Clock.Tick(CHECK_INTERVAL)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			d.check()
+		}
+	}
+}
+
+func (d *Detector) check() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.Clock.Now()
+	for ref, o := range d.objects {
+		o.transitions = d.trim(o.transitions, now)
+
+		if o.flapping {
+			last := o.since
+			if len(o.transitions) != 0 {
+				last = o.transitions[len(o.transitions)-1]
+			}
+			if now.Sub(last) < d.Stable {
+				continue
+			}
+
+			log.Infof("%s %s stopped flapping, %d notifications were held back", ref.Kind, ref.Name, o.suppressed)
+			if o.announced {
+				n := d.notification(o, now, false)
+				d.Spawn(func() { d.send(n) })
+			}
+			o.flapping = false
+			o.transitions = nil
+			o.reasons = map[string]bool{o.lastReason: true}
+			continue
+		}
+
+		if now.Sub(o.lastSeen) > d.Window {
+			delete(d.objects, ref)
+		}
+	}
+}
+
+// Drops transitions that are no longer within the window
+func (d *Detector) trim(times []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-d.Window)
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// Builds the flapping or stopped flapping notification. It carries a copy of the
+// object's latest event so it is still about the same involved object.
+func (d *Detector) notification(o *object, now time.Time, started bool) *deps.Notification {
+	reasons := make([]string, 0, len(o.reasons))
+	for r := range o.reasons {
+		reasons = append(reasons, r)
+	}
+	sort.Strings(reasons)
+
+	e := o.lastEvent
+	e.Source = api.EventSource{Component: COMPONENT}
+	e.FirstTimestamp = unversioned.NewTime(o.since)
+	e.LastTimestamp = unversioned.NewTime(now)
+	if started {
+		e.Reason = REASON_STARTED
+		e.Type = api.EventTypeWarning
+		e.Count = int32(len(o.transitions))
+		e.Message = fmt.Sprintf("%s %s is flapping: %d transitions in %v between %s. Holding back notifications until it is stable for %v.",
+			o.ref.Kind, o.ref.Name, len(o.transitions), d.Window, strings.Join(reasons, ", "), d.Stable)
+	} else {
+		e.Reason = REASON_ENDED
+		e.Type = api.EventTypeNormal
+		e.Count = int32(o.suppressed)
+		e.Message = fmt.Sprintf("%s %s has been stable for %v after flapping for %v between %s. %d notifications were held back, the latest was %s: %s",
+			o.ref.Kind, o.ref.Name, d.Stable, now.Sub(o.since), strings.Join(reasons, ", "), o.suppressed, o.lastEvent.Reason, o.lastEvent.Message)
+	}
+
+	return &deps.Notification{
+		Cluster:   d.Cluster,
+		Namespace: e.ObjectMeta.Namespace,
+		Event:     e,
+		Level:     o.level,
+	}
+}
@@ -0,0 +1,13 @@
+package flap
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFlapSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Flap Suite")
+}
@@ -0,0 +1,221 @@
+// +build unit
+
+package flap
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/fakes/notifiersfakes"
+)
+
+func podEvent(pod, reason string) api.Event {
+	return api.Event{
+		ObjectMeta:     api.ObjectMeta{Name: pod + ".1", Namespace: "default"},
+		InvolvedObject: api.ObjectReference{Kind: "Pod", Namespace: "default", Name: pod},
+		Reason:         reason,
+	}
+}
+
+func nodeEvent(node, reason string) api.Event {
+	return api.Event{
+		ObjectMeta:     api.ObjectMeta{Name: node + ".1"},
+		InvolvedObject: api.ObjectReference{Kind: "Node", Name: node},
+		Reason:         reason,
+		Message:        "Node " + node + " status is now: " + reason,
+	}
+}
+
+var _ = Describe("Detector", func() {
+	var (
+		mentions *depsfakes.FakeIMentionResolver
+		sink     *notifiersfakes.FakeSink
+		clk      *clock.FakeClock
+		d        *Detector
+	)
+
+	// Alternates between ready and not ready a minute apart, reporting how many were held back
+	flap := func(node string, n int) int {
+		held := 0
+		for i := 0; i < n; i++ {
+			reason, level := "NodeReady", "INFO"
+			if i%2 == 1 {
+				reason, level = "NodeNotReady", "WARN"
+			}
+			if d.Observe(nodeEvent(node, reason), level) {
+				held++
+			}
+			clk.Step(time.Minute)
+		}
+		return held
+	}
+
+	BeforeEach(func() {
+		cfg := config.New()
+		cfg.FlapThreshold = 4
+		cfg.FlapWindow = 600
+		cfg.FlapStable = 300
+		cfg.NotificationLevel = "INFO"
+		cfg.ClusterName = "prod"
+
+		mentions = &depsfakes.FakeIMentionResolver{}
+		mentions.ResolveEventReturns("infra")
+		sink = &notifiersfakes.FakeSink{}
+		clk = clock.NewFakeClock(time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC))
+		d = New(cfg, mentions, sink)
+		d.Clock = clk
+	})
+
+	It("should not hold back repeats of the same reason", func() {
+		for i := 0; i < 10; i++ {
+			Expect(d.Observe(nodeEvent("node-1", "NodeNotReady"), "WARN")).To(BeFalse())
+		}
+		Consistently(sink.SendAllCallCount).Should(Equal(0))
+	})
+
+	It("should not hold back transitions spread out over more than the window", func() {
+		for i := 0; i < 10; i++ {
+			reason := "NodeReady"
+			if i%2 == 1 {
+				reason = "NodeNotReady"
+			}
+			Expect(d.Observe(nodeEvent("node-1", reason), "WARN")).To(BeFalse())
+			clk.Step(4 * time.Minute)
+		}
+	})
+
+	It("should not count a pod starting up through new reasons", func() {
+		for _, reason := range []string{"Scheduled", "Pulling", "Pulled", "Created", "Started"} {
+			Expect(d.Observe(podEvent("web-1", reason), "INFO")).To(BeFalse())
+			clk.Step(time.Second)
+		}
+		Expect(d.Observe(podEvent("web-1", "BackOff"), "ERROR")).To(BeFalse())
+		Consistently(sink.SendAllCallCount).Should(Equal(0))
+	})
+
+	It("should count a pod going back and forth between killed and started", func() {
+		held := 0
+		for i := 0; i < 6; i++ {
+			for _, reason := range []string{"Killing", "Started"} {
+				if d.Observe(podEvent("web-1", reason), "INFO") {
+					held++
+				}
+			}
+		}
+		Expect(held).To(Equal(7))
+		Eventually(sink.SendAllCallCount).Should(Equal(1))
+	})
+
+	It("should count events below the notification level", func() {
+		d.Level = "WARN"
+		Expect(flap("node-1", 6)).To(Equal(1))
+		Eventually(sink.SendAllCallCount).Should(Equal(1))
+
+		n := sink.SendAllArgsForCall(0)
+		Expect(n.Event.Reason).To(Equal(REASON_STARTED))
+		Expect(n.Level).To(Equal("WARN"))
+	})
+
+	Context("when flapping between events below the notification level", func() {
+		BeforeEach(func() {
+			d.Level = "ERROR"
+			flap("node-1", 8)
+		})
+
+		It("should not say so", func() {
+			Consistently(sink.SendAllCallCount).Should(Equal(0))
+
+			clk.Step(5 * time.Minute)
+			d.check()
+			Consistently(sink.SendAllCallCount).Should(Equal(0))
+		})
+
+		It("should say so once an event at the notification level is held back", func() {
+			Expect(d.Observe(nodeEvent("node-1", "NodeNotSchedulable"), "ERROR")).To(BeTrue())
+			Eventually(sink.SendAllCallCount).Should(Equal(1))
+			n := sink.SendAllArgsForCall(0)
+			Expect(n.Event.Reason).To(Equal(REASON_STARTED))
+			Expect(n.Level).To(Equal("ERROR"))
+
+			clk.Step(5 * time.Minute)
+			d.check()
+			Eventually(sink.SendAllCallCount).Should(Equal(2))
+			Expect(sink.SendAllArgsForCall(1).Event.Reason).To(Equal(REASON_ENDED))
+		})
+	})
+
+	It("should ignore events without an involved object", func() {
+		Expect(d.Observe(api.Event{Reason: "A"}, "WARN")).To(BeFalse())
+	})
+
+	Context("when an object transitions too often", func() {
+		It("should send a single flapping notification and hold back the rest", func() {
+			// The first two events are not transitions, the sixth is the fourth transition
+			Expect(flap("node-1", 10)).To(Equal(5))
+			Eventually(sink.SendAllCallCount).Should(Equal(1))
+
+			n := sink.SendAllArgsForCall(0)
+			Expect(n.Event.Reason).To(Equal(REASON_STARTED))
+			Expect(n.Event.InvolvedObject.Name).To(Equal("node-1"))
+			Expect(n.Event.Message).To(HavePrefix("Node node-1 is flapping: 4 transitions in 10m0s between NodeNotReady, NodeReady"))
+			Expect(n.Level).To(Equal("WARN"))
+			Expect(n.Cluster).To(Equal("prod"))
+		})
+
+		It("should mention whoever the object's own notifications would", func() {
+			flap("node-1", 6)
+			Eventually(sink.SendAllCallCount).Should(Equal(1))
+
+			Expect(sink.SendAllArgsForCall(0).Mention).To(Equal("infra"))
+			Expect(mentions.ResolveEventArgsForCall(0).InvolvedObject.Name).To(Equal("node-1"))
+		})
+
+		It("should not hold back other objects", func() {
+			flap("node-1", 6)
+			Expect(d.Observe(nodeEvent("node-2", "NodeNotReady"), "WARN")).To(BeFalse())
+		})
+
+		It("should keep holding back until it is stable for long enough", func() {
+			flap("node-1", 6)
+			Eventually(sink.SendAllCallCount).Should(Equal(1))
+
+			// Another transition restarts the stable period
+			clk.Step(3 * time.Minute)
+			d.check()
+			Expect(d.Observe(nodeEvent("node-1", "NodeReady"), "WARN")).To(BeTrue())
+
+			clk.Step(4 * time.Minute)
+			d.check()
+			Consistently(sink.SendAllCallCount).Should(Equal(1))
+		})
+
+		It("should send a stopped flapping notification once stable", func() {
+			flap("node-1", 8)
+			Eventually(sink.SendAllCallCount).Should(Equal(1))
+
+			clk.Step(5 * time.Minute)
+			d.check()
+			Eventually(sink.SendAllCallCount).Should(Equal(2))
+
+			n := sink.SendAllArgsForCall(1)
+			Expect(n.Event.Reason).To(Equal(REASON_ENDED))
+			Expect(n.Event.Count).To(Equal(int32(3)))
+			Expect(n.Event.Message).To(ContainSubstring("the latest was NodeNotReady"))
+
+			Expect(d.Observe(nodeEvent("node-1", "NodeNotReady"), "WARN")).To(BeFalse())
+		})
+	})
+
+	It("should forget objects it no longer hears about", func() {
+		d.Observe(nodeEvent("node-1", "NodeReady"), "WARN")
+		clk.Step(11 * time.Minute)
+		d.check()
+		Expect(d.objects).To(BeEmpty())
+	})
+})
@@ -0,0 +1,234 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+const (
+	REASON_CLOSED  = "IncidentClosed"
+	COMPONENT      = "kit-overwatch"
+	CHECK_INTERVAL = 10 * time.Second
+
+	// Notifications at or above this level open an incident
+	OPEN_LEVEL = "WARN"
+)
+
+// An open incident and what we need to close it
+type incident struct {
+	deps.Incident
+	level    string
+	lastSeen time.Time
+	latest   *deps.Notification
+}
+
+// Tracker turns notifications into incidents. The first WARN or ERROR notification
+// about an object (or, with Owners set, the workload owning it) opens an incident
+// and later ones about it are attached as updates. Once nothing has been heard about
+// it for Quiet the incident is closed with a final notification. Notifications that
+// aren't about an object, or are below WARN with no incident open, pass straight on.
+type Tracker struct {
+	Owners *owners.Resolver
+	Next   deps.Sink
+	Quiet  time.Duration
+	Clock  clock.Clock
+
+	lock      sync.Mutex
+	incidents map[api.ObjectReference]*incident
+}
+
+func New(cfg *config.Config, o *owners.Resolver, next deps.Sink) *Tracker {
+	t := &Tracker{
+		Next:      next,
+		Quiet:     time.Duration(cfg.IncidentQuiet) * time.Second,
+		Clock:     clock.RealClock{},
+		incidents: make(map[api.ObjectReference]*incident),
+	}
+	if cfg.IncidentByOwner {
+		t.Owners = o
+	}
+	return t
+}
+
+func (t *Tracker) SendAll(n *deps.Notification) {
+	ref, ok := t.object(n)
+	if !ok {
+		t.Next.SendAll(n)
+		return
+	}
+
+	t.lock.Lock()
+	now := t.Clock.Now()
+	i, ok := t.incidents[ref]
+	if !ok {
+		if deps.Severity(n.Level) < deps.Severity(OPEN_LEVEL) {
+			t.lock.Unlock()
+			t.Next.SendAll(n)
+			return
+		}
+
+		i = &incident{
+			Incident: deps.Incident{
+				ID:     fmt.Sprintf("%s-%s-%d", strings.ToLower(ref.Kind), ref.Name, now.Unix()),
+				State:  deps.INCIDENT_OPEN,
+				Object: ref,
+				Opened: now,
+			},
+		}
+		t.incidents[ref] = i
+		log.Infof("Opened incident %s for %s %s: %s", i.ID, ref.Kind, ref.Name, n.Event.Reason)
+	} else {
+		i.State = deps.INCIDENT_UPDATE
+	}
+
+	i.Events++
+	i.lastSeen = now
+	i.latest = n
+	if deps.Severity(n.Level) > deps.Severity(i.level) {
+		i.level = n.Level
+	}
+	if !contains(i.Reasons, n.Event.Reason) {
+		i.Reasons = append(i.Reasons, n.Event.Reason)
+		sort.Strings(i.Reasons)
+	}
+
+	copied := *n
+	copied.Incident = i.snapshot()
+	t.lock.Unlock()
+
+	t.Next.SendAll(&copied)
+}
+
+// Periodically closes incidents that have been quiet for long enough, until the context is done
+func (t *Tracker) Run(ctx context.Context) {
+	tick := t.Clock.Tick(CHECK_INTERVAL)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			t.check()
+		}
+	}
+}
+
+func (t *Tracker) check() {
+	t.lock.Lock()
+	now := t.Clock.Now()
+	var closed []*deps.Notification
+	for ref, i := range t.incidents {
+		if now.Sub(i.lastSeen) < t.Quiet {
+			continue
+		}
+
+		delete(t.incidents, ref)
+		i.State = deps.INCIDENT_CLOSE
+		log.Infof("Closed incident %s for %s %s after %d events", i.ID, ref.Kind, ref.Name, i.Events)
+		closed = append(closed, t.notification(i, now))
+	}
+	t.lock.Unlock()
+
+	for _, n := range closed {
+		t.Next.SendAll(n)
+	}
+}
+
+// Abandon forgets the incidents still open once we stop watching, logging each as
+// they won't be closed. Returns how many there were.
+func (t *Tracker) Abandon() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	n := len(t.incidents)
+	for ref, i := range t.incidents {
+		log.Warnf("Leaving incident %s for %s %s open after %d events: %s", i.ID, ref.Kind, ref.Name, i.Events, strings.Join(i.Reasons, ", "))
+	}
+	t.incidents = make(map[api.ObjectReference]*incident)
+	return n
+}
+
+// Works out which object a notification's incident is about
+func (t *Tracker) object(n *deps.Notification) (api.ObjectReference, bool) {
+	ref := api.ObjectReference{
+		Kind:      n.Event.InvolvedObject.Kind,
+		Namespace: n.Event.InvolvedObject.Namespace,
+		Name:      n.Event.InvolvedObject.Name,
+	}
+	if ref.Name == "" {
+		return ref, false
+	}
+	if ref.Namespace == "" {
+		ref.Namespace = n.Event.ObjectMeta.Namespace
+	}
+
+	if t.Owners != nil {
+		// The incident is keyed on the object alone, but its owners are looked up in its group
+		lookup := ref
+		lookup.APIVersion = n.Event.InvolvedObject.APIVersion
+		root, err := t.Owners.Root(lookup)
+		if err != nil {
+			log.Debugf("Unable to resolve the owner of %s %s, tracking the incident on it instead: %v", ref.Kind, ref.Name, err.Error())
+			return ref, true
+		}
+		ref = api.ObjectReference{
+			Kind:      root.Kind,
+			Namespace: root.Namespace,
+			Name:      root.Name,
+		}
+	}
+
+	return ref, true
+}
+
+// Builds the closing notification. It carries a copy of the incident's latest event
+// so it is still about the same involved object.
+func (t *Tracker) notification(i *incident, now time.Time) *deps.Notification {
+	e := i.latest.Event
+	e.Source = api.EventSource{Component: COMPONENT}
+	e.InvolvedObject = i.Object
+	e.Reason = REASON_CLOSED
+	e.Type = api.EventTypeNormal
+	e.Count = int32(i.Events)
+	e.FirstTimestamp = unversioned.NewTime(i.Opened)
+	e.LastTimestamp = unversioned.NewTime(now)
+	e.Message = fmt.Sprintf("Incident %s for %s %s is closed after %v without events. It lasted %v with %d events: %s. The latest was %s: %s",
+		i.ID, i.Object.Kind, i.Object.Name, t.Quiet, i.lastSeen.Sub(i.Opened), i.Events, strings.Join(i.Reasons, ", "), i.latest.Event.Reason, i.latest.Event.Message)
+
+	return &deps.Notification{
+		Cluster:   i.latest.Cluster,
+		Namespace: i.latest.Namespace,
+		Event:     e,
+		Level:     i.level,
+		Mention:   i.latest.Mention,
+		Incident:  i.snapshot(),
+	}
+}
+
+// Copies the incident so notifications don't change as it does
+func (i *incident) snapshot() *deps.Incident {
+	s := i.Incident
+	s.Reasons = append([]string(nil), i.Reasons...)
+	return &s
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
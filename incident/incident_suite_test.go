@@ -0,0 +1,13 @@
+package incident
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestIncidentSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Incident Suite")
+}
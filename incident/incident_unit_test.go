@@ -0,0 +1,201 @@
+// +build unit
+
+package incident
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/fakes/notifiersfakes"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+func podNotification(pod, reason, level string) *deps.Notification {
+	return &deps.Notification{
+		Cluster:   "prod",
+		Namespace: "default",
+		Level:     level,
+		Mention:   "payments",
+		Event: api.Event{
+			ObjectMeta:     api.ObjectMeta{Name: pod + ".1", Namespace: "default"},
+			InvolvedObject: api.ObjectReference{Kind: "Pod", Name: pod},
+			Reason:         reason,
+			Message:        reason + " for " + pod,
+		},
+	}
+}
+
+var _ = Describe("Tracker", func() {
+	var (
+		fakeGetter *depsfakes.FakeIObjectGetter
+		sink       *notifiersfakes.FakeSink
+		sent       func() []*deps.Notification
+		clk        *clock.FakeClock
+		cfg        *config.Config
+		t          *Tracker
+	)
+
+	BeforeEach(func() {
+		controller := true
+		fakeGetter = &depsfakes.FakeIObjectGetter{}
+		fakeGetter.GetStub = func(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+			switch {
+			case kind == "Pod" && name == "missing":
+				return nil, fmt.Errorf("not found")
+			case kind == "Pod":
+				return &api.ObjectMeta{Name: name, OwnerReferences: []api.OwnerReference{{Kind: "ReplicaSet", Name: "web-1234", Controller: &controller}}}, nil
+			case kind == "ReplicaSet":
+				return &api.ObjectMeta{Name: name, OwnerReferences: []api.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &controller}}}, nil
+			}
+			return &api.ObjectMeta{Name: name}, nil
+		}
+
+		cfg = config.New()
+		cfg.IncidentQuiet = 600
+		cfg.IncidentByOwner = true
+		sink = &notifiersfakes.FakeSink{}
+		sent = notifiersfakes.SentTo(sink)
+		clk = clock.NewFakeClock(time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC))
+	})
+
+	JustBeforeEach(func() {
+		t = New(cfg, owners.New(fakeGetter), sink)
+		t.Clock = clk
+	})
+
+	It("should pass INFO notifications through when no incident is open", func() {
+		t.SendAll(podNotification("web-1234-abcde", "Scheduled", "INFO"))
+		Expect(sent()).To(HaveLen(1))
+		Expect(sent()[0].Incident).To(BeNil())
+	})
+
+	It("should pass notifications without an involved object through", func() {
+		n := podNotification("", "EventStorm", "ERROR")
+		t.SendAll(n)
+		Expect(sent()).To(ConsistOf(n))
+	})
+
+	It("should open an incident on the first warning", func() {
+		t.SendAll(podNotification("web-1234-abcde", "BackOff", "WARN"))
+
+		Expect(sent()).To(HaveLen(1))
+		i := sent()[0].Incident
+		Expect(i).ToNot(BeNil())
+		Expect(i.State).To(Equal(deps.INCIDENT_OPEN))
+		Expect(i.ID).To(Equal(fmt.Sprintf("deployment-web-%d", clk.Now().Unix())))
+		Expect(i.Object).To(Equal(api.ObjectReference{Kind: "Deployment", Namespace: "default", Name: "web"}))
+		Expect(i.Events).To(Equal(1))
+	})
+
+	It("should attach later notifications about the same owner as updates", func() {
+		t.SendAll(podNotification("web-1234-abcde", "BackOff", "WARN"))
+		clk.Step(time.Minute)
+		t.SendAll(podNotification("web-1234-fghij", "Unhealthy", "ERROR"))
+		clk.Step(time.Minute)
+		t.SendAll(podNotification("web-1234-abcde", "Pulled", "INFO"))
+
+		Expect(sent()).To(HaveLen(3))
+		first, last := sent()[0].Incident, sent()[2].Incident
+		Expect(last.ID).To(Equal(first.ID))
+		Expect(last.State).To(Equal(deps.INCIDENT_UPDATE))
+		Expect(last.Events).To(Equal(3))
+		Expect(last.Reasons).To(Equal([]string{"BackOff", "Pulled", "Unhealthy"}))
+
+		// Earlier notifications are not changed by later ones
+		Expect(first.State).To(Equal(deps.INCIDENT_OPEN))
+		Expect(first.Events).To(Equal(1))
+	})
+
+	It("should close the incident once it has been quiet", func() {
+		t.SendAll(podNotification("web-1234-abcde", "BackOff", "WARN"))
+		clk.Step(5 * time.Minute)
+		t.SendAll(podNotification("web-1234-abcde", "Unhealthy", "ERROR"))
+
+		clk.Step(9 * time.Minute)
+		t.check()
+		Expect(sent()).To(HaveLen(2))
+
+		clk.Step(time.Minute)
+		t.check()
+		Expect(sent()).To(HaveLen(3))
+
+		n := sent()[2]
+		Expect(n.Incident.State).To(Equal(deps.INCIDENT_CLOSE))
+		Expect(n.Incident.ID).To(Equal(sent()[0].Incident.ID))
+		Expect(n.Level).To(Equal("ERROR"))
+		Expect(n.Mention).To(Equal("payments"))
+		Expect(n.Event.Reason).To(Equal(REASON_CLOSED))
+		Expect(n.Event.InvolvedObject.Name).To(Equal("web"))
+		Expect(n.Event.Count).To(Equal(int32(2)))
+		Expect(n.Event.Message).To(ContainSubstring("lasted 5m0s with 2 events: BackOff, Unhealthy"))
+	})
+
+	It("should close quiet incidents while running, until the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			t.Run(ctx)
+			close(stopped)
+		}()
+		Eventually(clk.HasWaiters).Should(BeTrue())
+
+		t.SendAll(podNotification("web-1234-abcde", "BackOff", "WARN"))
+		clk.Step(10 * time.Minute)
+		Eventually(sent).Should(HaveLen(2))
+
+		cancel()
+		Eventually(stopped).Should(BeClosed())
+	})
+
+	It("should forget the incidents still open when abandoned, without closing them", func() {
+		t.SendAll(podNotification("web-1234-abcde", "BackOff", "WARN"))
+		Expect(t.Abandon()).To(Equal(1))
+
+		clk.Step(10 * time.Minute)
+		t.check()
+		Expect(sent()).To(HaveLen(1))
+	})
+
+	It("should open a new incident after the last one closed", func() {
+		t.SendAll(podNotification("web-1234-abcde", "BackOff", "WARN"))
+		clk.Step(10 * time.Minute)
+		t.check()
+		clk.Step(time.Minute)
+		t.SendAll(podNotification("web-1234-abcde", "BackOff", "WARN"))
+
+		Expect(sent()).To(HaveLen(3))
+		Expect(sent()[2].Incident.State).To(Equal(deps.INCIDENT_OPEN))
+		Expect(sent()[2].Incident.ID).ToNot(Equal(sent()[0].Incident.ID))
+	})
+
+	It("should track the pod itself when its owner can't be resolved", func() {
+		t.SendAll(podNotification("missing", "BackOff", "WARN"))
+		Expect(sent()[0].Incident.Object.Name).To(Equal("missing"))
+	})
+
+	Context("when incidents are not grouped by owner", func() {
+		BeforeEach(func() {
+			cfg.IncidentByOwner = false
+		})
+
+		It("should open an incident per involved object", func() {
+			t.SendAll(podNotification("web-1234-abcde", "BackOff", "WARN"))
+			t.SendAll(podNotification("web-1234-fghij", "BackOff", "WARN"))
+
+			Expect(sent()).To(HaveLen(2))
+			Expect(sent()[0].Incident.State).To(Equal(deps.INCIDENT_OPEN))
+			Expect(sent()[1].Incident.State).To(Equal(deps.INCIDENT_OPEN))
+			Expect(sent()[1].Incident.Object.Name).To(Equal("web-1234-fghij"))
+			Expect(fakeGetter.GetCallCount()).To(Equal(0))
+		})
+	})
+})
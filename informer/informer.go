@@ -0,0 +1,165 @@
+package informer
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/events"
+	notifiers "github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+const (
+	DELTA_BUFFER        = 100
+	DEFAULT_STATSD_RATE = 1.0
+)
+
+// A single add, update or delete of an event, emitted once per change. Events from
+// the events.k8s.io API are normalized into core events, with what else they say in Details.
+type Delta struct {
+	Type    watch.EventType
+	Event   api.Event
+	Details *notifiers.EventDetails
+
+	// Set on synthetic events about a container from the pod monitor
+	Container *notifiers.Container
+
+	// Set on synthetic events from the rollout monitor
+	Rollout *notifiers.Rollout
+}
+
+// Informer lists events once to prime a local cache, then keeps the cache up to
+// date from a watch and emits every change on Deltas exactly once.
+type Informer struct {
+	Client       deps.IEventSource
+	Dependencies *deps.Dependencies
+	Deltas       chan Delta
+
+	reflector *Reflector
+	lock      sync.RWMutex
+	store     map[types.UID]Delta
+}
+
+func New(c deps.IEventSource, d *deps.Dependencies) *Informer {
+	i := &Informer{
+		Client:       c,
+		Dependencies: d,
+		Deltas:       make(chan Delta, DELTA_BUFFER),
+		store:        make(map[types.UID]Delta),
+	}
+	i.reflector = &Reflector{
+		Lister:       c,
+		Handler:      i,
+		Dependencies: d,
+		Name:         "events",
+	}
+	return i
+}
+
+// Run keeps the cache in sync with the cluster until the context is done, then
+// closes Deltas. Relisting after the watch's resourceVersion has expired only
+// emits deltas for versions we have not seen yet.
+func (i *Informer) Run(ctx context.Context) {
+	defer close(i.Deltas)
+	i.reflector.Run(ctx)
+}
+
+// Returns a snapshot of every event currently in the cache
+func (i *Informer) List() []api.Event {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	list := make([]api.Event, 0, len(i.store))
+	for _, d := range i.store {
+		list = append(list, d.Event)
+	}
+	return list
+}
+
+// Returns the cached event with the given UID
+func (i *Informer) Get(uid types.UID) (api.Event, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	d, ok := i.store[uid]
+	return d.Event, ok
+}
+
+// Returns why events can't currently be watched, or nil while they are
+func (i *Informer) Err() error {
+	return i.reflector.Err()
+}
+
+// Reports whether the cache has been listed and a watch is keeping it up to date
+func (i *Informer) Synced() bool {
+	return i.reflector.Synced()
+}
+
+// Replace is given the listed events
+func (i *Informer) Replace(ctx context.Context, items []runtime.Object) {
+	listed := make([]Delta, 0, len(items))
+	for _, item := range items {
+		e, details, err := events.Normalize(item)
+		if err != nil {
+			log.Warnf("Skip: unable to read listed event: %v", err.Error())
+			continue
+		}
+		listed = append(listed, Delta{Type: watch.Added, Event: e, Details: details})
+	}
+
+	// Anything we have cached that is no longer listed was deleted while we weren't watching
+	uids := make(map[types.UID]bool, len(listed))
+	for _, d := range listed {
+		uids[d.Event.ObjectMeta.UID] = true
+	}
+	for _, e := range i.List() {
+		if !uids[e.ObjectMeta.UID] {
+			i.update(Delta{Type: watch.Deleted, Event: e})
+		}
+	}
+
+	for _, d := range listed {
+		i.update(d)
+	}
+}
+
+// Update is given each watched change to an event
+func (i *Informer) Update(ctx context.Context, t watch.EventType, obj runtime.Object) {
+	e, details, err := events.Normalize(obj)
+	if err != nil {
+		log.Warnf("Skip: %v in event watch", err.Error())
+		return
+	}
+	i.update(Delta{Type: t, Event: e, Details: details})
+}
+
+// Applies a change to the cache and emits a delta unless we have already seen this version
+func (i *Informer) update(d Delta) {
+	uid := d.Event.ObjectMeta.UID
+
+	i.lock.Lock()
+	cached, ok := i.store[uid]
+	if d.Type == watch.Deleted {
+		delete(i.store, uid)
+	} else {
+		if ok && cached.Event.ResourceVersion == d.Event.ResourceVersion {
+			i.lock.Unlock()
+			return
+		}
+
+		d.Type = watch.Added
+		if ok {
+			d.Type = watch.Modified
+		}
+		i.store[uid] = d
+	}
+	i.lock.Unlock()
+
+	i.Deltas <- d
+}
@@ -0,0 +1,13 @@
+package informer
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestInformerSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Informer Suite")
+}
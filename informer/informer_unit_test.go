@@ -0,0 +1,233 @@
+// +build unit
+
+package informer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cactus/go-statsd-client/statsd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/events"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+)
+
+func newEvent(uid, resourceVersion string, count int32) *api.Event {
+	return &api.Event{
+		ObjectMeta: api.ObjectMeta{
+			UID:             types.UID(uid),
+			ResourceVersion: resourceVersion,
+		},
+		Reason: "BackOff",
+		Count:  count,
+	}
+}
+
+var _ = Describe("Informer", func() {
+	var (
+		fakeEventClient *depsfakes.FakeIEventSource
+		fakeWatch       *watch.FakeWatcher
+		inf             *Informer
+		ctx             context.Context
+		cancel          context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		fakeWatch = watch.NewFake()
+		fakeEventClient = &depsfakes.FakeIEventSource{}
+		fakeEventClient.ListReturns(&api.EventList{
+			ListMeta: unversioned.ListMeta{ResourceVersion: "10"},
+			Items:    []api.Event{*newEvent("a", "5", 1)},
+		}, nil)
+
+		// Every (re)connect gets a fresh watch so tests can end them one at a time
+		fakeWatches := make(chan *watch.FakeWatcher, 1)
+		fakeWatches <- fakeWatch
+		fakeEventClient.WatchStub = func(opts api.ListOptions) (watch.Interface, error) {
+			select {
+			case w := <-fakeWatches:
+				return w, nil
+			default:
+				return watch.NewFake(), nil
+			}
+		}
+
+		inf = New(fakeEventClient, &deps.Dependencies{
+			StatsD: &statsd.NoopClient{},
+		})
+	})
+
+	JustBeforeEach(func() {
+		go inf.Run(ctx)
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	Context("when started", func() {
+		It("should list once and emit each listed event as added", func() {
+			var d Delta
+			Eventually(inf.Deltas).Should(Receive(&d))
+			Expect(d.Type).To(Equal(watch.Added))
+			Expect(d.Event.ObjectMeta.UID).To(Equal(types.UID("a")))
+			Expect(fakeEventClient.ListCallCount()).To(Equal(1))
+		})
+
+		It("should watch from the listed resourceVersion", func() {
+			Eventually(inf.Deltas).Should(Receive())
+			Eventually(fakeEventClient.WatchCallCount).Should(Equal(1))
+			Expect(fakeEventClient.WatchArgsForCall(0).ResourceVersion).To(Equal("10"))
+		})
+	})
+
+	Context("when the watch yields changes", func() {
+		JustBeforeEach(func() {
+			Eventually(inf.Deltas).Should(Receive())
+		})
+
+		It("should not emit a delta for a version already in the cache", func() {
+			fakeWatch.Modify(newEvent("a", "5", 1))
+			Consistently(inf.Deltas).ShouldNot(Receive())
+		})
+
+		It("should emit a modified delta and update the cache", func() {
+			fakeWatch.Modify(newEvent("a", "11", 2))
+
+			var d Delta
+			Eventually(inf.Deltas).Should(Receive(&d))
+			Expect(d.Type).To(Equal(watch.Modified))
+
+			e, ok := inf.Get("a")
+			Expect(ok).To(BeTrue())
+			Expect(e.Count).To(Equal(int32(2)))
+		})
+
+		It("should emit an added delta for new events", func() {
+			fakeWatch.Add(newEvent("b", "12", 1))
+
+			var d Delta
+			Eventually(inf.Deltas).Should(Receive(&d))
+			Expect(d.Type).To(Equal(watch.Added))
+			Expect(inf.List()).To(HaveLen(2))
+		})
+
+		It("should emit events.k8s.io events as core events with their details", func() {
+			fakeWatch.Add(&events.Event{
+				ObjectMeta:          api.ObjectMeta{UID: types.UID("c"), ResourceVersion: "14"},
+				Reason:              "BackOff",
+				Action:              "Restarting",
+				ReportingController: "kubelet",
+				Series:              &events.EventSeries{Count: 3},
+			})
+
+			var d Delta
+			Eventually(inf.Deltas).Should(Receive(&d))
+			Expect(d.Event.Count).To(Equal(int32(3)))
+			Expect(d.Details.Action).To(Equal("Restarting"))
+		})
+
+		It("should emit a deleted delta and drop the event from the cache", func() {
+			fakeWatch.Delete(newEvent("a", "13", 1))
+
+			var d Delta
+			Eventually(inf.Deltas).Should(Receive(&d))
+			Expect(d.Type).To(Equal(watch.Deleted))
+			Expect(inf.List()).To(BeEmpty())
+		})
+	})
+
+	Context("when watching", func() {
+		It("should report no error", func() {
+			Eventually(fakeEventClient.WatchCallCount).Should(Equal(1))
+			Expect(inf.Err()).To(BeNil())
+		})
+	})
+
+	Context("when stopped", func() {
+		It("should stop watching and close Deltas", func() {
+			Eventually(inf.Deltas).Should(Receive())
+			cancel()
+			Eventually(inf.Deltas).Should(BeClosed())
+		})
+	})
+
+	Context("when the list fails", func() {
+		BeforeEach(func() {
+			fakeEventClient.ListReturns(nil, fmt.Errorf("boom"))
+		})
+
+		It("should retry the list without watching", func() {
+			Eventually(fakeEventClient.ListCallCount, 3*time.Second).Should(BeNumerically(">=", 2))
+			Expect(fakeEventClient.WatchCallCount()).To(Equal(0))
+		})
+		It("should report why it can't watch", func() {
+			Eventually(inf.Err).Should(MatchError(ContainSubstring("boom")))
+		})
+	})
+
+	Context("when the watch ends", func() {
+		It("should reconnect and resume from the last resourceVersion seen", func() {
+			Eventually(inf.Deltas).Should(Receive())
+			fakeWatch.Modify(newEvent("a", "11", 2))
+			Eventually(inf.Deltas).Should(Receive())
+			fakeWatch.Stop()
+
+			Eventually(fakeEventClient.WatchCallCount, 3*time.Second).Should(Equal(2))
+			Expect(fakeEventClient.WatchArgsForCall(1).ResourceVersion).To(Equal("11"))
+			Expect(fakeEventClient.ListCallCount()).To(Equal(1))
+		})
+
+		It("should reconnect right away and stay healthy", func() {
+			Eventually(inf.Deltas).Should(Receive())
+			fakeWatch.Modify(newEvent("a", "11", 2))
+			Eventually(inf.Deltas).Should(Receive())
+			fakeWatch.Stop()
+
+			Eventually(fakeEventClient.WatchCallCount, 200*time.Millisecond).Should(Equal(2))
+			Expect(inf.Err()).To(BeNil())
+		})
+
+		It("should back off when it ends right away without a change", func() {
+			Eventually(inf.Deltas).Should(Receive())
+			fakeWatch.Stop()
+
+			Eventually(inf.Err).Should(MatchError(ContainSubstring("closed right away")))
+			Consistently(fakeEventClient.WatchCallCount, 200*time.Millisecond).Should(Equal(1))
+			Eventually(fakeEventClient.WatchCallCount, 3*time.Second).Should(Equal(2))
+		})
+	})
+
+	Context("when the resourceVersion has expired", func() {
+		It("should relist without re-emitting events already seen", func() {
+			Eventually(inf.Deltas).Should(Receive())
+			fakeWatch.Error(&errors.NewGone("too old resource version").ErrStatus)
+
+			Eventually(fakeEventClient.ListCallCount).Should(Equal(2))
+			Consistently(inf.Deltas).ShouldNot(Receive())
+		})
+
+		It("should emit deletes for events that disappeared while relisting", func() {
+			Eventually(inf.Deltas).Should(Receive())
+			fakeEventClient.ListReturns(&api.EventList{
+				ListMeta: unversioned.ListMeta{ResourceVersion: "20"},
+			}, nil)
+			fakeWatch.Error(&errors.NewGone("too old resource version").ErrStatus)
+
+			var d Delta
+			Eventually(inf.Deltas).Should(Receive(&d))
+			Expect(d.Type).To(Equal(watch.Deleted))
+			Expect(inf.List()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,257 @@
+package informer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/cenkalti/backoff"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/meta"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+// Watches are routinely ended by the apiserver, so this isn't a reason to be unhealthy
+var errWatchClosed = fmt.Errorf("watch channel closed")
+
+// A watch closed sooner than this without a change is taken to have failed rather
+// than ended routinely, so reconnecting backs off instead of spinning
+const MIN_WATCH_DURATION = time.Second
+
+// Returned by a list when Stop says there's no point retrying
+var errStopped = fmt.Errorf("stopped")
+
+// ListerWatcher lists and watches one kind of object, eg. deps.IEventSource
+type ListerWatcher interface {
+	List(opts api.ListOptions) (runtime.Object, error)
+	Watch(opts api.ListOptions) (watch.Interface, error)
+}
+
+// Handler is given what a Reflector lists and watches. Both are called from Run's
+// goroutine, so they can block until the context is done.
+type Handler interface {
+	// Every object listed, which replaces whatever was known before
+	Replace(ctx context.Context, items []runtime.Object)
+
+	// A single change from the watch
+	Update(ctx context.Context, t watch.EventType, obj runtime.Object)
+}
+
+// Reflector lists objects once and then watches them, passing both to its Handler.
+// Used for events as well as any other kind of object we follow.
+type Reflector struct {
+	Lister       ListerWatcher
+	Handler      Handler
+	Dependencies *deps.Dependencies
+
+	// What is listed, for logs and errors, eg. "events" or "Pod objects"
+	Name string
+
+	// Optional; reports whether a list error means there is no point retrying, eg.
+	// the cluster doesn't serve the kind
+	Stop func(err error) bool
+
+	lock            sync.RWMutex
+	resourceVersion string
+	err             error
+	synced          bool
+}
+
+// Adapts an IObjectLister to list and watch one kind in one namespace (or all of them)
+func ForKind(l deps.IObjectLister, kind, namespace string) ListerWatcher {
+	return &kindLister{lister: l, kind: kind, namespace: namespace}
+}
+
+type kindLister struct {
+	lister    deps.IObjectLister
+	kind      string
+	namespace string
+}
+
+func (k *kindLister) List(opts api.ListOptions) (runtime.Object, error) {
+	return k.lister.List(k.kind, k.namespace, opts)
+}
+
+func (k *kindLister) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return k.lister.Watch(k.kind, k.namespace, opts)
+}
+
+// Run keeps the Handler in sync with the cluster until the context is done, or Stop
+// says to give up. When the watch ends it reconnects right away, and when it fails
+// with jittered exponential backoff, resuming from the last resourceVersion seen. If
+// that version has expired (410 Gone) everything is relisted.
+func (r *Reflector) Run(ctx context.Context) {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 0
+
+	for {
+		received, err := r.listAndWatch(ctx)
+		if ctx.Err() != nil {
+			log.Debugf("Watching %s stopped", r.Name)
+			return
+		}
+		if err == errStopped {
+			return
+		}
+		if received {
+			b.Reset()
+		}
+
+		if err == errWatchClosed {
+			log.Debugf("Watching %s has ended, reconnecting", r.Name)
+			r.inc("informer.reconnects")
+			continue
+		}
+
+		if !isGone(err) {
+			r.setErr(err)
+		}
+
+		if isGone(err) {
+			log.Warnf("%s resourceVersion %s has expired, relisting: %v", r.Name, r.resourceVersion, err.Error())
+			r.resourceVersion = ""
+			r.inc("informer.relists")
+			continue
+		}
+
+		wait := b.NextBackOff()
+		log.Errorf("Watching %s has ended, reconnecting in %v: %v", r.Name, wait, err.Error())
+		select {
+		case <-ctx.Done():
+			log.Debugf("Watching %s stopped", r.Name)
+			return
+		case <-time.After(wait):
+		}
+		r.inc("informer.reconnects")
+	}
+}
+
+// Returns why the objects can't currently be watched, or nil while they are
+func (r *Reflector) Err() error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.err
+}
+
+// Reports whether the objects have been listed and a watch is following them
+func (r *Reflector) Synced() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.synced
+}
+
+// A nil error means the watch has been (re)established
+func (r *Reflector) setErr(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.err = err
+	r.synced = err == nil
+}
+
+// Lists (unless we can resume from a known resourceVersion) then watches until
+// the watch ends. Reports whether any change was received so backoff can be reset.
+func (r *Reflector) listAndWatch(ctx context.Context) (bool, error) {
+	if r.resourceVersion == "" {
+		if err := r.list(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	return r.watch(ctx)
+}
+
+func (r *Reflector) list(ctx context.Context) error {
+	list, err := r.Lister.List(api.ListOptions{
+		ResourceVersion: "0",
+	})
+	if err != nil {
+		if r.Stop != nil && r.Stop(err) {
+			return errStopped
+		}
+		return fmt.Errorf("Unable to list %s: %v", r.Name, err.Error())
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return fmt.Errorf("Unable to read %s: %v", r.Name, err.Error())
+	}
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return fmt.Errorf("Unable to read %s: %v", r.Name, err.Error())
+	}
+
+	r.Handler.Replace(ctx, items)
+	r.resourceVersion = listMeta.GetResourceVersion()
+
+	log.Debugf("Listed %d %s", len(items), r.Name)
+	return nil
+}
+
+func (r *Reflector) watch(ctx context.Context) (bool, error) {
+	wi, err := r.Lister.Watch(api.ListOptions{
+		ResourceVersion: r.resourceVersion,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer wi.Stop()
+
+	r.setErr(nil)
+	log.Infof("Watching for %s from resourceVersion %s...", r.Name, r.resourceVersion)
+	started := time.Now()
+
+	received := false
+	for {
+		var we watch.Event
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return received, ctx.Err()
+		case we, ok = <-wi.ResultChan():
+		}
+		if !ok && !received && time.Since(started) < MIN_WATCH_DURATION {
+			return received, fmt.Errorf("Watch of %s closed right away", r.Name)
+		}
+		if !ok {
+			return received, errWatchClosed
+		}
+
+		if we.Type == watch.Error {
+			return received, errors.FromObject(we.Object)
+		}
+		received = true
+
+		m, err := api.ObjectMetaFor(we.Object)
+		if err != nil {
+			log.Warnf("Skip: unexpected object in %s watch: %T", r.Name, we.Object)
+			continue
+		}
+
+		log.Debugf("%s %s detected", we.Type, r.Name)
+		r.Handler.Update(ctx, we.Type, we.Object)
+		r.resourceVersion = m.ResourceVersion
+	}
+}
+
+func (r *Reflector) inc(stat string) {
+	if r.Dependencies == nil || r.Dependencies.StatsD == nil {
+		return
+	}
+	go r.Dependencies.StatsD.Inc(stat, 1, DEFAULT_STATSD_RATE)
+}
+
+// A watch or list against an expired resourceVersion fails with 410 Gone
+func isGone(err error) bool {
+	status, ok := err.(errors.APIStatus)
+	if !ok {
+		return false
+	}
+	return status.Status().Code == http.StatusGone
+}
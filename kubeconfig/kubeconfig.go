@@ -0,0 +1,223 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/kubernetes/pkg/client/restclient"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+)
+
+const (
+	// Where the API is reached out of cluster when nothing else is configured, ie. through kubectl proxy
+	DEFAULT_HOST = "http://127.0.0.1:8001"
+)
+
+// A kubeconfig file as written by kubectl. The client we build against doesn't
+// include the loader, so only the fields we use are declared.
+type file struct {
+	CurrentContext string         `json:"current-context"`
+	Clusters       []namedCluster `json:"clusters"`
+	Users          []namedUser    `json:"users"`
+	Contexts       []namedContext `json:"contexts"`
+}
+
+type namedCluster struct {
+	Name    string  `json:"name"`
+	Cluster cluster `json:"cluster"`
+}
+
+type cluster struct {
+	Server                   string `json:"server"`
+	InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+	CertificateAuthority     string `json:"certificate-authority"`
+	CertificateAuthorityData []byte `json:"certificate-authority-data"`
+}
+
+type namedUser struct {
+	Name string `json:"name"`
+	User user   `json:"user"`
+}
+
+type user struct {
+	ClientCertificate     string `json:"client-certificate"`
+	ClientCertificateData []byte `json:"client-certificate-data"`
+	ClientKey             string `json:"client-key"`
+	ClientKeyData         []byte `json:"client-key-data"`
+	Token                 string `json:"token"`
+	TokenFile             string `json:"tokenFile"`
+	Username              string `json:"username"`
+	Password              string `json:"password"`
+
+	// Plugins the client predates, declared only to refuse them rather than connect without credentials
+	Exec         interface{} `json:"exec"`
+	AuthProvider interface{} `json:"auth-provider"`
+}
+
+type namedContext struct {
+	Name    string  `json:"name"`
+	Context context `json:"context"`
+}
+
+type context struct {
+	Cluster string `json:"cluster"`
+	User    string `json:"user"`
+}
+
+// New returns how to reach the cluster: the service account when running in
+// cluster, otherwise the kubeconfig context (if a kubeconfig is given) with any
+// explicitly configured host, token, certificates and verification on top.
+func New(cfg *config.Config) (*restclient.Config, error) {
+	if cfg.InCluster {
+		c, err := restclient.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load in cluster config: %v", err.Error())
+		}
+		return c, nil
+	}
+
+	c := &restclient.Config{Host: DEFAULT_HOST}
+	if cfg.Kubeconfig != "" {
+		var err error
+		if c, err = Load(cfg.Kubeconfig, cfg.KubeContext); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.ClusterHost != "" {
+		c.Host = cfg.ClusterHost
+	}
+	if cfg.ClusterToken != "" {
+		c.BearerToken = cfg.ClusterToken
+		c.Username, c.Password = "", ""
+	}
+	if cfg.ClusterCert != "" {
+		c.CertFile, c.CertData = cfg.ClusterCert, nil
+		c.KeyFile, c.KeyData = cfg.ClusterKey, nil
+	}
+	if cfg.ClusterCA != "" {
+		c.CAFile, c.CAData = cfg.ClusterCA, nil
+		c.Insecure = false
+	}
+	if cfg.ClusterInsecure {
+		// Verification can't be skipped while a CA is given to verify with
+		c.Insecure = true
+		c.CAFile, c.CAData = "", nil
+	}
+
+	return c, nil
+}
+
+// Load reads how to reach a cluster from a context in a kubeconfig file, or its
+// current context if none is named. Relative paths in the file are relative to it.
+func Load(path, contextName string) (*restclient.Config, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read kubeconfig: %v", err.Error())
+	}
+
+	var f file
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("Unable to parse kubeconfig %s: %v", path, err.Error())
+	}
+
+	if contextName == "" {
+		contextName = f.CurrentContext
+	}
+	if contextName == "" {
+		return nil, fmt.Errorf("kubeconfig %s has no current context, one must be named", path)
+	}
+
+	ctx, ok := f.context(contextName)
+	if !ok {
+		return nil, fmt.Errorf("context '%s' not found in kubeconfig %s", contextName, path)
+	}
+	cl, ok := f.cluster(ctx.Cluster)
+	if !ok {
+		return nil, fmt.Errorf("cluster '%s' of context '%s' not found in kubeconfig %s", ctx.Cluster, contextName, path)
+	}
+	u, ok := f.user(ctx.User)
+	if !ok && ctx.User != "" {
+		return nil, fmt.Errorf("user '%s' of context '%s' not found in kubeconfig %s", ctx.User, contextName, path)
+	}
+	if plugin := u.plugin(); plugin != "" {
+		return nil, fmt.Errorf("user '%s' of context '%s' in kubeconfig %s uses unsupported auth '%s', only tokens, client certificates and passwords are supported", ctx.User, contextName, path, plugin)
+	}
+
+	dir := filepath.Dir(path)
+	c := &restclient.Config{
+		Host:        cl.Server,
+		BearerToken: u.Token,
+		Username:    u.Username,
+		Password:    u.Password,
+		Insecure:    cl.InsecureSkipTLSVerify,
+		TLSClientConfig: restclient.TLSClientConfig{
+			CAFile:   resolve(dir, cl.CertificateAuthority),
+			CAData:   cl.CertificateAuthorityData,
+			CertFile: resolve(dir, u.ClientCertificate),
+			CertData: u.ClientCertificateData,
+			KeyFile:  resolve(dir, u.ClientKey),
+			KeyData:  u.ClientKeyData,
+		},
+	}
+
+	if c.BearerToken == "" && u.TokenFile != "" {
+		token, err := ioutil.ReadFile(resolve(dir, u.TokenFile))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read token of user '%s': %v", ctx.User, err.Error())
+		}
+		c.BearerToken = strings.TrimSpace(string(token))
+	}
+
+	return c, nil
+}
+
+func (f *file) context(name string) (context, bool) {
+	for _, c := range f.Contexts {
+		if c.Name == name {
+			return c.Context, true
+		}
+	}
+	return context{}, false
+}
+
+func (f *file) cluster(name string) (cluster, bool) {
+	for _, c := range f.Clusters {
+		if c.Name == name {
+			return c.Cluster, true
+		}
+	}
+	return cluster{}, false
+}
+
+func (f *file) user(name string) (user, bool) {
+	for _, u := range f.Users {
+		if u.Name == name {
+			return u.User, true
+		}
+	}
+	return user{}, false
+}
+
+// Names the auth plugin the user authenticates with, if any
+func (u user) plugin() string {
+	switch {
+	case u.Exec != nil:
+		return "exec"
+	case u.AuthProvider != nil:
+		return "auth-provider"
+	}
+	return ""
+}
+
+// Makes a path from the kubeconfig relative to the directory it is in
+func resolve(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
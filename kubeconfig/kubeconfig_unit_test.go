@@ -0,0 +1,194 @@
+// +build unit
+
+package kubeconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: staging
+  cluster:
+    server: https://staging.example.com
+    certificate-authority: certs/staging-ca.crt
+- name: prod
+  cluster:
+    server: https://prod.example.com
+    certificate-authority-data: Y2EtZGF0YQ==
+users:
+- name: ops
+  user:
+    client-certificate: /etc/ops/client.crt
+    client-key: /etc/ops/client.key
+- name: robot
+  user:
+    tokenFile: robot-token
+- name: gke
+  user:
+    auth-provider:
+      name: gcp
+- name: eks
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1alpha1
+      command: aws-iam-authenticator
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: ops
+- name: prod
+  context:
+    cluster: prod
+    user: robot
+- name: broken
+  context:
+    cluster: missing
+    user: ops
+- name: gke
+  context:
+    cluster: prod
+    user: gke
+- name: eks
+  context:
+    cluster: prod
+    user: eks
+`
+
+var _ = Describe("Kubeconfig", func() {
+	var (
+		dir  string
+		path string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "kubeconfig")
+		Expect(err).To(BeNil())
+
+		path = filepath.Join(dir, "config")
+		Expect(ioutil.WriteFile(path, []byte(testKubeconfig), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "robot-token"), []byte("s3cr3t\n"), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Load", func() {
+		It("should use the current context when none is named", func() {
+			c, err := Load(path, "")
+			Expect(err).To(BeNil())
+			Expect(c.Host).To(Equal("https://staging.example.com"))
+			Expect(c.CertFile).To(Equal("/etc/ops/client.crt"))
+			Expect(c.KeyFile).To(Equal("/etc/ops/client.key"))
+		})
+
+		It("should resolve relative paths against the kubeconfig's directory", func() {
+			c, err := Load(path, "staging")
+			Expect(err).To(BeNil())
+			Expect(c.CAFile).To(Equal(filepath.Join(dir, "certs/staging-ca.crt")))
+		})
+
+		It("should use the named context, its inline CA and token file", func() {
+			c, err := Load(path, "prod")
+			Expect(err).To(BeNil())
+			Expect(c.Host).To(Equal("https://prod.example.com"))
+			Expect(string(c.CAData)).To(Equal("ca-data"))
+			Expect(c.BearerToken).To(Equal("s3cr3t"))
+		})
+
+		It("should error when the context is unknown", func() {
+			_, err := Load(path, "dev")
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("context 'dev' not found"))
+		})
+
+		It("should error when the context's cluster is unknown", func() {
+			_, err := Load(path, "broken")
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("cluster 'missing'"))
+		})
+
+		It("should error when the user authenticates with a plugin", func() {
+			_, err := Load(path, "gke")
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("unsupported auth 'auth-provider'"))
+
+			_, err = Load(path, "eks")
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("unsupported auth 'exec'"))
+		})
+
+		It("should error when the file doesn't exist", func() {
+			_, err := Load(filepath.Join(dir, "missing"), "")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Describe("New", func() {
+		var cfg *config.Config
+
+		BeforeEach(func() {
+			cfg = config.New()
+		})
+
+		It("should go through kubectl proxy when nothing is configured", func() {
+			c, err := New(cfg)
+			Expect(err).To(BeNil())
+			Expect(c.Host).To(Equal(DEFAULT_HOST))
+		})
+
+		It("should use explicit settings without a kubeconfig", func() {
+			cfg.ClusterHost = "https://10.0.0.1"
+			cfg.ClusterToken = "token"
+			cfg.ClusterCA = "/etc/ca.crt"
+			c, err := New(cfg)
+			Expect(err).To(BeNil())
+			Expect(c.Host).To(Equal("https://10.0.0.1"))
+			Expect(c.BearerToken).To(Equal("token"))
+			Expect(c.CAFile).To(Equal("/etc/ca.crt"))
+		})
+
+		It("should apply explicit settings over the kubeconfig context", func() {
+			cfg.Kubeconfig = path
+			cfg.KubeContext = "prod"
+			cfg.ClusterToken = "override"
+			cfg.ClusterInsecure = true
+			c, err := New(cfg)
+			Expect(err).To(BeNil())
+			Expect(c.Host).To(Equal("https://prod.example.com"))
+			Expect(c.BearerToken).To(Equal("override"))
+			Expect(c.Insecure).To(BeTrue())
+			Expect(c.CAData).To(BeNil())
+		})
+
+		It("should replace the kubeconfig's client certificate", func() {
+			cfg.Kubeconfig = path
+			cfg.ClusterCert = "/etc/me.crt"
+			cfg.ClusterKey = "/etc/me.key"
+			c, err := New(cfg)
+			Expect(err).To(BeNil())
+			Expect(c.CertFile).To(Equal("/etc/me.crt"))
+			Expect(c.KeyFile).To(Equal("/etc/me.key"))
+		})
+
+		It("should error when the kubeconfig can't be loaded", func() {
+			cfg.Kubeconfig = path
+			cfg.KubeContext = "dev"
+			_, err := New(cfg)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+})
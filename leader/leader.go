@@ -0,0 +1,266 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/wait"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+const (
+	LEADER_ANNOTATION = "control-plane.alpha.kubernetes.io/leader"
+	JITTER_FACTOR     = 1.2
+)
+
+// The lock record stored as an annotation on the lock Endpoints object
+type Record struct {
+	HolderIdentity       string           `json:"holderIdentity"`
+	LeaseDurationSeconds int              `json:"leaseDurationSeconds"`
+	AcquireTime          unversioned.Time `json:"acquireTime"`
+	RenewTime            unversioned.Time `json:"renewTime"`
+	LeaderTransitions    int              `json:"leaderTransitions"`
+}
+
+// Elector competes for an Endpoints based lock so only one replica watches and
+// notifies at a time.
+type Elector struct {
+	Client        deps.IEndpointsClient
+	Name          string
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+
+	lock           sync.RWMutex
+	leader         bool
+	observedRecord Record
+	observedTime   time.Time
+}
+
+func New(cfg *config.Config, c deps.IEndpointsClient) *Elector {
+	// Default to the hostname, which is the pod name when running in a cluster
+	identity := cfg.LeaderElectIdentity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatalf("Unable to determine leader election identity: %v", err.Error())
+		}
+		identity = hostname
+	}
+
+	return &Elector{
+		Client:        c,
+		Name:          cfg.LeaderElectName,
+		Identity:      identity,
+		LeaseDuration: time.Duration(cfg.LeaderElectLeaseDuration) * time.Second,
+		RenewDeadline: time.Duration(cfg.LeaderElectRenewDeadline) * time.Second,
+		RetryPeriod:   time.Duration(cfg.LeaderElectRetryPeriod) * time.Second,
+	}
+}
+
+// Run competes for the lock until the context is done. Each time the lock is
+// acquired onStartedLeading is called and the lock is then renewed; when it cannot
+// be renewed onStoppedLeading is called and we compete for it again. Both are called
+// from Run's goroutine, so the lock isn't renewed or competed for until they return.
+// onStartedLeading receives the last time the previous leader renewed the lock, or
+// the zero time when there was no previous leader.
+func (e *Elector) Run(ctx context.Context, onStartedLeading func(handover time.Time), onStoppedLeading func()) {
+	for {
+		handover, ok := e.acquire(ctx)
+		if !ok {
+			return
+		}
+		onStartedLeading(handover)
+
+		if !e.renew(ctx) {
+			return
+		}
+		onStoppedLeading()
+	}
+}
+
+// Reports whether this instance currently holds the lock
+func (e *Elector) IsLeader() bool {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.leader
+}
+
+// Blocks until the lock is acquired, or reports false once the context is done
+func (e *Elector) acquire(ctx context.Context) (time.Time, bool) {
+	log.Infof("Attempting to acquire leader lock %s as %s...", e.Name, e.Identity)
+	for {
+		if handover, ok := e.tryAcquireOrRenew(); ok {
+			log.Infof("Acquired leader lock %s as %s", e.Name, e.Identity)
+			return handover, true
+		}
+		if !sleep(ctx, wait.Jitter(e.RetryPeriod, JITTER_FACTOR)) {
+			return time.Time{}, false
+		}
+	}
+}
+
+// Keeps renewing the lock until that fails, or reports false once the context is done
+func (e *Elector) renew(ctx context.Context) bool {
+	for {
+		deadline := time.Now().Add(e.RenewDeadline)
+		renewed := false
+		for time.Now().Before(deadline) {
+			if _, renewed = e.tryAcquireOrRenew(); renewed {
+				break
+			}
+			if !sleep(ctx, e.RetryPeriod) {
+				return false
+			}
+		}
+
+		if !renewed {
+			log.Errorf("Unable to renew leader lock %s within %v", e.Name, e.RenewDeadline)
+			e.setLeader(false)
+			return true
+		}
+		if !sleep(ctx, e.RetryPeriod) {
+			return false
+		}
+	}
+}
+
+// Waits for d, or reports false if the context is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// Tries to take or renew the lock. When taking it over from another holder the
+// previous holder's last renew time is returned.
+func (e *Elector) tryAcquireOrRenew() (time.Time, bool) {
+	now := unversioned.Now()
+	record := Record{
+		HolderIdentity:       e.Identity,
+		LeaseDurationSeconds: int(e.LeaseDuration / time.Second),
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+
+	ep, err := e.Client.Get(e.Name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Errorf("Unable to get leader lock %s: %v", e.Name, err.Error())
+			return time.Time{}, false
+		}
+
+		raw, err := json.Marshal(record)
+		if err != nil {
+			log.Errorf("Unable to marshal leader record: %v", err.Error())
+			return time.Time{}, false
+		}
+		_, err = e.Client.Create(&api.Endpoints{
+			ObjectMeta: api.ObjectMeta{
+				Name: e.Name,
+				Annotations: map[string]string{
+					LEADER_ANNOTATION: string(raw),
+				},
+			},
+		})
+		if err != nil {
+			log.Errorf("Unable to create leader lock %s: %v", e.Name, err.Error())
+			return time.Time{}, false
+		}
+
+		e.observe(record)
+		e.setLeader(true)
+		return time.Time{}, true
+	}
+
+	var old Record
+	if raw, ok := ep.Annotations[LEADER_ANNOTATION]; ok {
+		if err := json.Unmarshal([]byte(raw), &old); err != nil {
+			log.Errorf("Unable to unmarshal leader record: %v", err.Error())
+			return time.Time{}, false
+		}
+	}
+
+	// Lease expiry is judged from when we first observed the record so clock skew between replicas doesn't matter
+	if !reflect.DeepEqual(old, e.observedRecord) {
+		e.observe(old)
+	}
+	if old.HolderIdentity != "" && old.HolderIdentity != e.Identity && e.observedTime.Add(e.LeaseDuration).After(time.Now()) {
+		log.Debugf("Leader lock %s is held by %s", e.Name, old.HolderIdentity)
+		e.setLeader(false)
+		return time.Time{}, false
+	}
+
+	var handover time.Time
+	if old.HolderIdentity == e.Identity {
+		record.AcquireTime = old.AcquireTime
+		record.LeaderTransitions = old.LeaderTransitions
+	} else {
+		record.LeaderTransitions = old.LeaderTransitions + 1
+		handover = old.RenewTime.Time
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		log.Errorf("Unable to marshal leader record: %v", err.Error())
+		return time.Time{}, false
+	}
+	if ep.Annotations == nil {
+		ep.Annotations = make(map[string]string)
+	}
+	ep.Annotations[LEADER_ANNOTATION] = string(raw)
+
+	// The update fails on conflict if another replica changed the lock since our get
+	if _, err := e.Client.Update(ep); err != nil {
+		log.Errorf("Unable to update leader lock %s: %v", e.Name, err.Error())
+		return time.Time{}, false
+	}
+
+	e.observe(record)
+	e.setLeader(true)
+	return handover, true
+}
+
+func (e *Elector) observe(record Record) {
+	e.observedRecord = record
+	e.observedTime = time.Now()
+}
+
+func (e *Elector) setLeader(leader bool) {
+	e.lock.Lock()
+	e.leader = leader
+	e.lock.Unlock()
+}
+
+// Electors are the locks held in each cluster, by cluster name
+type Electors map[string]*Elector
+
+// Reports whether this instance holds the lock in any cluster
+func (es Electors) IsLeader() bool {
+	for _, e := range es {
+		if e.IsLeader() {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether this instance holds the lock in the named cluster
+func (es Electors) IsLeaderOf(cluster string) bool {
+	e, ok := es[cluster]
+	return ok && e.IsLeader()
+}
@@ -0,0 +1,13 @@
+package leader
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLeaderSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Leader Suite")
+}
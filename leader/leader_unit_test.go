@@ -0,0 +1,241 @@
+// +build unit
+
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+)
+
+func lockHeldBy(identity string, renewTime time.Time, transitions int) *api.Endpoints {
+	raw, _ := json.Marshal(Record{
+		HolderIdentity:    identity,
+		AcquireTime:       unversioned.NewTime(renewTime.Add(-time.Hour)),
+		RenewTime:         unversioned.NewTime(renewTime),
+		LeaderTransitions: transitions,
+	})
+	return &api.Endpoints{
+		ObjectMeta: api.ObjectMeta{
+			Name: "kit-overwatch",
+			Annotations: map[string]string{
+				LEADER_ANNOTATION: string(raw),
+			},
+		},
+	}
+}
+
+func recordOf(ep *api.Endpoints) Record {
+	var r Record
+	json.Unmarshal([]byte(ep.Annotations[LEADER_ANNOTATION]), &r)
+	return r
+}
+
+var _ = Describe("Elector", func() {
+	var (
+		cfg                 *config.Config
+		fakeEndpointsClient *depsfakes.FakeIEndpointsClient
+		elector             *Elector
+	)
+
+	BeforeEach(func() {
+		cfg = config.New()
+		cfg.LeaderElectName = "kit-overwatch"
+		cfg.LeaderElectIdentity = "replica-a"
+		cfg.LeaderElectLeaseDuration = 15
+		cfg.LeaderElectRenewDeadline = 10
+		cfg.LeaderElectRetryPeriod = 2
+
+		fakeEndpointsClient = &depsfakes.FakeIEndpointsClient{}
+		fakeEndpointsClient.UpdateStub = func(ep *api.Endpoints) (*api.Endpoints, error) {
+			return ep, nil
+		}
+		fakeEndpointsClient.CreateStub = func(ep *api.Endpoints) (*api.Endpoints, error) {
+			return ep, nil
+		}
+
+		elector = New(cfg, fakeEndpointsClient)
+	})
+
+	Describe("New", func() {
+		It("should use the configured timings", func() {
+			Expect(elector.Identity).To(Equal("replica-a"))
+			Expect(elector.LeaseDuration).To(Equal(15 * time.Second))
+			Expect(elector.RenewDeadline).To(Equal(10 * time.Second))
+			Expect(elector.RetryPeriod).To(Equal(2 * time.Second))
+		})
+
+		It("should default the identity to the hostname", func() {
+			cfg.LeaderElectIdentity = ""
+			Expect(New(cfg, fakeEndpointsClient).Identity).ToNot(BeEmpty())
+		})
+	})
+
+	Context("when the lock does not exist", func() {
+		BeforeEach(func() {
+			fakeEndpointsClient.GetReturns(nil, errors.NewNotFound(api.Resource("endpoints"), "kit-overwatch"))
+		})
+
+		It("should create it and become leader with no handover", func() {
+			handover, ok := elector.tryAcquireOrRenew()
+			Expect(ok).To(BeTrue())
+			Expect(handover.IsZero()).To(BeTrue())
+			Expect(elector.IsLeader()).To(BeTrue())
+
+			Expect(fakeEndpointsClient.CreateCallCount()).To(Equal(1))
+			Expect(recordOf(fakeEndpointsClient.CreateArgsForCall(0)).HolderIdentity).To(Equal("replica-a"))
+		})
+	})
+
+	Context("when the lock is held by another replica", func() {
+		var lastRenew time.Time
+
+		BeforeEach(func() {
+			lastRenew = time.Now().Add(-5 * time.Second).Truncate(time.Second)
+			fakeEndpointsClient.GetReturns(lockHeldBy("replica-b", lastRenew, 3), nil)
+		})
+
+		It("should stay standby while the lease is fresh", func() {
+			_, ok := elector.tryAcquireOrRenew()
+			Expect(ok).To(BeFalse())
+			Expect(elector.IsLeader()).To(BeFalse())
+			Expect(fakeEndpointsClient.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("should take over once the lease has expired and report the handover time", func() {
+			elector.LeaseDuration = 0
+
+			handover, ok := elector.tryAcquireOrRenew()
+			Expect(ok).To(BeTrue())
+			Expect(handover).To(BeTemporally("==", lastRenew))
+			Expect(elector.IsLeader()).To(BeTrue())
+
+			record := recordOf(fakeEndpointsClient.UpdateArgsForCall(0))
+			Expect(record.HolderIdentity).To(Equal("replica-a"))
+			Expect(record.LeaderTransitions).To(Equal(4))
+		})
+
+		It("should not become leader when another replica updated the lock first", func() {
+			elector.LeaseDuration = 0
+			fakeEndpointsClient.UpdateReturns(nil, fmt.Errorf("conflict"))
+
+			_, ok := elector.tryAcquireOrRenew()
+			Expect(ok).To(BeFalse())
+			Expect(elector.IsLeader()).To(BeFalse())
+		})
+	})
+
+	Context("when the lock is already held by this replica", func() {
+		BeforeEach(func() {
+			fakeEndpointsClient.GetReturns(lockHeldBy("replica-a", time.Now(), 2), nil)
+		})
+
+		It("should renew it without a handover", func() {
+			handover, ok := elector.tryAcquireOrRenew()
+			Expect(ok).To(BeTrue())
+			Expect(handover.IsZero()).To(BeTrue())
+
+			record := recordOf(fakeEndpointsClient.UpdateArgsForCall(0))
+			Expect(record.LeaderTransitions).To(Equal(2))
+		})
+	})
+})
+
+var _ = Describe("Elector.Run", func() {
+	var (
+		fakeEndpointsClient *depsfakes.FakeIEndpointsClient
+		elector             *Elector
+		lock                sync.Mutex
+		unreachable         bool
+	)
+
+	setUnreachable := func(u bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		unreachable = u
+	}
+
+	BeforeEach(func() {
+		cfg := config.New()
+		cfg.LeaderElectName = "kit-overwatch"
+		cfg.LeaderElectIdentity = "replica-a"
+
+		unreachable = false
+		fakeEndpointsClient = &depsfakes.FakeIEndpointsClient{}
+		fakeEndpointsClient.GetStub = func(name string) (*api.Endpoints, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			if unreachable {
+				return nil, fmt.Errorf("connection refused")
+			}
+			return lockHeldBy("replica-a", time.Now(), 1), nil
+		}
+		fakeEndpointsClient.UpdateStub = func(ep *api.Endpoints) (*api.Endpoints, error) {
+			return ep, nil
+		}
+
+		elector = New(cfg, fakeEndpointsClient)
+		elector.RenewDeadline = 5 * time.Millisecond
+		elector.RetryPeriod = time.Millisecond
+	})
+
+	It("should compete for the lock again after failing to renew it, until the context is done", func() {
+		started := make(chan time.Time, 10)
+		stopped := make(chan struct{}, 10)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			elector.Run(ctx, func(handover time.Time) {
+				started <- handover
+			}, func() {
+				stopped <- struct{}{}
+			})
+			close(done)
+		}()
+
+		Eventually(started).Should(Receive())
+		Expect(elector.IsLeader()).To(BeTrue())
+
+		setUnreachable(true)
+		Eventually(stopped).Should(Receive())
+		Expect(elector.IsLeader()).To(BeFalse())
+
+		setUnreachable(false)
+		Eventually(started).Should(Receive())
+		Expect(elector.IsLeader()).To(BeTrue())
+
+		cancel()
+		Eventually(done).Should(BeClosed())
+		Expect(stopped).ToNot(Receive())
+	})
+})
+
+var _ = Describe("Electors", func() {
+	It("should be leader while holding the lock in any cluster", func() {
+		electors := Electors{"prod": {}, "staging": {}}
+		Expect(electors.IsLeader()).To(BeFalse())
+
+		electors["staging"].setLeader(true)
+		Expect(electors.IsLeader()).To(BeTrue())
+	})
+
+	It("should report the lock held in each cluster", func() {
+		electors := Electors{"prod": {}, "staging": {}}
+		electors["staging"].setLeader(true)
+
+		Expect(electors.IsLeaderOf("prod")).To(BeFalse())
+		Expect(electors.IsLeaderOf("staging")).To(BeTrue())
+		Expect(electors.IsLeaderOf("edge")).To(BeFalse())
+	})
+})
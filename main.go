@@ -1,6 +1,12 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -8,10 +14,14 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/zorkian/go-datadog-api"
 	"gopkg.in/alecthomas/kingpin.v2"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
 
 	"github.com/InVisionApp/kit-overwatch/api"
 	"github.com/InVisionApp/kit-overwatch/config"
 	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/kubeconfig"
+	"github.com/InVisionApp/kit-overwatch/leader"
+	"github.com/InVisionApp/kit-overwatch/state"
 	"github.com/InVisionApp/kit-overwatch/watcher"
 )
 
@@ -58,9 +68,16 @@ func main() {
 		log.Fatalf("Unable to instantiate statsd client: %v", err.Error())
 	}
 
+	// Open the store used to dedup and throttle notifications
+	store, err := state.New(cfg)
+	if err != nil {
+		log.Fatalf("Unable to instantiate state store: %v", err.Error())
+	}
+
 	// For dependency injection
 	d := &deps.Dependencies{
 		StatsD: statsdClient,
+		State:  store,
 	}
 
 	// Add datadog if enabled
@@ -69,11 +86,159 @@ func main() {
 		d.DDClient = ddClient
 	}
 
-	// Start the watcher
-	w := watcher.New(cfg, d)
-	go w.Watch()
+	// Stops watching for events when cancelled
+	ctx, stop := context.WithCancel(context.Background())
+
+	// Set up a watcher per cluster; one that can't be set up doesn't stop the others
+	clusters, err := cfg.Clusters()
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err.Error())
+	}
+	var watchers []*watcher.Watcher
+	var current []*watcher.Current
+	for _, clusterCfg := range clusters {
+		w, err := watcher.New(clusterCfg, d)
+		if err != nil {
+			log.Errorf("Unable to watch cluster %s: %v", clusterCfg.ClusterName, err.Error())
+			d.Clusters = append(d.Clusters, &watcher.Unavailable{Cluster: clusterCfg.ClusterName, Err: err})
+			continue
+		}
+		c := watcher.NewCurrent(w)
+		watchers = append(watchers, w)
+		current = append(current, c)
+		d.Clusters = append(d.Clusters, c)
+	}
+	if len(watchers) == 0 {
+		log.Fatalf("Unable to watch any cluster")
+	}
+
+	// Start the watchers, each only once we hold the leader lock in its cluster if
+	// running more than one instance. Losing a lock only stops watching that cluster
+	// until the lock is won again.
+	var lock sync.Mutex
+	var dropped sync.WaitGroup
+	watching := make(map[*watcher.Watcher]context.CancelFunc)
+	watch := func(w *watcher.Watcher) {
+		lock.Lock()
+		defer lock.Unlock()
+		if watching == nil {
+			return
+		}
+		wCtx, cancel := context.WithCancel(ctx)
+		watching[w] = cancel
+		go w.Watch(wCtx)
+	}
+	drop := func(w *watcher.Watcher) {
+		lock.Lock()
+		cancel, ok := watching[w]
+		if ok {
+			delete(watching, w)
+			dropped.Add(1)
+		}
+		lock.Unlock()
+		if !ok {
+			return
+		}
+
+		cancel()
+		defer dropped.Done()
+		dropCtx, cancelDrop := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+		defer cancelDrop()
+		if err := w.Shutdown(dropCtx); err != nil {
+			log.Errorf("Unable to send every notification about cluster %s after losing its leader lock: %v", w.Name(), err.Error())
+		}
+	}
+	if cfg.LeaderElect {
+		electors := make(leader.Electors)
+		for i, w := range watchers {
+			clusterCfg := w.Config
+			leaderClient, err := kubeClient(&clusterCfg)
+			if err != nil {
+				log.Fatalf("Unable to instantiate leader election client for cluster %s: %v", w.Name(), err.Error())
+			}
+			elector := leader.New(&clusterCfg, leaderClient.Endpoints(clusterCfg.LeaderElectNamespace))
+			electors[clusterCfg.ClusterName] = elector
+
+			// A watcher can't be restarted once shut down, so one is set up afresh each time the lock is won again
+			w, c := w, current[i]
+			go elector.Run(ctx, func(handover time.Time) {
+				if w == nil {
+					var err error
+					if w, err = watcher.New(&clusterCfg, d); err != nil {
+						log.Errorf("Unable to watch cluster %s: %v", clusterCfg.ClusterName, err.Error())
+						c.Set(&watcher.Unavailable{Cluster: clusterCfg.ClusterName, Err: err})
+						return
+					}
+					c.Set(w)
+				}
+				w.HandoverTime = handover
+				watch(w)
+			}, func() {
+				log.Errorf("Lost leader lock %s in cluster %s, no longer watching it until the lock is won again", clusterCfg.LeaderElectName, clusterCfg.ClusterName)
+				if w != nil {
+					drop(w)
+					w = nil
+				}
+			})
+		}
+		d.Leader = electors
+	} else {
+		for _, w := range watchers {
+			watch(w)
+		}
+	}
 
 	// Start the API server
 	api := api.New(cfg, d, version)
-	log.Fatal(api.Run())
+	go func() {
+		if err := api.Run(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Wait to be told to stop
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	sig := <-signals
+	log.Infof("Received %v, shutting down within %ds", sig, cfg.ShutdownTimeout)
+
+	// Stop accepting events, then drain what is in flight before closing the API server
+	stop()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	lock.Lock()
+	var wg sync.WaitGroup
+	for w := range watching {
+		wg.Add(1)
+		go func(w *watcher.Watcher) {
+			defer wg.Done()
+			if err := w.Shutdown(shutdownCtx); err != nil {
+				log.Errorf("Unable to send every notification about cluster %s before shutting down: %v", w.Name(), err.Error())
+			}
+		}(w)
+	}
+	watching = nil
+	lock.Unlock()
+	wg.Wait()
+	dropped.Wait()
+
+	if err := store.Close(); err != nil {
+		log.Errorf("Unable to close state store: %v", err.Error())
+	}
+	if err := api.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("Unable to shut down the API server cleanly: %v", err.Error())
+	}
+	statsdClient.Close()
+
+	log.Infof("Shut down")
+}
+
+// A client for the cluster the config points at
+func kubeClient(cfg *config.Config) (*client.Client, error) {
+	clientConfig, err := kubeconfig.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(clientConfig)
 }
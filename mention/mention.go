@@ -0,0 +1,195 @@
+package mention
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+// Places a mention can be found, checked in the order configured
+const (
+	SOURCE_LABEL                = "label"
+	SOURCE_ANNOTATION           = "annotation"
+	SOURCE_OWNER_LABEL          = "owner-label"
+	SOURCE_OWNER_ANNOTATION     = "owner-annotation"
+	SOURCE_NAMESPACE_LABEL      = "namespace-label"
+	SOURCE_NAMESPACE_ANNOTATION = "namespace-annotation"
+)
+
+// Resolver works out who to mention in a notification about an object by checking
+// each source in Chain in turn, falling back to Default. Owner sources walk the
+// object's ownerReferences up to the first owner carrying the label or annotation,
+// since those are often only set on the workload rather than the Pods generated for
+// it. A value can list several mentions separated by commas.
+type Resolver struct {
+	Owners     *owners.Resolver
+	Label      string
+	Annotation string
+	Chain      []string
+	Default    string
+}
+
+func New(cfg *config.Config, o *owners.Resolver) *Resolver {
+	return &Resolver{
+		Owners:     o,
+		Label:      cfg.MentionLabel,
+		Annotation: cfg.MentionAnnotation,
+		Chain:      cfg.MentionChain,
+		Default:    cfg.MentionDefault,
+	}
+}
+
+// The object, its owners and its namespace, each only fetched when first needed
+type lookup struct {
+	r        *Resolver
+	ref      api.ObjectReference
+	fallback *api.ObjectMeta
+
+	chained bool
+	refs    []api.ObjectReference
+	metas   []*api.ObjectMeta
+
+	namespaced bool
+	namespace  *api.ObjectMeta
+}
+
+// Returns the mentions for the object from the first source in the chain that has any, or the default
+func (r *Resolver) Resolve(ref api.ObjectReference) string {
+	return r.resolve(&lookup{r: r, ref: ref})
+}
+
+// Returns the mentions for the object an event is about. If the object can't be
+// fetched, eg. because it has been deleted, the event's own metadata is used instead.
+func (r *Resolver) ResolveEvent(e api.Event) string {
+	ref := api.ObjectReference{
+		APIVersion: e.InvolvedObject.APIVersion,
+		Kind:       e.InvolvedObject.Kind,
+		Namespace:  e.InvolvedObject.Namespace,
+		Name:       e.InvolvedObject.Name,
+	}
+	if ref.Namespace == "" {
+		ref.Namespace = e.ObjectMeta.Namespace
+	}
+
+	return r.resolve(&lookup{r: r, ref: ref, fallback: &e.ObjectMeta})
+}
+
+func (r *Resolver) resolve(l *lookup) string {
+	if r.Label == "" && r.Annotation == "" {
+		return r.Default
+	}
+
+	ref := l.ref
+	for _, source := range r.Chain {
+		if mention, where := l.find(source); mention != "" {
+			log.Debugf("Mention %s found in %s of %s", mention, source, where)
+			return mention
+		}
+	}
+
+	if l.chained && len(l.refs) != 0 {
+		log.Warnf("Mention not found for %s, using default: %s", describe(l.refs), r.Default)
+	} else {
+		log.Warnf("Mention not found for %s %s, using default: %s", ref.Kind, ref.Name, r.Default)
+	}
+	return r.Default
+}
+
+// Looks for mentions in one source, returning them along with where they were found
+func (l *lookup) find(source string) (string, string) {
+	var key string
+	var annotation bool
+	switch source {
+	case SOURCE_LABEL, SOURCE_OWNER_LABEL, SOURCE_NAMESPACE_LABEL:
+		key = l.r.Label
+	case SOURCE_ANNOTATION, SOURCE_OWNER_ANNOTATION, SOURCE_NAMESPACE_ANNOTATION:
+		key = l.r.Annotation
+		annotation = true
+	default:
+		log.Warnf("Unknown mention source %s", source)
+		return "", ""
+	}
+	if key == "" {
+		return "", ""
+	}
+
+	switch source {
+	case SOURCE_LABEL, SOURCE_ANNOTATION:
+		refs, metas := l.chain()
+		if len(metas) != 0 {
+			if mention := value(metas[0], key, annotation); mention != "" {
+				return mention, describe(refs[:1])
+			}
+		}
+	case SOURCE_OWNER_LABEL, SOURCE_OWNER_ANNOTATION:
+		refs, metas := l.chain()
+		for i := 1; i < len(metas); i++ {
+			if mention := value(metas[i], key, annotation); mention != "" {
+				return mention, describe(refs[:i+1])
+			}
+		}
+	case SOURCE_NAMESPACE_LABEL, SOURCE_NAMESPACE_ANNOTATION:
+		if ns := l.ns(); ns != nil {
+			if mention := value(ns, key, annotation); mention != "" {
+				return mention, fmt.Sprintf("Namespace %s", ns.Name)
+			}
+		}
+	}
+
+	return "", ""
+}
+
+func (l *lookup) chain() ([]api.ObjectReference, []*api.ObjectMeta) {
+	if !l.chained {
+		l.chained = true
+		refs, metas, err := l.r.Owners.Chain(l.ref)
+		if err != nil {
+			log.Warnf("Unable to look for mentions on %s %s: %v", l.ref.Kind, l.ref.Name, err.Error())
+			if l.fallback != nil {
+				refs, metas = []api.ObjectReference{l.ref}, []*api.ObjectMeta{l.fallback}
+			}
+		}
+		l.refs, l.metas = refs, metas
+	}
+	return l.refs, l.metas
+}
+
+func (l *lookup) ns() *api.ObjectMeta {
+	if !l.namespaced {
+		l.namespaced = true
+		if l.ref.Namespace == "" {
+			return nil
+		}
+		meta, err := l.r.Owners.Getter.Get("v1", "Namespace", "", l.ref.Namespace)
+		if err != nil {
+			log.Warnf("Unable to look for mentions on namespace %s: %v", l.ref.Namespace, err.Error())
+			return nil
+		}
+		l.namespace = meta
+	}
+	return l.namespace
+}
+
+// Returns the normalised mentions in a label or annotation, or empty if there are none
+func value(meta *api.ObjectMeta, key string, annotation bool) string {
+	values := meta.Labels
+	if annotation {
+		values = meta.Annotations
+	}
+	return strings.Join(deps.SplitMentions(values[key]), ",")
+}
+
+// Formats the owner chain followed, eg. Pod web-1234-abcde -> ReplicaSet web-1234 -> Deployment web
+func describe(refs []api.ObjectReference) string {
+	parts := make([]string, len(refs))
+	for i, ref := range refs {
+		parts[i] = fmt.Sprintf("%s %s", ref.Kind, ref.Name)
+	}
+	return strings.Join(parts, " -> ")
+}
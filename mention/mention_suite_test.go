@@ -0,0 +1,13 @@
+package mention
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMentionSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Mention Suite")
+}
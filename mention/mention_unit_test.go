@@ -0,0 +1,154 @@
+// +build unit
+
+package mention
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+var _ = Describe("Resolver", func() {
+	var (
+		fakeGetter *depsfakes.FakeIObjectGetter
+		objects    map[string]*api.ObjectMeta
+		r          *Resolver
+	)
+
+	// Metadata of an object controlled by the given kind and name, if any
+	meta := func(name string, labels map[string]string, ownerKind, ownerName string) *api.ObjectMeta {
+		m := &api.ObjectMeta{Name: name, Labels: labels}
+		if ownerKind != "" {
+			controller := true
+			m.OwnerReferences = []api.OwnerReference{{Kind: ownerKind, Name: ownerName, Controller: &controller}}
+		}
+		return m
+	}
+
+	BeforeEach(func() {
+		objects = map[string]*api.ObjectMeta{
+			"Pod/web-1234-abcde":   meta("web-1234-abcde", nil, "ReplicaSet", "web-1234"),
+			"ReplicaSet/web-1234":  meta("web-1234", map[string]string{"app": "web"}, "Deployment", "web"),
+			"Deployment/web":       meta("web", map[string]string{"team": "payments"}, "", ""),
+			"Pod/report-1-abcde":   meta("report-1-abcde", nil, "Job", "report-1"),
+			"Job/report-1":         meta("report-1", nil, "CronJob", "report"),
+			"CronJob/report":       meta("report", map[string]string{"team": "analytics"}, "", ""),
+			"Pod/orphan":           meta("orphan", nil, "", ""),
+			"Pod/labelled-1-abcde": meta("labelled-1-abcde", map[string]string{"team": "search"}, "ReplicaSet", "web-1234"),
+			"Namespace/default":    meta("default", nil, "", ""),
+			"Namespace/billing":    meta("billing", map[string]string{"team": "billing"}, "", ""),
+			"Pod/invoice":          meta("invoice", nil, "", ""),
+		}
+		objects["Pod/annotated-1-abcde"] = meta("annotated-1-abcde", nil, "ReplicaSet", "web-1234")
+		objects["Pod/annotated-1-abcde"].Annotations = map[string]string{"kit-overwatch/mention": "search, payments-oncall"}
+		objects["Namespace/billing"].Annotations = map[string]string{"kit-overwatch/mention": "billing-oncall"}
+
+		fakeGetter = &depsfakes.FakeIObjectGetter{}
+		fakeGetter.GetStub = func(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+			if m, ok := objects[kind+"/"+name]; ok {
+				return m, nil
+			}
+			return nil, fmt.Errorf("%s %s not found", kind, name)
+		}
+
+		cfg := config.New()
+		cfg.MentionLabel = "team"
+		cfg.MentionAnnotation = "kit-overwatch/mention"
+		cfg.MentionChain = []string{SOURCE_LABEL, SOURCE_ANNOTATION, SOURCE_OWNER_LABEL, SOURCE_NAMESPACE_LABEL, SOURCE_NAMESPACE_ANNOTATION}
+		cfg.MentionDefault = "here"
+		r = New(cfg, owners.New(fakeGetter))
+	})
+
+	It("should use the label on the Deployment owning a pod", func() {
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web-1234-abcde"})).To(Equal("payments"))
+		Expect(fakeGetter.GetCallCount()).To(Equal(3))
+	})
+
+	It("should use the label on the CronJob owning a job's pod", func() {
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "report-1-abcde"})).To(Equal("analytics"))
+	})
+
+	It("should use the label closest to the object", func() {
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "labelled-1-abcde"})).To(Equal("search"))
+	})
+
+	It("should use the default when nothing in the chain is labelled", func() {
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "orphan"})).To(Equal("here"))
+	})
+
+	It("should use the default when the object can't be fetched", func() {
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "gone"})).To(Equal("here"))
+	})
+
+	It("should still check the namespace when the object can't be fetched", func() {
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "billing", Name: "gone"})).To(Equal("billing"))
+	})
+
+	It("should prefer the object's annotation to its owner's label", func() {
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "annotated-1-abcde"})).To(Equal("search,payments-oncall"))
+	})
+
+	It("should fall back to the namespace's label", func() {
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "billing", Name: "invoice"})).To(Equal("billing"))
+	})
+
+	It("should follow the configured order", func() {
+		r.Chain = []string{SOURCE_NAMESPACE_ANNOTATION, SOURCE_LABEL}
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "billing", Name: "invoice"})).To(Equal("billing-oncall"))
+		Expect(fakeGetter.GetCallCount()).To(Equal(1))
+	})
+
+	It("should skip the namespace of objects that aren't namespaced", func() {
+		objects["Node/node-1"] = meta("node-1", nil, "", "")
+		Expect(r.Resolve(api.ObjectReference{Kind: "Node", Name: "node-1"})).To(Equal("here"))
+		Expect(fakeGetter.GetCallCount()).To(Equal(1))
+	})
+
+	It("should use the event's own metadata when the object has been deleted", func() {
+		e := api.Event{
+			ObjectMeta:     api.ObjectMeta{Name: "gone.1", Namespace: "default", Annotations: map[string]string{"kit-overwatch/mention": "search"}},
+			InvolvedObject: api.ObjectReference{Kind: "Pod", Name: "gone"},
+		}
+		Expect(r.ResolveEvent(e)).To(Equal("search"))
+	})
+
+	It("should resolve events about objects in the event's namespace", func() {
+		e := api.Event{
+			ObjectMeta:     api.ObjectMeta{Name: "invoice.1", Namespace: "billing"},
+			InvolvedObject: api.ObjectReference{Kind: "Pod", Name: "invoice"},
+		}
+		Expect(r.ResolveEvent(e)).To(Equal("billing"))
+	})
+
+	It("should look up the object an event is about in its group", func() {
+		e := api.Event{
+			ObjectMeta:     api.ObjectMeta{Name: "nightly.1", Namespace: "default"},
+			InvolvedObject: api.ObjectReference{APIVersion: "stable.example.com/v1", Kind: "CronTab", Name: "nightly"},
+		}
+		r.ResolveEvent(e)
+		apiVersion, kind, _, _ := fakeGetter.GetArgsForCall(0)
+		Expect(apiVersion).To(Equal("stable.example.com/v1"))
+		Expect(kind).To(Equal("CronTab"))
+	})
+
+	It("should not look anything up when no mention label or annotation is configured", func() {
+		r.Label = ""
+		r.Annotation = ""
+		Expect(r.Resolve(api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web-1234-abcde"})).To(Equal("here"))
+		Expect(fakeGetter.GetCallCount()).To(Equal(0))
+	})
+
+	It("should describe the chain followed", func() {
+		Expect(describe([]api.ObjectReference{
+			{Kind: "Pod", Name: "web-1234-abcde"},
+			{Kind: "ReplicaSet", Name: "web-1234"},
+			{Kind: "Deployment", Name: "web"},
+		})).To(Equal("Pod web-1234-abcde -> ReplicaSet web-1234 -> Deployment web"))
+	})
+})
@@ -49,6 +49,9 @@ func (ndd *NotifyDataDog) Send(n *deps.Notification) error {
 		event.AlertType = "Error"
 		event.Priority = "high"
 	}
+	if n.Resolved != nil {
+		event.AlertType = "Success"
+	}
 
 	serviceName := n.Event.ObjectMeta.Name
 	splitName := strings.Split(n.Event.ObjectMeta.Name, "-")
@@ -69,27 +72,82 @@ func (ndd *NotifyDataDog) Send(n *deps.Notification) error {
 	}
 
 	title := fmt.Sprintf("`%s` event for `%s` on `%s`", n.Event.Reason, serviceName, n.Cluster)
-	if n.Mention != "" {
-		title = fmt.Sprintf("k8s Event for [%s] concerning %s", n.Mention, title)
+	mentions := n.Mentions()
+	if len(mentions) != 0 {
+		title = fmt.Sprintf("k8s Event for [%s] concerning %s", strings.Join(mentions, ", "), title)
 	}
 
 	event.Title = title
 	event.Tags = []string{
-		"team:" + n.Mention,
 		"cluster:" + n.Cluster,
 		"type:" + n.Event.Type,
 		"level:" + n.Level,
 		"reason:" + n.Event.Reason,
 		"node:" + n.Event.Source.Host,
 		"name:" + n.Event.ObjectMeta.Name,
-		"namespace:" + n.Event.ObjectMeta.Namespace,
+		"namespace:" + n.Namespace,
 		"component:" + n.Event.Source.Component,
 		"count:" + fmt.Sprintf("%d", n.Event.Count),
 		"object-kind:" + n.Event.InvolvedObject.Kind,
 		"object-name:" + n.Event.InvolvedObject.Name,
-		"mentioned:" + n.Mention,
 		"service:" + serviceName,
 	}
+	// Always tagged with whom the event concerns, left empty when nobody is mentioned
+	teams := mentions
+	if len(teams) == 0 {
+		teams = []string{""}
+	}
+	for _, m := range teams {
+		event.Tags = append(event.Tags, "team:"+m, "mentioned:"+m)
+	}
+
+	if d := n.Details; d != nil {
+		if d.Action != "" {
+			event.Tags = append(event.Tags, "action:"+d.Action)
+		}
+		if d.ReportingController != "" {
+			event.Tags = append(event.Tags, "reporting-controller:"+d.ReportingController)
+		}
+		if d.Related != nil {
+			event.Tags = append(event.Tags, "related-kind:"+d.Related.Kind, "related-name:"+d.Related.Name)
+		}
+	}
+
+	if c := n.Container; c != nil {
+		event.Tags = append(event.Tags,
+			"container:"+c.Name,
+			fmt.Sprintf("exit-code:%d", c.ExitCode),
+			fmt.Sprintf("signal:%d", c.Signal),
+			fmt.Sprintf("restarts:%d", c.RestartCount),
+		)
+	}
+
+	if r := n.Rollout; r != nil {
+		for _, c := range r.Images {
+			event.Tags = append(event.Tags, "image:"+c.To)
+		}
+		if r.Revision != "" {
+			event.Tags = append(event.Tags, "revision:"+r.Revision)
+		}
+	}
+
+	// A problem's events and its resolution are rolled up together, unless they belong to an incident
+	if n.Problem != nil {
+		event.Aggregation = n.Problem.ID
+		event.Tags = append(event.Tags, "problem:"+n.Problem.ID)
+	}
+	if n.Resolved != nil {
+		event.Title = fmt.Sprintf("[RESOLVED] %s", event.Title)
+		event.Aggregation = n.Resolved.ID
+		event.Tags = append(event.Tags, "problem:"+n.Resolved.ID, "resolved:"+n.Resolved.Reason)
+	}
+
+	// DataDog rolls events with the same aggregation key up together, so an incident shows as one
+	if n.Incident != nil {
+		event.Title = fmt.Sprintf("[Incident %s %s] %s", n.Incident.ID, n.Incident.State, event.Title)
+		event.Aggregation = n.Incident.ID
+		event.Tags = append(event.Tags, "incident:"+n.Incident.ID, "incident-state:"+n.Incident.State)
+	}
 
 	message := `#### Message Details
 	%v
@@ -109,7 +167,7 @@ func (ndd *NotifyDataDog) Send(n *deps.Notification) error {
 
 	eDetails := &eventDetails{
 		Node:           n.Event.Source.Host,
-		Namespace:      n.Event.ObjectMeta.Namespace,
+		Namespace:      n.Namespace,
 		Component:      n.Event.Source.Component,
 		Count:          fmt.Sprintf("%d", n.Event.Count),
 		FirstOccurance: n.Event.FirstTimestamp.Format(time.RFC1123),
@@ -120,6 +178,14 @@ func (ndd *NotifyDataDog) Send(n *deps.Notification) error {
 		Kind: n.Event.InvolvedObject.Kind,
 		Name: n.Event.InvolvedObject.Name,
 	}
+	if c := n.Container; c != nil {
+		iObject.Container = c.Name
+		iObject.TerminationMessage = c.Message
+	}
+	if r := n.Rollout; r != nil {
+		iObject.ChangeCause = r.ChangeCause
+		iObject.Revision = r.Revision
+	}
 
 	mDetailsJson, err := json.Marshal(mDetails)
 	if err != nil {
@@ -168,6 +234,10 @@ type eventDetails struct {
 }
 
 type involvedObject struct {
-	Kind string `json:"kind,omitempty"`
-	Name string `json:"name,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Container          string `json:"container,omitempty"`
+	TerminationMessage string `json:"termination_message,omitempty"`
+	ChangeCause        string `json:"change_cause,omitempty"`
+	Revision           string `json:"revision,omitempty"`
 }
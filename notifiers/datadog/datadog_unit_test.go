@@ -42,7 +42,8 @@ var _ = Describe("NotifyDataDogSend", func() {
 
 		notifier = New(fakeDataDogClient)
 		expectedNotifier = &deps.Notification{
-			Cluster: "local",
+			Cluster:   "local",
+			Namespace: "default",
 			Event: api.Event{
 				Reason:  "Scheduled",
 				Message: "Scheduled event message from k8s",
@@ -142,6 +143,110 @@ var _ = Describe("NotifyDataDogSend", func() {
 			Expect(actualEvent.Title).To(Equal("`Scheduled` event for `joebob-service` on `local`"))
 		})
 
+		It("should still tag the team and mention, left empty, if no mention", func() {
+			expectedNotifier.Mention = ""
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.Tags).To(ContainElement("team:"))
+			Expect(actualEvent.Tags).To(ContainElement("mentioned:"))
+		})
+
+		It("should mention and tag each of several mentions", func() {
+			expectedNotifier.Mention = "payments, payments-oncall"
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.Title).To(HavePrefix("k8s Event for [payments, payments-oncall] concerning"))
+			Expect(actualEvent.Tags).To(ContainElement("team:payments"))
+			Expect(actualEvent.Tags).To(ContainElement("mentioned:payments-oncall"))
+		})
+
+		It("should tag the namespace the event happened in", func() {
+			expectedNotifier.Namespace = "kube-system"
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.Tags).To(ContainElement("namespace:kube-system"))
+		})
+
+		It("should aggregate and tag events belonging to an incident", func() {
+			expectedNotifier.Incident = &deps.Incident{
+				ID:    "deployment-joebob-service-1480420800",
+				State: deps.INCIDENT_UPDATE,
+			}
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.Aggregation).To(Equal("deployment-joebob-service-1480420800"))
+			Expect(actualEvent.Tags).To(ContainElement("incident:deployment-joebob-service-1480420800"))
+			Expect(actualEvent.Tags).To(ContainElement("incident-state:UPDATE"))
+			Expect(actualEvent.Title).To(HavePrefix("[Incident deployment-joebob-service-1480420800 UPDATE] "))
+		})
+
+		It("should aggregate and tag events about a problem", func() {
+			expectedNotifier.Problem = &deps.Problem{ID: "pod-joebob-backoff-1262307600", Reason: "BackOff"}
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.Aggregation).To(Equal("pod-joebob-backoff-1262307600"))
+			Expect(actualEvent.Tags).To(ContainElement("problem:pod-joebob-backoff-1262307600"))
+		})
+
+		It("should announce a resolved problem as a success rolled up with it", func() {
+			expectedNotifier.Level = "WARN"
+			expectedNotifier.Resolved = &deps.Problem{ID: "pod-joebob-backoff-1262307600", Reason: "BackOff"}
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.AlertType).To(Equal("Success"))
+			Expect(actualEvent.Title).To(HavePrefix("[RESOLVED] "))
+			Expect(actualEvent.Aggregation).To(Equal("pod-joebob-backoff-1262307600"))
+			Expect(actualEvent.Tags).To(ContainElement("problem:pod-joebob-backoff-1262307600"))
+			Expect(actualEvent.Tags).To(ContainElement("resolved:BackOff"))
+		})
+
+		It("should tag and describe what happened to a container", func() {
+			expectedNotifier.Event.Reason = "OOMKilled"
+			expectedNotifier.Container = &deps.Container{
+				Name:         "web",
+				RestartCount: 3,
+				Reason:       "OOMKilled",
+				ExitCode:     137,
+				Signal:       9,
+				Message:      "out of memory",
+			}
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.Tags).To(ContainElement("container:web"))
+			Expect(actualEvent.Tags).To(ContainElement("exit-code:137"))
+			Expect(actualEvent.Tags).To(ContainElement("signal:9"))
+			Expect(actualEvent.Tags).To(ContainElement("restarts:3"))
+			Expect(actualEvent.Text).To(ContainSubstring(`"container":"web","termination_message":"out of memory"`))
+		})
+
+		It("should tag and describe what a rollout changed", func() {
+			expectedNotifier.Event.Reason = "RolloutStarted"
+			expectedNotifier.Rollout = &deps.Rollout{
+				Images:      []deps.ImageChange{{Container: "web", From: "nginx:1.10", To: "nginx:1.11"}},
+				ChangeCause: "kubectl set image deployment/web web=nginx:1.11",
+				Revision:    "4",
+			}
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.Tags).To(ContainElement("image:nginx:1.11"))
+			Expect(actualEvent.Tags).To(ContainElement("revision:4"))
+			Expect(actualEvent.Text).To(ContainSubstring(`"change_cause":"kubectl set image deployment/web web=nginx:1.11","revision":"4"`))
+		})
+
+		It("should tag what events.k8s.io events say about the action and related object", func() {
+			expectedNotifier.Details = &deps.EventDetails{
+				Action:              "Binding",
+				ReportingController: "default-scheduler",
+				Related:             &api.ObjectReference{Kind: "Node", Name: "node-1"},
+			}
+			err := notifier.Send(expectedNotifier)
+			Expect(err).To(BeNil())
+			Expect(actualEvent.Tags).To(ContainElement("action:Binding"))
+			Expect(actualEvent.Tags).To(ContainElement("reporting-controller:default-scheduler"))
+			Expect(actualEvent.Tags).To(ContainElement("related-kind:Node"))
+			Expect(actualEvent.Tags).To(ContainElement("related-name:node-1"))
+		})
+
 		It("should error when datadog errors", func() {
 			fakeDataDogClient.PostEventStub = func(event *dd.Event) (*dd.Event, error) {
 				actualEvent = nil
@@ -1,12 +1,146 @@
 package deps
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"k8s.io/kubernetes/pkg/api"
 )
 
+const (
+	INCIDENT_OPEN   = "OPEN"
+	INCIDENT_UPDATE = "UPDATE"
+	INCIDENT_CLOSE  = "CLOSE"
+)
+
 type Notification struct {
-	Cluster string
-	Event   api.Event
-	Level   string
+	Cluster   string
+	Namespace string
+	Event     api.Event
+	Level     string
+
+	// Who to mention, several are separated by commas
 	Mention string
+
+	// Set when the notification opens, updates or closes an incident
+	Incident *Incident
+
+	// Set for events from the events.k8s.io API
+	Details *EventDetails
+
+	// Set on notifications about a problem that is announced as RESOLVED once it clears
+	Problem *Problem
+
+	// Set on RESOLVED notifications, the problem that cleared
+	Resolved *Problem
+
+	// Set on notifications from the pod monitor about a container
+	Container *Container
+
+	// Set on notifications from the rollout monitor
+	Rollout *Rollout
+}
+
+// What events.k8s.io events say beyond the fields of a core event. Their series
+// (how often and when they were last observed) is folded into the event's Count
+// and LastTimestamp.
+type EventDetails struct {
+	Action              string
+	ReportingController string
+	ReportingInstance   string
+	Related             *api.ObjectReference
+}
+
+// Returns each of the notification's mentions
+func (n *Notification) Mentions() []string {
+	return SplitMentions(n.Mention)
+}
+
+// Splits comma separated mentions, dropping empty ones
+func SplitMentions(s string) []string {
+	var mentions []string
+	for _, m := range strings.Split(s, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			mentions = append(mentions, m)
+		}
+	}
+	return mentions
+}
+
+// An incident groups the notifications about one object (or its owner) from the
+// first warning until things have been quiet for a while
+type Incident struct {
+	ID      string
+	State   string
+	Object  api.ObjectReference
+	Opened  time.Time
+	Events  int
+	Reasons []string
+}
+
+// A problem with an object that is followed up when it clears, eg. a pod in BackOff.
+// The notifications about it and the RESOLVED one share its ID.
+type Problem struct {
+	ID     string
+	Reason string
+	Object api.ObjectReference
+	Since  time.Time
+}
+
+// What the pod monitor saw of a container, and how it last terminated if it has.
+// The exit code, signal and message are those the container terminated with.
+type Container struct {
+	Name         string
+	RestartCount int32
+	Reason       string
+	ExitCode     int32
+	Signal       int32
+	Message      string
+}
+
+// A rollout of a workload's new pod template. Started is zero when the rollout
+// was already under way when watching began, and Duration is set once it completes.
+type Rollout struct {
+	Images      []ImageChange
+	ChangeCause string
+	Revision    string
+	Started     time.Time
+	Duration    time.Duration
+}
+
+// A container whose image a rollout changes; From is empty for a new container
+type ImageChange struct {
+	Container string
+	From      string
+	To        string
+}
+
+//go:generate counterfeiter -o ../../fakes/notifiersfakes/fake_sink.go . Sink
+
+// Sink is anything notifications can be sent to, eg. the notifiers themselves or
+// a stage that transforms notifications before passing them on
+type Sink interface {
+	SendAll(n *Notification)
+}
+
+//go:generate counterfeiter -o ../../fakes/notifiersfakes/fake_drainer.go . Drainer
+
+// Drainer is a sink that can wait for the notifications sent to it to be delivered
+type Drainer interface {
+	Sink
+	Shutdown(ctx context.Context) error
+}
+
+// Notification levels, least severe first
+var Levels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// Orders levels by severity; unknown levels are the least severe
+func Severity(level string) int {
+	for i, l := range Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
 }
@@ -4,14 +4,43 @@ import (
 	"fmt"
 	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
 	log "github.com/Sirupsen/logrus"
+	"strings"
 )
 
 func Send(n *deps.Notification) error {
-	message := fmt.Sprintf("NotifyLog: %s / %s / %s / %s", n.Cluster, n.Event.Reason, n.Event.Message, n.Event.LastTimestamp)
+	message := fmt.Sprintf("NotifyLog: %s / %s / %s / %s / %s", n.Cluster, n.Namespace, n.Event.Reason, n.Event.Message, n.Event.LastTimestamp)
+
+	if n.Incident != nil {
+		message = fmt.Sprintf("%s / incident %s %s", message, n.Incident.State, n.Incident.ID)
+	}
+
+	if n.Problem != nil {
+		message = fmt.Sprintf("%s / problem %s", message, n.Problem.ID)
+	}
+	if n.Resolved != nil {
+		message = fmt.Sprintf("%s / RESOLVED %s %s", message, n.Resolved.Reason, n.Resolved.ID)
+	}
+
+	if c := n.Container; c != nil {
+		message = fmt.Sprintf("%s / container %s exit code %d signal %d", message, c.Name, c.ExitCode, c.Signal)
+	}
+
+	if r := n.Rollout; r != nil {
+		for _, c := range r.Images {
+			message = fmt.Sprintf("%s / rollout %s %s -> %s", message, c.Container, c.From, c.To)
+		}
+	}
+
+	if d := n.Details; d != nil && d.Action != "" {
+		message = fmt.Sprintf("%s / action %s", message, d.Action)
+	}
+	if d := n.Details; d != nil && d.Related != nil {
+		message = fmt.Sprintf("%s / related %s %s", message, d.Related.Kind, d.Related.Name)
+	}
 
 	// Add mention if one exists
-	if n.Mention != "" {
-		message = fmt.Sprintf("%s / @%s", message, n.Mention)
+	if mentions := n.Mentions(); len(mentions) != 0 {
+		message = fmt.Sprintf("%s / @%s", message, strings.Join(mentions, " @"))
 	}
 
 	switch n.Level {
@@ -1,6 +1,9 @@
 package notifiers
 
 import (
+	"context"
+	"sync"
+
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/InVisionApp/kit-overwatch/config"
@@ -15,6 +18,10 @@ import (
 type Notifiers struct {
 	Config       config.Config
 	Dependencies *dependencies.Dependencies
+
+	lock     sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
 }
 
 func New(cfg *config.Config, d *dependencies.Dependencies) *Notifiers {
@@ -25,27 +32,15 @@ func New(cfg *config.Config, d *dependencies.Dependencies) *Notifiers {
 }
 
 func (notifiers *Notifiers) SendAll(n *deps.Notification) {
-	// Only send notification if it's a desired Level
-	levels := [...]string{"DEBUG", "INFO", "WARN", "ERROR"}
-	send := false
-	switch notifiers.Config.NotificationLevel {
-	case "DEBUG":
-		if stringInSlice(n.Level, levels[0:]) {
-			send = true
-		}
-	case "INFO":
-		if stringInSlice(n.Level, levels[1:]) {
-			send = true
-		}
-	case "WARN":
-		if stringInSlice(n.Level, levels[2:]) {
-			send = true
-		}
-	case "ERROR":
-		if stringInSlice(n.Level, levels[3:]) {
-			send = true
-		}
+	if !notifiers.begin() {
+		log.Warnf("Dropping notification after shutdown: %s / %s / %s / %s", n.Cluster, n.Event.Reason, n.Event.Message, n.Event.LastTimestamp)
+		return
 	}
+	defer notifiers.inflight.Done()
+
+	// Only send notification if it's a desired Level
+	level := deps.Severity(n.Level)
+	send := level >= 0 && level >= deps.Severity(notifiers.Config.NotificationLevel)
 
 	if send {
 		if notifiers.Config.NotifyLog {
@@ -74,12 +69,35 @@ func (notifiers *Notifiers) SendAll(n *deps.Notification) {
 	}
 }
 
-// For finding a string in an array
-func stringInSlice(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
-		}
+// Shutdown stops sending notifications and waits for the ones being sent to finish,
+// or for the context to be done
+func (notifiers *Notifiers) Shutdown(ctx context.Context) error {
+	notifiers.lock.Lock()
+	notifiers.closed = true
+	notifiers.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		notifiers.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reports whether a notification can be sent, counting it as in flight if so
+func (notifiers *Notifiers) begin() bool {
+	notifiers.lock.Lock()
+	defer notifiers.lock.Unlock()
+
+	if notifiers.closed {
+		return false
 	}
-	return false
+	notifiers.inflight.Add(1)
+	return true
 }
@@ -2,6 +2,7 @@ package notifiers
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -71,6 +72,9 @@ func (ns *NotifySlack) Send(n *deps.Notification) error {
 	case "ERROR":
 		eventAttachment.Color = "danger"
 	}
+	if n.Resolved != nil {
+		eventAttachment.Color = "good"
+	}
 
 	eventDetailsAttachment := slack.Attachment{
 		Fallback: n.Event.Message,
@@ -88,7 +92,7 @@ func (ns *NotifySlack) Send(n *deps.Notification) error {
 			},
 			slack.AttachmentField{
 				Title: "Namespace",
-				Value: n.Event.ObjectMeta.Namespace,
+				Value: n.Namespace,
 				Short: true,
 			},
 			slack.AttachmentField{
@@ -131,12 +135,128 @@ func (ns *NotifySlack) Send(n *deps.Notification) error {
 		},
 	}
 
+	// Events from the events.k8s.io API say what was done and by which controller instance
+	if d := n.Details; d != nil {
+		if d.Action != "" {
+			eventDetailsAttachment.Fields = append(eventDetailsAttachment.Fields, slack.AttachmentField{
+				Title: "Action",
+				Value: d.Action,
+				Short: true,
+			})
+		}
+		if d.ReportingController != "" {
+			eventDetailsAttachment.Fields = append(eventDetailsAttachment.Fields, slack.AttachmentField{
+				Title: "Reporting Controller",
+				Value: strings.TrimSuffix(fmt.Sprintf("%s %s", d.ReportingController, d.ReportingInstance), " "),
+				Short: true,
+			})
+		}
+		if d.Related != nil {
+			involvedObjectAttachment.Fields = append(involvedObjectAttachment.Fields, slack.AttachmentField{
+				Title: "Related",
+				Value: fmt.Sprintf("%s %s", d.Related.Kind, d.Related.Name),
+				Short: false,
+			})
+		}
+	}
+
+	// The pod monitor says what happened to the container
+	if c := n.Container; c != nil {
+		involvedObjectAttachment.Fields = append(involvedObjectAttachment.Fields,
+			slack.AttachmentField{
+				Title: "Container",
+				Value: c.Name,
+				Short: true,
+			},
+			slack.AttachmentField{
+				Title: "Restarts",
+				Value: fmt.Sprintf("%d", c.RestartCount),
+				Short: true,
+			},
+			slack.AttachmentField{
+				Title: "Exit Code",
+				Value: fmt.Sprintf("%d", c.ExitCode),
+				Short: true,
+			},
+			slack.AttachmentField{
+				Title: "Signal",
+				Value: fmt.Sprintf("%d", c.Signal),
+				Short: true,
+			},
+		)
+		if c.Message != "" {
+			involvedObjectAttachment.Fields = append(involvedObjectAttachment.Fields, slack.AttachmentField{
+				Title: "Termination Message",
+				Value: c.Message,
+				Short: false,
+			})
+		}
+	}
+
+	// The rollout monitor says what was rolled out and how long it took
+	if r := n.Rollout; r != nil {
+		if len(r.Images) != 0 {
+			images := make([]string, len(r.Images))
+			for i, c := range r.Images {
+				images[i] = fmt.Sprintf("%s: %s -> %s", c.Container, c.From, c.To)
+			}
+			involvedObjectAttachment.Fields = append(involvedObjectAttachment.Fields, slack.AttachmentField{
+				Title: "Images",
+				Value: strings.Join(images, "\n"),
+				Short: false,
+			})
+		}
+		if r.ChangeCause != "" {
+			involvedObjectAttachment.Fields = append(involvedObjectAttachment.Fields, slack.AttachmentField{
+				Title: "Change Cause",
+				Value: r.ChangeCause,
+				Short: false,
+			})
+		}
+		if r.Revision != "" {
+			involvedObjectAttachment.Fields = append(involvedObjectAttachment.Fields, slack.AttachmentField{
+				Title: "Revision",
+				Value: r.Revision,
+				Short: true,
+			})
+		}
+		if r.Duration != 0 {
+			involvedObjectAttachment.Fields = append(involvedObjectAttachment.Fields, slack.AttachmentField{
+				Title: "Duration",
+				Value: r.Duration.String(),
+				Short: true,
+			})
+		}
+	}
+
+	// Problems and their resolution share an ID so the RESOLVED message can be matched up
+	if n.Problem != nil {
+		eventDetailsAttachment.Fields = append(eventDetailsAttachment.Fields, slack.AttachmentField{
+			Title: "Problem",
+			Value: n.Problem.ID,
+			Short: true,
+		})
+	}
+	if n.Resolved != nil {
+		eventDetailsAttachment.Fields = append(eventDetailsAttachment.Fields, slack.AttachmentField{
+			Title: "Resolved Problem",
+			Value: n.Resolved.ID,
+			Short: true,
+		})
+	}
+
 	params.Attachments = []slack.Attachment{eventAttachment, eventDetailsAttachment, involvedObjectAttachment}
 	message := fmt.Sprintf("`%s` event for `%s` on `%s`", n.Event.Reason, n.Event.ObjectMeta.Name, n.Cluster)
+	if n.Resolved != nil {
+		message = fmt.Sprintf("RESOLVED `%s` problem `%s` for `%s` on `%s`", n.Resolved.Reason, n.Resolved.ID, n.Resolved.Object.Name, n.Cluster)
+	}
+	if n.Incident != nil {
+		message = fmt.Sprintf("Incident `%s` %s: %s", n.Incident.ID, incidentVerb(n.Incident.State), message)
+	}
 
 	// Add mention handle if there is one
-	if n.Mention != "" {
-		message = fmt.Sprintf("Alerting @%s concerning %s", n.Mention, message)
+	if mentions := n.Mentions(); len(mentions) != 0 {
+		message = fmt.Sprintf("Alerting @%s concerning %s", strings.Join(mentions, " @"), message)
 	}
 
 	channelID, timestamp, err := api.PostMessage(ns.Channel, message, params)
@@ -146,3 +266,13 @@ func (ns *NotifySlack) Send(n *deps.Notification) error {
 	log.Infof("NotifySlack: %s / %s / %s / %s", n.Event.Reason, n.Event.Message, channelID, timestamp)
 	return nil
 }
+
+func incidentVerb(state string) string {
+	switch state {
+	case deps.INCIDENT_OPEN:
+		return "opened"
+	case deps.INCIDENT_CLOSE:
+		return "closed"
+	}
+	return "updated"
+}
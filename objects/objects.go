@@ -0,0 +1,219 @@
+package objects
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/clock"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/informer"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+const (
+	DEFAULT_STATSD_RATE = 1.0
+
+	// How long the metadata of deleted objects is kept, as events about them keep arriving for a while
+	TOMBSTONE_TTL = 10 * time.Minute
+)
+
+// Kinds that don't live in a namespace, so are cached cluster wide
+var ClusterScoped = map[string]bool{
+	"Node":      true,
+	"Namespace": true,
+}
+
+// Cache serves object metadata from a local copy kept up to date by a list and watch
+// per kind (and namespace), falling back to a live lookup for kinds that aren't
+// cached and objects the cache doesn't know about yet. Deleted objects are kept for
+// TOMBSTONE_TTL so events about pods that have just gone can still be resolved.
+type Cache struct {
+	Getter       deps.IObjectGetter
+	Dependencies *deps.Dependencies
+
+	informers map[string]*Informer
+}
+
+func New(cfg *config.Config, l deps.IObjectLister, g deps.IObjectGetter, namespaces []string, d *deps.Dependencies) *Cache {
+	c := &Cache{
+		Getter:       g,
+		Dependencies: d,
+		informers:    make(map[string]*Informer),
+	}
+
+	for _, kind := range cfg.ObjectCacheKinds {
+		if ClusterScoped[kind] {
+			c.informers[key(kind, api.NamespaceAll)] = NewInformer(l, kind, api.NamespaceAll)
+			continue
+		}
+		for _, ns := range namespaces {
+			c.informers[key(kind, ns)] = NewInformer(l, kind, ns)
+		}
+	}
+
+	return c
+}
+
+// Keeps every cache in sync with the cluster until the context is done
+func (c *Cache) Run(ctx context.Context) {
+	for _, inf := range c.informers {
+		go inf.Run(ctx)
+	}
+}
+
+// Objects of a kind with the same name as a cached one, but of another group, aren't cached
+func (c *Cache) Get(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+	if !owners.Typed(apiVersion) {
+		return c.Getter.Get(apiVersion, kind, namespace, name)
+	}
+
+	if ClusterScoped[kind] {
+		namespace = api.NamespaceAll
+	}
+
+	inf, ok := c.informers[key(kind, namespace)]
+	if !ok {
+		inf, ok = c.informers[key(kind, api.NamespaceAll)]
+	}
+	if ok {
+		if m, found := inf.Get(namespace, name); found {
+			c.inc("objects.cache.hit")
+			return m, nil
+		}
+	}
+
+	c.inc("objects.cache.miss")
+	return c.Getter.Get(apiVersion, kind, namespace, name)
+}
+
+func (c *Cache) inc(stat string) {
+	if c.Dependencies == nil || c.Dependencies.StatsD == nil {
+		return
+	}
+	go c.Dependencies.StatsD.Inc(stat, 1, DEFAULT_STATSD_RATE)
+}
+
+// The metadata of a deleted object and when it was deleted
+type tombstone struct {
+	meta    *api.ObjectMeta
+	deleted time.Time
+}
+
+// Informer keeps the metadata of every object of one kind in one namespace (or all
+// of them) in sync with the cluster.
+type Informer struct {
+	Lister    deps.IObjectLister
+	Kind      string
+	Namespace string
+	Clock     clock.Clock
+
+	reflector *informer.Reflector
+	lock      sync.RWMutex
+	store     map[string]*api.ObjectMeta
+	deleted   map[string]tombstone
+}
+
+func NewInformer(l deps.IObjectLister, kind, namespace string) *Informer {
+	i := &Informer{
+		Lister:    l,
+		Kind:      kind,
+		Namespace: namespace,
+		Clock:     clock.RealClock{},
+		store:     make(map[string]*api.ObjectMeta),
+		deleted:   make(map[string]tombstone),
+	}
+	i.reflector = &informer.Reflector{
+		Lister:  informer.ForKind(l, kind, namespace),
+		Handler: i,
+		Name:    kind + " objects",
+	}
+	return i
+}
+
+// Run keeps the cache in sync until the context is done
+func (i *Informer) Run(ctx context.Context) {
+	i.reflector.Run(ctx)
+}
+
+// Returns the cached metadata of an object, including ones deleted within TOMBSTONE_TTL
+func (i *Informer) Get(namespace, name string) (*api.ObjectMeta, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	k := key(namespace, name)
+	if m, ok := i.store[k]; ok {
+		return m, true
+	}
+	if t, ok := i.deleted[k]; ok && i.Clock.Since(t.deleted) < TOMBSTONE_TTL {
+		return t.meta, true
+	}
+	return nil, false
+}
+
+// Replace is given the listed objects
+func (i *Informer) Replace(ctx context.Context, items []runtime.Object) {
+	store := make(map[string]*api.ObjectMeta, len(items))
+	for _, item := range items {
+		m, err := api.ObjectMetaFor(item)
+		if err != nil {
+			log.Warnf("Skip: unable to read %s metadata: %v", i.Kind, err.Error())
+			continue
+		}
+		store[key(m.Namespace, m.Name)] = m
+	}
+
+	i.lock.Lock()
+	// Anything no longer listed was deleted while we weren't watching
+	now := i.Clock.Now()
+	for k, m := range i.store {
+		if _, ok := store[k]; !ok {
+			i.deleted[k] = tombstone{meta: m, deleted: now}
+		}
+	}
+	i.store = store
+	i.lock.Unlock()
+
+	log.Debugf("Cached %d %s objects in namespace '%s'", len(store), i.Kind, i.Namespace)
+}
+
+// Update is given each watched change to an object
+func (i *Informer) Update(ctx context.Context, t watch.EventType, obj runtime.Object) {
+	m, err := api.ObjectMetaFor(obj)
+	if err != nil {
+		log.Warnf("Skip: unexpected object in %s watch: %T", i.Kind, obj)
+		return
+	}
+	i.update(t, m)
+}
+
+func (i *Informer) update(t watch.EventType, m *api.ObjectMeta) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	k := key(m.Namespace, m.Name)
+	now := i.Clock.Now()
+	if t == watch.Deleted {
+		delete(i.store, k)
+		i.deleted[k] = tombstone{meta: m, deleted: now}
+	} else {
+		i.store[k] = m
+		delete(i.deleted, k)
+	}
+
+	for k, t := range i.deleted {
+		if now.Sub(t.deleted) >= TOMBSTONE_TTL {
+			delete(i.deleted, k)
+		}
+	}
+}
+
+func key(a, b string) string {
+	return a + "/" + b
+}
@@ -0,0 +1,13 @@
+package objects
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestObjectsSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Objects Suite")
+}
@@ -0,0 +1,218 @@
+// +build unit
+
+package objects
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cactus/go-statsd-client/statsd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/clock"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+)
+
+// Records the stats sent so tests can count them
+type recordingSender struct {
+	lock sync.Mutex
+	sent []string
+}
+
+func (s *recordingSender) Send(data []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sent = append(s.sent, string(data))
+	return len(data), nil
+}
+
+func (s *recordingSender) Close() error {
+	return nil
+}
+
+func (s *recordingSender) count(stat string) func() int {
+	return func() int {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		n := 0
+		for _, sent := range s.sent {
+			if sent == stat+":1|c" {
+				n++
+			}
+		}
+		return n
+	}
+}
+
+func newPod(namespace, name, resourceVersion string) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Namespace:       namespace,
+			Name:            name,
+			ResourceVersion: resourceVersion,
+			Labels:          map[string]string{"team": "payments"},
+		},
+	}
+}
+
+var _ = Describe("Cache", func() {
+	var (
+		fakeLister  *depsfakes.FakeIObjectLister
+		fakeGetter  *depsfakes.FakeIObjectGetter
+		fakeWatches map[string]*watch.FakeWatcher
+		sender      *recordingSender
+		clk         *clock.FakeClock
+		c           *Cache
+		cancel      context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		// Each spec's watches are captured separately from those of informers still stopping
+		watches := map[string]*watch.FakeWatcher{
+			"Pod":  watch.NewFake(),
+			"Node": watch.NewFake(),
+		}
+		fakeWatches = watches
+
+		fakeLister = &depsfakes.FakeIObjectLister{}
+		fakeLister.ListStub = func(kind, namespace string, opts api.ListOptions) (runtime.Object, error) {
+			switch kind {
+			case "Pod":
+				return &api.PodList{
+					ListMeta: unversioned.ListMeta{ResourceVersion: "10"},
+					Items:    []api.Pod{*newPod(namespace, "web-1234-abcde", "5")},
+				}, nil
+			case "Node":
+				return &api.NodeList{
+					ListMeta: unversioned.ListMeta{ResourceVersion: "10"},
+					Items:    []api.Node{{ObjectMeta: api.ObjectMeta{Name: "node-1"}}},
+				}, nil
+			}
+			return nil, fmt.Errorf("unsupported kind %s", kind)
+		}
+		fakeLister.WatchStub = func(kind, namespace string, opts api.ListOptions) (watch.Interface, error) {
+			return watches[kind], nil
+		}
+
+		fakeGetter = &depsfakes.FakeIObjectGetter{}
+		fakeGetter.GetStub = func(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+			return nil, errors.NewNotFound(api.Resource(kind), name)
+		}
+
+		sender = &recordingSender{}
+		statter, err := statsd.NewClientWithSender(sender, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg := config.New()
+		cfg.ObjectCacheKinds = []string{"Pod", "Node"}
+		c = New(cfg, fakeLister, fakeGetter, []string{"default"}, &deps.Dependencies{StatsD: statter})
+
+		clk = clock.NewFakeClock(time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC))
+		for _, inf := range c.informers {
+			inf.Clock = clk
+		}
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		c.Run(ctx)
+
+		Eventually(fakeLister.WatchCallCount).Should(Equal(2))
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should serve listed objects from the cache", func() {
+		m, err := c.Get("v1", "Pod", "default", "web-1234-abcde")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.Labels["team"]).To(Equal("payments"))
+		Expect(fakeGetter.GetCallCount()).To(Equal(0))
+		Eventually(sender.count("objects.cache.hit")).Should(Equal(1))
+	})
+
+	It("should ignore the namespace of cluster scoped kinds", func() {
+		_, err := c.Get("", "Node", "default", "node-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeGetter.GetCallCount()).To(Equal(0))
+	})
+
+	It("should add objects from the watch", func() {
+		fakeWatches["Pod"].Add(newPod("default", "web-1234-fghij", "11"))
+		Eventually(func() error {
+			_, err := c.Get("", "Pod", "default", "web-1234-fghij")
+			return err
+		}).ShouldNot(HaveOccurred())
+	})
+
+	It("should stop watching once the context is done", func() {
+		cancel()
+		Eventually(func() bool {
+			pods := fakeWatches["Pod"]
+			pods.Lock()
+			defer pods.Unlock()
+			return pods.Stopped
+		}).Should(BeTrue())
+	})
+
+	It("should fall back to a live lookup for objects it doesn't know", func() {
+		fakeGetter.GetReturns(&api.ObjectMeta{Name: "web-1234-new"}, nil)
+		m, err := c.Get("", "Pod", "default", "web-1234-new")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.Name).To(Equal("web-1234-new"))
+		Expect(fakeGetter.GetCallCount()).To(Equal(1))
+		Eventually(sender.count("objects.cache.miss")).Should(Equal(1))
+	})
+
+	It("should fall back to a live lookup for kinds that aren't cached", func() {
+		_, err := c.Get("", "Deployment", "default", "web")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		Expect(fakeGetter.GetCallCount()).To(Equal(1))
+	})
+
+	It("should look up kinds of the same name in another group live", func() {
+		c.Get("stable.example.com/v1", "Pod", "default", "web-1234-abcde")
+		Expect(fakeGetter.GetCallCount()).To(Equal(1))
+		apiVersion, kind, _, _ := fakeGetter.GetArgsForCall(0)
+		Expect(apiVersion).To(Equal("stable.example.com/v1"))
+		Expect(kind).To(Equal("Pod"))
+	})
+
+	It("should fall back to a live lookup in namespaces that aren't cached", func() {
+		c.Get("", "Pod", "kube-system", "web-1234-abcde")
+		Expect(fakeGetter.GetCallCount()).To(Equal(1))
+	})
+
+	Context("when an object is deleted", func() {
+		BeforeEach(func() {
+			fakeWatches["Pod"].Delete(newPod("default", "web-1234-abcde", "12"))
+			Eventually(func() int {
+				inf := c.informers[key("Pod", "default")]
+				inf.lock.RLock()
+				defer inf.lock.RUnlock()
+				return len(inf.deleted)
+			}).Should(Equal(1))
+		})
+
+		It("should keep serving it for a while", func() {
+			_, err := c.Get("v1", "Pod", "default", "web-1234-abcde")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeGetter.GetCallCount()).To(Equal(0))
+		})
+
+		It("should forget it after the tombstone expires", func() {
+			clk.Step(TOMBSTONE_TTL)
+			_, err := c.Get("v1", "Pod", "default", "web-1234-abcde")
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
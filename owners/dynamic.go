@@ -0,0 +1,209 @@
+package owners
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+const (
+	// How often discovery may be repeated when asked for a kind it doesn't know, eg. a newly added custom resource
+	DISCOVERY_REFRESH = 5 * time.Minute
+)
+
+// Returned when the cluster doesn't serve a kind of object, in the group if one was given
+type UnsupportedKind struct {
+	Kind  string
+	Group string
+}
+
+func (u UnsupportedKind) Error() string {
+	if u.Group != "" {
+		return fmt.Sprintf("unsupported kind %s in group %s", u.Kind, u.Group)
+	}
+	return fmt.Sprintf("unsupported kind %s", u.Kind)
+}
+
+// Where objects of a kind are served
+type resource struct {
+	group        string
+	groupVersion string
+	name         string
+	namespaced   bool
+}
+
+// Dynamic gets the metadata of any kind of object the cluster serves, including
+// custom resources. The resource is found with discovery by the group of the
+// object's apiVersion and its kind, in the preferred version of the group, and the
+// object is fetched over REST. Without an apiVersion, if several groups serve the
+// same kind the core group wins, eg. Event over events.k8s.io, or else the first
+// one discovered.
+type Dynamic struct {
+	Discovery deps.IDiscoveryClient
+	REST      deps.IRESTGetter
+	Clock     clock.Clock
+
+	lock         sync.Mutex
+	resources    map[unversioned.GroupKind]resource
+	kinds        map[string]resource
+	discoveredAt time.Time
+}
+
+func NewDynamic(d deps.IDiscoveryClient, r deps.IRESTGetter) *Dynamic {
+	return &Dynamic{
+		Discovery: d,
+		REST:      r,
+		Clock:     clock.RealClock{},
+	}
+}
+
+func (d *Dynamic) Get(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+	r, err := d.resource(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := d.REST.GetRaw(r.path(namespace, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj struct {
+		Metadata api.ObjectMeta `json:"metadata"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("Unable to read %s %s: %v", kind, name, err.Error())
+	}
+
+	return &obj.Metadata, nil
+}
+
+// The REST path of a kind's objects in a namespace, or in every namespace when
+// it is empty, eg. /apis/apps/v1/namespaces/default/deployments
+func (d *Dynamic) Collection(kind, namespace string) (string, error) {
+	r, err := d.resource("", kind)
+	if err != nil {
+		return "", err
+	}
+
+	if !r.namespaced || namespace == api.NamespaceAll {
+		return path.Join(r.prefix(), r.groupVersion, r.name), nil
+	}
+	return path.Join(r.prefix(), r.groupVersion, "namespaces", namespace, r.name), nil
+}
+
+// Looks up where a kind is served, in the apiVersion's group unless it is empty,
+// rediscovering if it is unknown and discovery hasn't run recently
+func (d *Dynamic) resource(apiVersion, kind string) (resource, error) {
+	var gk *unversioned.GroupKind
+	if apiVersion != "" {
+		gv, err := unversioned.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return resource{}, fmt.Errorf("Unable to read apiVersion of %s: %v", kind, err.Error())
+		}
+		gk = &unversioned.GroupKind{Group: gv.Group, Kind: kind}
+	}
+	unsupported := UnsupportedKind{Kind: kind}
+	if gk != nil {
+		unsupported.Group = gk.Group
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if r, ok := d.lookup(gk, kind); ok {
+		return r, nil
+	}
+
+	if d.resources != nil && d.Clock.Since(d.discoveredAt) < DISCOVERY_REFRESH {
+		return resource{}, unsupported
+	}
+
+	if err := d.discover(); err != nil {
+		return resource{}, err
+	}
+
+	if r, ok := d.lookup(gk, kind); ok {
+		return r, nil
+	}
+	return resource{}, unsupported
+}
+
+// By group and kind when the group is known, or else by kind
+func (d *Dynamic) lookup(gk *unversioned.GroupKind, kind string) (resource, bool) {
+	if gk != nil {
+		r, ok := d.resources[*gk]
+		return r, ok
+	}
+	r, ok := d.kinds[kind]
+	return r, ok
+}
+
+func (d *Dynamic) discover() error {
+	groups, err := d.Discovery.ServerGroups()
+	if err != nil {
+		return fmt.Errorf("Unable to discover API groups: %v", err.Error())
+	}
+
+	resources := make(map[unversioned.GroupKind]resource)
+	kinds := make(map[string]resource)
+	for _, g := range groups.Groups {
+		gv := g.PreferredVersion.GroupVersion
+		list, err := d.Discovery.ServerResourcesForGroupVersion(gv)
+		if err != nil {
+			log.Warnf("Unable to discover resources in %s: %v", gv, err.Error())
+			continue
+		}
+
+		for _, r := range list.APIResources {
+			// Subresources like pods/status share their parent's kind
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			res := resource{
+				group:        g.Name,
+				groupVersion: gv,
+				name:         r.Name,
+				namespaced:   r.Namespaced,
+			}
+			resources[unversioned.GroupKind{Group: g.Name, Kind: r.Kind}] = res
+
+			if known, ok := kinds[r.Kind]; ok && (known.group == "" || g.Name != "") {
+				continue
+			}
+			kinds[r.Kind] = res
+		}
+	}
+
+	d.resources = resources
+	d.kinds = kinds
+	d.discoveredAt = d.Clock.Now()
+	log.Debugf("Discovered %d kinds of object", len(resources))
+	return nil
+}
+
+// The REST path of an object, eg. /apis/apps/v1beta1/namespaces/default/statefulsets/web
+func (r resource) path(namespace, name string) string {
+	if !r.namespaced {
+		return path.Join(r.prefix(), r.groupVersion, r.name, name)
+	}
+	return path.Join(r.prefix(), r.groupVersion, "namespaces", namespace, r.name, name)
+}
+
+// The core group is served under /api, every other group under /apis
+func (r resource) prefix() string {
+	if r.group == "" {
+		return "/api"
+	}
+	return "/apis"
+}
@@ -0,0 +1,165 @@
+package owners
+
+import (
+	"fmt"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+// The groups the client's kinds are served from
+var typedGroups = map[string]bool{
+	"":           true,
+	"extensions": true,
+	"apps":       true,
+	"batch":      true,
+}
+
+// Kube gets, lists and watches objects in the Kubernetes API for the kinds the client
+// knows about. Gets of any other kind, eg. StatefulSets, CronJobs and custom resources,
+// are passed to Dynamic when it is set, as are those of another group than the
+// client's, eg. a custom resource called Job.
+type Kube struct {
+	Client  client.Interface
+	Dynamic deps.IObjectGetter
+}
+
+func NewKube(c client.Interface) *Kube {
+	return &Kube{
+		Client: c,
+	}
+}
+
+func (k *Kube) Get(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+	if !Typed(apiVersion) {
+		return k.dynamic(apiVersion, kind, namespace, name)
+	}
+
+	var obj runtime.Object
+	var err error
+
+	switch kind {
+	case "Pod":
+		obj, err = k.Client.Pods(namespace).Get(name)
+	case "Service":
+		obj, err = k.Client.Services(namespace).Get(name)
+	case "Node":
+		obj, err = k.Client.Nodes().Get(name)
+	case "Namespace":
+		obj, err = k.Client.Namespaces().Get(name)
+	case "ReplicationController":
+		obj, err = k.Client.ReplicationControllers(namespace).Get(name)
+	case "ReplicaSet":
+		obj, err = k.Client.Extensions().ReplicaSets(namespace).Get(name)
+	case "Deployment":
+		obj, err = k.Client.Extensions().Deployments(namespace).Get(name)
+	case "DaemonSet":
+		obj, err = k.Client.Extensions().DaemonSets(namespace).Get(name)
+	case "Job":
+		obj, err = k.Client.Extensions().Jobs(namespace).Get(name)
+	case "ScheduledJob":
+		obj, err = k.Client.Batch().ScheduledJobs(namespace).Get(name)
+	case "PetSet":
+		obj, err = k.Client.Apps().PetSets(namespace).Get(name)
+	default:
+		return k.dynamic(apiVersion, kind, namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return api.ObjectMetaFor(obj)
+}
+
+func (k *Kube) dynamic(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+	if k.Dynamic == nil {
+		return nil, fmt.Errorf("unsupported kind %s", kind)
+	}
+	return k.Dynamic.Get(apiVersion, kind, namespace, name)
+}
+
+// Reports whether an object of the apiVersion may be of a kind the client knows, as
+// opposed to eg. a custom resource of the same name in another group. An object
+// without an apiVersion, eg. from an old created-by annotation, may be.
+func Typed(apiVersion string) bool {
+	if apiVersion == "" {
+		return true
+	}
+	gv, err := unversioned.ParseGroupVersion(apiVersion)
+	return err == nil && typedGroups[gv.Group]
+}
+
+// Returns a pod with its status, eg. to check it is ready
+func (k *Kube) Pod(namespace, name string) (*api.Pod, error) {
+	return k.Client.Pods(namespace).Get(name)
+}
+
+func (k *Kube) List(kind, namespace string, opts api.ListOptions) (runtime.Object, error) {
+	var obj runtime.Object
+	var err error
+
+	switch kind {
+	case "Pod":
+		obj, err = k.Client.Pods(namespace).List(opts)
+	case "Service":
+		obj, err = k.Client.Services(namespace).List(opts)
+	case "Node":
+		obj, err = k.Client.Nodes().List(opts)
+	case "Namespace":
+		obj, err = k.Client.Namespaces().List(opts)
+	case "ReplicationController":
+		obj, err = k.Client.ReplicationControllers(namespace).List(opts)
+	case "ReplicaSet":
+		obj, err = k.Client.Extensions().ReplicaSets(namespace).List(opts)
+	case "Deployment":
+		obj, err = k.Client.Extensions().Deployments(namespace).List(opts)
+	case "DaemonSet":
+		obj, err = k.Client.Extensions().DaemonSets(namespace).List(opts)
+	case "Job":
+		obj, err = k.Client.Extensions().Jobs(namespace).List(opts)
+	case "ScheduledJob":
+		obj, err = k.Client.Batch().ScheduledJobs(namespace).List(opts)
+	case "PetSet":
+		obj, err = k.Client.Apps().PetSets(namespace).List(opts)
+	default:
+		return nil, fmt.Errorf("unsupported kind %s", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (k *Kube) Watch(kind, namespace string, opts api.ListOptions) (watch.Interface, error) {
+	switch kind {
+	case "Pod":
+		return k.Client.Pods(namespace).Watch(opts)
+	case "Service":
+		return k.Client.Services(namespace).Watch(opts)
+	case "Node":
+		return k.Client.Nodes().Watch(opts)
+	case "Namespace":
+		return k.Client.Namespaces().Watch(opts)
+	case "ReplicationController":
+		return k.Client.ReplicationControllers(namespace).Watch(opts)
+	case "ReplicaSet":
+		return k.Client.Extensions().ReplicaSets(namespace).Watch(opts)
+	case "Deployment":
+		return k.Client.Extensions().Deployments(namespace).Watch(opts)
+	case "DaemonSet":
+		return k.Client.Extensions().DaemonSets(namespace).Watch(opts)
+	case "Job":
+		return k.Client.Extensions().Jobs(namespace).Watch(opts)
+	case "ScheduledJob":
+		return k.Client.Batch().ScheduledJobs(namespace).Watch(opts)
+	case "PetSet":
+		return k.Client.Apps().PetSets(namespace).Watch(opts)
+	}
+	return nil, fmt.Errorf("unsupported kind %s", kind)
+}
@@ -0,0 +1,86 @@
+package owners
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/kubernetes/pkg/api"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+const (
+	// Guards against ownerReference cycles
+	MAX_DEPTH = 10
+)
+
+// Resolver walks ownerReferences from an object up to the workload that ultimately controls it,
+// eg. Pod -> ReplicaSet -> Deployment.
+type Resolver struct {
+	Getter deps.IObjectGetter
+}
+
+func New(g deps.IObjectGetter) *Resolver {
+	return &Resolver{
+		Getter: g,
+	}
+}
+
+// Returns the object followed by each of its controllers in turn, along with the
+// metadata of each. The chain ends early if an owner cannot be fetched.
+func (r *Resolver) Chain(ref api.ObjectReference) ([]api.ObjectReference, []*api.ObjectMeta, error) {
+	var refs []api.ObjectReference
+	var metas []*api.ObjectMeta
+
+	for i := 0; i < MAX_DEPTH; i++ {
+		meta, err := r.Getter.Get(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+		if err != nil {
+			if i == 0 {
+				return nil, nil, fmt.Errorf("Unable to get %s %s: %v", ref.Kind, ref.Name, err.Error())
+			}
+			return refs, metas, nil
+		}
+		refs = append(refs, ref)
+		metas = append(metas, meta)
+
+		owner, ok := controllerOf(meta)
+		if !ok {
+			break
+		}
+		owner.Namespace = ref.Namespace
+		ref = owner
+	}
+
+	return refs, metas, nil
+}
+
+// Returns the top most controller of the object, or the object itself if nothing controls it
+func (r *Resolver) Root(ref api.ObjectReference) (api.ObjectReference, error) {
+	refs, _, err := r.Chain(ref)
+	if err != nil {
+		return ref, err
+	}
+	return refs[len(refs)-1], nil
+}
+
+// Prefers the controller ownerReference, falling back to the created-by annotation older controllers set
+func controllerOf(meta *api.ObjectMeta) (api.ObjectReference, bool) {
+	for _, o := range meta.OwnerReferences {
+		if o.Controller != nil && *o.Controller {
+			return api.ObjectReference{Kind: o.Kind, Name: o.Name, UID: o.UID, APIVersion: o.APIVersion}, true
+		}
+	}
+	if len(meta.OwnerReferences) == 1 {
+		o := meta.OwnerReferences[0]
+		return api.ObjectReference{Kind: o.Kind, Name: o.Name, UID: o.UID, APIVersion: o.APIVersion}, true
+	}
+
+	if raw, ok := meta.Annotations[api.CreatedByAnnotation]; ok {
+		var sr api.SerializedReference
+		if err := json.Unmarshal([]byte(raw), &sr); err == nil && sr.Reference.Kind != "" {
+			return sr.Reference, true
+		}
+	}
+
+	return api.ObjectReference{}, false
+}
@@ -0,0 +1,13 @@
+package owners
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestOwnersSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Owners Suite")
+}
@@ -0,0 +1,283 @@
+// +build unit
+
+package owners
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+)
+
+func controlledBy(kind, name string) []api.OwnerReference {
+	controller := true
+	return []api.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+var _ = Describe("Resolver", func() {
+	var (
+		fakeGetter *depsfakes.FakeIObjectGetter
+		objects    map[string]*api.ObjectMeta
+		r          *Resolver
+	)
+
+	BeforeEach(func() {
+		objects = map[string]*api.ObjectMeta{
+			"Pod/web-1234-abcd":   {Name: "web-1234-abcd", OwnerReferences: controlledBy("ReplicaSet", "web-1234")},
+			"ReplicaSet/web-1234": {Name: "web-1234", OwnerReferences: controlledBy("Deployment", "web")},
+			"Deployment/web":      {Name: "web"},
+			"Pod/bare":            {Name: "bare"},
+		}
+
+		fakeGetter = &depsfakes.FakeIObjectGetter{}
+		fakeGetter.GetStub = func(apiVersion, kind, namespace, name string) (*api.ObjectMeta, error) {
+			if meta, ok := objects[kind+"/"+name]; ok {
+				return meta, nil
+			}
+			return nil, fmt.Errorf("%s %s not found", kind, name)
+		}
+
+		r = New(fakeGetter)
+	})
+
+	It("should look up each owner in the group of its ownerReference", func() {
+		controller := true
+		objects["Pod/web-0"] = &api.ObjectMeta{Name: "web-0", OwnerReferences: []api.OwnerReference{
+			{APIVersion: "stable.example.com/v1", Kind: "Deployment", Name: "web", Controller: &controller},
+		}}
+
+		r.Chain(api.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "default", Name: "web-0"})
+		apiVersion, kind, _, _ := fakeGetter.GetArgsForCall(0)
+		Expect(apiVersion).To(Equal("v1"))
+		Expect(kind).To(Equal("Pod"))
+		apiVersion, kind, _, _ = fakeGetter.GetArgsForCall(1)
+		Expect(apiVersion).To(Equal("stable.example.com/v1"))
+		Expect(kind).To(Equal("Deployment"))
+	})
+
+	It("should walk the controllers up to the workload", func() {
+		refs, metas, err := r.Chain(api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web-1234-abcd"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(refs).To(HaveLen(3))
+		Expect(refs[1].Kind).To(Equal("ReplicaSet"))
+		Expect(refs[2].Kind).To(Equal("Deployment"))
+		Expect(refs[2].Namespace).To(Equal("default"))
+		Expect(metas[2].Name).To(Equal("web"))
+
+		_, _, namespace, _ := fakeGetter.GetArgsForCall(2)
+		Expect(namespace).To(Equal("default"))
+	})
+
+	It("should return the object itself when nothing controls it", func() {
+		root, err := r.Root(api.ObjectReference{Kind: "Pod", Name: "bare"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(root.Name).To(Equal("bare"))
+	})
+
+	It("should stop at the last owner that could be fetched", func() {
+		delete(objects, "Deployment/web")
+		root, err := r.Root(api.ObjectReference{Kind: "Pod", Name: "web-1234-abcd"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(root.Kind).To(Equal("ReplicaSet"))
+	})
+
+	It("should return an error when the object cannot be fetched", func() {
+		_, err := r.Root(api.ObjectReference{Kind: "Pod", Name: "missing"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fall back to the created-by annotation", func() {
+		objects["Pod/old"] = &api.ObjectMeta{
+			Name: "old",
+			Annotations: map[string]string{
+				api.CreatedByAnnotation: `{"kind":"SerializedReference","apiVersion":"v1","reference":{"kind":"ReplicaSet","name":"web-1234"}}`,
+			},
+		}
+
+		root, err := r.Root(api.ObjectReference{Kind: "Pod", Name: "old"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(root.Name).To(Equal("web"))
+	})
+
+	It("should not loop forever on ownerReference cycles", func() {
+		objects["ReplicaSet/a"] = &api.ObjectMeta{Name: "a", OwnerReferences: controlledBy("ReplicaSet", "b")}
+		objects["ReplicaSet/b"] = &api.ObjectMeta{Name: "b", OwnerReferences: controlledBy("ReplicaSet", "a")}
+
+		refs, _, err := r.Chain(api.ObjectReference{Kind: "ReplicaSet", Name: "a"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(refs).To(HaveLen(MAX_DEPTH))
+	})
+})
+
+// The path of the nth raw GET
+func rawPath(fake *depsfakes.FakeIRESTGetter, n int) string {
+	p, _ := fake.GetRawArgsForCall(n)
+	return p
+}
+
+var _ = Describe("Dynamic", func() {
+	var (
+		fakeDiscovery *depsfakes.FakeIDiscoveryClient
+		fakeREST      *depsfakes.FakeIRESTGetter
+		clk           *clock.FakeClock
+		d             *Dynamic
+	)
+
+	BeforeEach(func() {
+		fakeDiscovery = &depsfakes.FakeIDiscoveryClient{}
+		fakeDiscovery.ServerGroupsReturns(&unversioned.APIGroupList{
+			Groups: []unversioned.APIGroup{
+				{Name: "", PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "v1"}},
+				{Name: "apps", PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "apps/v1beta1"}},
+				{Name: "stable.example.com", PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "stable.example.com/v1"}},
+			},
+		}, nil)
+		fakeDiscovery.ServerResourcesForGroupVersionStub = func(gv string) (*unversioned.APIResourceList, error) {
+			switch gv {
+			case "v1":
+				return &unversioned.APIResourceList{APIResources: []unversioned.APIResource{
+					{Name: "pods", Namespaced: true, Kind: "Pod"},
+					{Name: "pods/status", Namespaced: true, Kind: "Pod"},
+					{Name: "nodes", Namespaced: false, Kind: "Node"},
+				}}, nil
+			case "apps/v1beta1":
+				return &unversioned.APIResourceList{APIResources: []unversioned.APIResource{
+					{Name: "statefulsets", Namespaced: true, Kind: "StatefulSet"},
+				}}, nil
+			case "stable.example.com/v1":
+				return &unversioned.APIResourceList{APIResources: []unversioned.APIResource{
+					{Name: "crontabs", Namespaced: true, Kind: "CronTab"},
+				}}, nil
+			}
+			return nil, fmt.Errorf("unknown group version %s", gv)
+		}
+
+		fakeREST = &depsfakes.FakeIRESTGetter{}
+		fakeREST.GetRawReturns([]byte(`{
+			"apiVersion": "stable.example.com/v1",
+			"kind": "CronTab",
+			"metadata": {
+				"name": "nightly",
+				"namespace": "default",
+				"labels": {"team": "payments"},
+				"annotations": {"kit-overwatch/mention": "payments-oncall"},
+				"ownerReferences": [{"apiVersion": "apps/v1beta1", "kind": "StatefulSet", "name": "web", "uid": "1234", "controller": true}]
+			}
+		}`), nil)
+
+		clk = clock.NewFakeClock(time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC))
+		d = &Dynamic{
+			Discovery: fakeDiscovery,
+			REST:      fakeREST,
+			Clock:     clk,
+		}
+	})
+
+	It("should fetch custom resources from their group's preferred version", func() {
+		meta, err := d.Get("stable.example.com/v1", "CronTab", "default", "nightly")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rawPath(fakeREST, 0)).To(Equal("/apis/stable.example.com/v1/namespaces/default/crontabs/nightly"))
+		Expect(meta.Labels["team"]).To(Equal("payments"))
+		Expect(meta.Annotations["kit-overwatch/mention"]).To(Equal("payments-oncall"))
+
+		owner, ok := controllerOf(meta)
+		Expect(ok).To(BeTrue())
+		Expect(owner.Kind).To(Equal("StatefulSet"))
+		Expect(owner.Name).To(Equal("web"))
+	})
+
+	It("should build paths for the core group and cluster scoped kinds", func() {
+		d.Get("v1", "Pod", "default", "web-0")
+		d.Get("v1", "Node", "", "node-1")
+		d.Get("apps/v1beta1", "StatefulSet", "default", "web")
+		Expect(rawPath(fakeREST, 0)).To(Equal("/api/v1/namespaces/default/pods/web-0"))
+		Expect(rawPath(fakeREST, 1)).To(Equal("/api/v1/nodes/node-1"))
+		Expect(rawPath(fakeREST, 2)).To(Equal("/apis/apps/v1beta1/namespaces/default/statefulsets/web"))
+	})
+
+	It("should prefer the core group for kinds other groups serve too", func() {
+		fakeDiscovery.ServerGroupsReturns(&unversioned.APIGroupList{
+			Groups: []unversioned.APIGroup{
+				{Name: "events.k8s.io", PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "events.k8s.io/v1"}},
+				{Name: "", PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "v1"}},
+			},
+		}, nil)
+		fakeDiscovery.ServerResourcesForGroupVersionStub = func(gv string) (*unversioned.APIResourceList, error) {
+			return &unversioned.APIResourceList{APIResources: []unversioned.APIResource{
+				{Name: "events", Namespaced: true, Kind: "Event"},
+			}}, nil
+		}
+
+		Expect(d.Collection("Event", "default")).To(Equal("/api/v1/namespaces/default/events"))
+	})
+
+	It("should tell apart kinds of the same name by group", func() {
+		fakeDiscovery.ServerGroupsReturns(&unversioned.APIGroupList{
+			Groups: []unversioned.APIGroup{
+				{Name: "a.example.com", PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "a.example.com/v1"}},
+				{Name: "b.example.com", PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "b.example.com/v2"}},
+			},
+		}, nil)
+		fakeDiscovery.ServerResourcesForGroupVersionStub = func(gv string) (*unversioned.APIResourceList, error) {
+			return &unversioned.APIResourceList{APIResources: []unversioned.APIResource{
+				{Name: "widgets", Namespaced: true, Kind: "Widget"},
+			}}, nil
+		}
+
+		d.Get("b.example.com/v1", "Widget", "default", "w")
+		d.Get("a.example.com/v1", "Widget", "default", "w")
+		d.Get("", "Widget", "default", "w")
+		Expect(rawPath(fakeREST, 0)).To(Equal("/apis/b.example.com/v2/namespaces/default/widgets/w"))
+		Expect(rawPath(fakeREST, 1)).To(Equal("/apis/a.example.com/v1/namespaces/default/widgets/w"))
+		Expect(rawPath(fakeREST, 2)).To(Equal("/apis/a.example.com/v1/namespaces/default/widgets/w"))
+
+		_, err := d.Get("c.example.com/v1", "Widget", "default", "w")
+		Expect(err).To(Equal(UnsupportedKind{Kind: "Widget", Group: "c.example.com"}))
+	})
+
+	It("should build the paths of collections", func() {
+		Expect(d.Collection("StatefulSet", "default")).To(Equal("/apis/apps/v1beta1/namespaces/default/statefulsets"))
+		Expect(d.Collection("StatefulSet", "")).To(Equal("/apis/apps/v1beta1/statefulsets"))
+		Expect(d.Collection("Node", "default")).To(Equal("/api/v1/nodes"))
+
+		_, err := d.Collection("Widget", "default")
+		Expect(err).To(Equal(UnsupportedKind{Kind: "Widget"}))
+	})
+
+	It("should only discover once", func() {
+		d.Get("", "Pod", "default", "web-0")
+		d.Get("", "StatefulSet", "default", "web")
+		Expect(fakeDiscovery.ServerGroupsCallCount()).To(Equal(1))
+	})
+
+	It("should not rediscover unknown kinds until discovery is due again", func() {
+		_, err := d.Get("", "Widget", "default", "w")
+		Expect(err).To(MatchError("unsupported kind Widget"))
+		d.Get("", "Widget", "default", "w")
+		Expect(fakeDiscovery.ServerGroupsCallCount()).To(Equal(1))
+
+		clk.Step(DISCOVERY_REFRESH)
+		d.Get("", "Widget", "default", "w")
+		Expect(fakeDiscovery.ServerGroupsCallCount()).To(Equal(2))
+		Expect(fakeREST.GetRawCallCount()).To(Equal(0))
+	})
+
+	It("should return errors from the API", func() {
+		fakeREST.GetRawReturns(nil, errors.NewNotFound(api.Resource("crontabs"), "nightly"))
+		_, err := d.Get("", "CronTab", "default", "nightly")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should error when discovery fails", func() {
+		fakeDiscovery.ServerGroupsReturns(nil, fmt.Errorf("boom"))
+		_, err := d.Get("", "Pod", "default", "web-0")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,349 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	dependencies "github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+const (
+	REASON_RESOLVED = "Resolved"
+	COMPONENT       = "kit-overwatch"
+	CHECK_INTERVAL  = 30 * time.Second
+
+	// Problems still open after this long are forgotten, eg. a pod left in BackOff for good
+	MAX_OPEN = 24 * time.Hour
+)
+
+// Rule says how a kind of problem clears: an event with one of the ResolvedBy
+// reasons about the same object, or with Ready, the pod becoming ready. With
+// Messages, only events whose message contains one of them are the problem.
+type Rule struct {
+	Kind       string
+	Problems   []string
+	Messages   []string
+	ResolvedBy []string
+	Ready      bool
+}
+
+// The problems followed up, by the reason of the event announcing them. The first
+// matching rule wins; the kubelet reports failing image pulls as BackOff and Failed.
+var Rules = []Rule{
+	{Kind: "Pod", Problems: []string{"ImagePullBackOff", "ErrImagePull"}, ResolvedBy: []string{"Pulled"}, Ready: true},
+	{Kind: "Pod", Problems: []string{"BackOff", "Failed"}, Messages: []string{"pulling image", "ImagePullBackOff", "ErrImagePull"}, ResolvedBy: []string{"Pulled"}, Ready: true},
+	{Kind: "Pod", Problems: []string{"BackOff", "CrashLoopBackOff"}, Ready: true},
+	{Kind: "Node", Problems: []string{"NodeNotReady"}, ResolvedBy: []string{"NodeReady"}},
+	{Kind: "Deployment", Problems: []string{"RolloutStalled"}, ResolvedBy: []string{"RolloutCompleted"}},
+}
+
+type key struct {
+	Object api.ObjectReference
+	Reason string
+}
+
+// An open problem and what we need to announce it cleared
+type problem struct {
+	deps.Problem
+	rule   *Rule
+	level  string
+	latest *deps.Notification
+
+	// Whether the pod was ready at the last check, and its restarts then
+	ready    bool
+	restarts int32
+}
+
+// Tracker follows up problems with a RESOLVED notification once they clear, sharing
+// the problem's ID with the notifications about it. Problems clear when an event
+// announcing recovery is observed, even if it isn't notified about itself, eg.
+// because it is throttled, or for pods, when two periodic checks in a row find
+// them ready without having restarted in between, so a crashing container that is
+// briefly ready doesn't resolve its problem.
+// Everything is passed on to Next.
+type Tracker struct {
+	Pods  dependencies.IPodGetter
+	Next  deps.Sink
+	Rules []Rule
+	Clock clock.Clock
+
+	// Runs sends in the background; the watcher sets it so Shutdown waits for them
+	Spawn func(func())
+
+	lock     sync.Mutex
+	problems map[key]*problem
+}
+
+func New(p dependencies.IPodGetter, next deps.Sink) *Tracker {
+	return &Tracker{
+		Pods:     p,
+		Next:     next,
+		Rules:    Rules,
+		Clock:    clock.RealClock{},
+		Spawn:    func(f func()) { go f() },
+		problems: make(map[key]*problem),
+	}
+}
+
+// Observe resolves the problems an event announces recovery from. It is given every
+// event, before any are held back, eg. by throttling or flap detection, so recovery
+// isn't missed when it isn't notified about itself. Only events that happened since
+// the latest notification about a problem resolve it, so one from before, eg.
+// listed again after reconnecting, doesn't.
+func (t *Tracker) Observe(e api.Event) {
+	ref, ok := objectOf(e)
+	if !ok {
+		return
+	}
+
+	t.lock.Lock()
+	now := t.Clock.Now()
+	var resolved []*deps.Notification
+	for k, p := range t.problems {
+		if k.Object != ref || !contains(p.rule.ResolvedBy, e.Reason) || e.LastTimestamp.Time.Before(p.latest.Event.LastTimestamp.Time) {
+			continue
+		}
+		delete(t.problems, k)
+		log.Infof("Problem %s resolved by %s", p.ID, e.Reason)
+		resolved = append(resolved, t.notification(p, fmt.Sprintf("%s: %s", e.Reason, e.Message), now))
+	}
+	t.lock.Unlock()
+
+	for _, r := range resolved {
+		r := r
+		t.Spawn(func() { t.Next.SendAll(r) })
+	}
+}
+
+// SendAll starts following up the problems notifications are about, and tags them with the problem's ID
+func (t *Tracker) SendAll(n *deps.Notification) {
+	ref, ok := objectOf(n.Event)
+	if !ok {
+		t.Next.SendAll(n)
+		return
+	}
+	reason := n.Event.Reason
+
+	t.lock.Lock()
+	now := t.Clock.Now()
+	if rule := t.rule(ref.Kind, reason, n.Event.Message); rule != nil {
+		k := key{Object: ref, Reason: reason}
+		p, ok := t.problems[k]
+		if !ok {
+			since := n.Event.FirstTimestamp.Time
+			if since.IsZero() {
+				since = now
+			}
+			p = &problem{
+				Problem: deps.Problem{
+					ID:     fmt.Sprintf("%s-%s-%s-%d", strings.ToLower(ref.Kind), ref.Name, strings.ToLower(reason), since.Unix()),
+					Reason: reason,
+					Object: ref,
+					Since:  since,
+				},
+				rule: rule,
+			}
+			t.problems[k] = p
+			log.Debugf("Following up problem %s", p.ID)
+		}
+		p.latest = n
+		if deps.Severity(n.Level) > deps.Severity(p.level) {
+			p.level = n.Level
+		}
+
+		copied := *n
+		copied.Problem = p.snapshot()
+		n = &copied
+	}
+	t.lock.Unlock()
+
+	t.Next.SendAll(n)
+}
+
+// The object an event is about, which is in the event's namespace unless it says otherwise
+func objectOf(e api.Event) (api.ObjectReference, bool) {
+	ref := api.ObjectReference{
+		Kind:      e.InvolvedObject.Kind,
+		Namespace: e.InvolvedObject.Namespace,
+		Name:      e.InvolvedObject.Name,
+	}
+	if ref.Name == "" {
+		return ref, false
+	}
+	if ref.Namespace == "" {
+		ref.Namespace = e.ObjectMeta.Namespace
+	}
+	return ref, true
+}
+
+// Periodically checks whether pods with problems have become ready, until the context is done
+func (t *Tracker) Run(ctx context.Context) {
+	tick := t.Clock.Tick(CHECK_INTERVAL)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			t.check()
+		}
+	}
+}
+
+func (t *Tracker) check() {
+	t.lock.Lock()
+	now := t.Clock.Now()
+	candidates := make(map[key]*problem)
+	for k, p := range t.problems {
+		if now.Sub(p.Since) >= MAX_OPEN {
+			log.Debugf("Forgetting problem %s, still open after %v", p.ID, MAX_OPEN)
+			delete(t.problems, k)
+			continue
+		}
+		if p.rule.Ready {
+			candidates[k] = p
+		}
+	}
+	t.lock.Unlock()
+
+	for k, p := range candidates {
+		pod, err := t.Pods.Pod(k.Object.Namespace, k.Object.Name)
+		if errors.IsNotFound(err) {
+			// A deleted pod never recovers, its replacement starts afresh
+			log.Debugf("Forgetting problem %s, the pod is gone", p.ID)
+			t.forget(k, p)
+			continue
+		}
+		if err != nil {
+			log.Warnf("Unable to check whether problem %s is resolved: %v", p.ID, err.Error())
+			continue
+		}
+		if !t.stable(p, pod) {
+			continue
+		}
+
+		if t.forget(k, p) {
+			log.Infof("Problem %s resolved, the pod is ready", p.ID)
+			t.Next.SendAll(t.notification(p, "the pod is ready", t.Clock.Now()))
+		}
+	}
+}
+
+// Abandon forgets the problems still open once we stop watching, logging each as
+// no RESOLVED notification will follow. Returns how many there were.
+func (t *Tracker) Abandon() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	n := len(t.problems)
+	for _, p := range t.problems {
+		log.Warnf("Leaving problem %s open since %v", p.ID, p.Since)
+	}
+	t.problems = make(map[key]*problem)
+	return n
+}
+
+// Records what a check found of a problem's pod, reporting whether it has been
+// ready since the previous check without restarting
+func (t *Tracker) stable(p *problem, pod *api.Pod) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	was, before := p.ready, p.restarts
+	p.ready, p.restarts = ready(pod), restarts(pod)
+	return was && p.ready && p.restarts == before
+}
+
+// Removes a problem unless it has already been resolved meanwhile, reporting whether it was removed
+func (t *Tracker) forget(k key, p *problem) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.problems[k] != p {
+		return false
+	}
+	delete(t.problems, k)
+	return true
+}
+
+func (t *Tracker) rule(kind, reason, message string) *Rule {
+	for i := range t.Rules {
+		r := &t.Rules[i]
+		if r.Kind != kind || !contains(r.Problems, reason) {
+			continue
+		}
+		if len(r.Messages) == 0 {
+			return r
+		}
+		for _, m := range r.Messages {
+			if strings.Contains(message, m) {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// Builds the RESOLVED notification. It carries a copy of the problem's latest event
+// so it is still about the same involved object, and is as severe as the problem was.
+func (t *Tracker) notification(p *problem, how string, now time.Time) *deps.Notification {
+	e := p.latest.Event
+	e.Source = api.EventSource{Component: COMPONENT}
+	e.Reason = REASON_RESOLVED
+	e.Type = api.EventTypeNormal
+	e.FirstTimestamp = unversioned.NewTime(p.Since)
+	e.LastTimestamp = unversioned.NewTime(now)
+	e.Message = fmt.Sprintf("%s %s has recovered from %s after %v, %s", p.Object.Kind, p.Object.Name, p.Reason, now.Sub(p.Since), how)
+
+	return &deps.Notification{
+		Cluster:   p.latest.Cluster,
+		Namespace: p.latest.Namespace,
+		Event:     e,
+		Level:     p.level,
+		Mention:   p.latest.Mention,
+		Resolved:  p.snapshot(),
+	}
+}
+
+// Copies the problem so notifications don't change as it does
+func (p *problem) snapshot() *deps.Problem {
+	s := p.Problem
+	return &s
+}
+
+// Reports whether all of a pod's containers are ready
+func ready(pod *api.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == api.PodReady {
+			return c.Status == api.ConditionTrue
+		}
+	}
+	return false
+}
+
+// The restarts of all of a pod's containers
+func restarts(pod *api.Pod) int32 {
+	var n int32
+	for _, s := range pod.Status.ContainerStatuses {
+		n += s.RestartCount
+	}
+	return n
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package recovery
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRecoverySuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Recovery Suite")
+}
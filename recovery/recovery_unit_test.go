@@ -0,0 +1,263 @@
+// +build unit
+
+package recovery
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/fakes/notifiersfakes"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+func notification(kind, name, reason, message, level string, first time.Time) *deps.Notification {
+	return &deps.Notification{
+		Cluster:   "prod",
+		Namespace: "default",
+		Level:     level,
+		Mention:   "payments",
+		Event: api.Event{
+			ObjectMeta:     api.ObjectMeta{Name: name + ".1", Namespace: "default"},
+			InvolvedObject: api.ObjectReference{Kind: kind, Name: name},
+			Reason:         reason,
+			Message:        message,
+			Type:           api.EventTypeWarning,
+			FirstTimestamp: unversioned.NewTime(first),
+			LastTimestamp:  unversioned.NewTime(first),
+		},
+	}
+}
+
+// An event that isn't notified about, eg. announcing recovery
+func event(kind, name, reason, message string, at time.Time) api.Event {
+	return notification(kind, name, reason, message, "INFO", at).Event
+}
+
+func pod(ready api.ConditionStatus) *api.Pod {
+	return &api.Pod{Status: api.PodStatus{Conditions: []api.PodCondition{{Type: api.PodReady, Status: ready}}}}
+}
+
+func restarted(p *api.Pod, restarts int32) *api.Pod {
+	p.Status.ContainerStatuses = []api.ContainerStatus{{Name: "web", RestartCount: restarts}}
+	return p
+}
+
+var _ = Describe("Tracker", func() {
+	var (
+		fakePods *depsfakes.FakeIPodGetter
+		sink     *notifiersfakes.FakeSink
+		sent     func() []*deps.Notification
+		clk      *clock.FakeClock
+		start    time.Time
+		t        *Tracker
+	)
+
+	BeforeEach(func() {
+		start = time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC)
+		fakePods = &depsfakes.FakeIPodGetter{}
+		fakePods.PodReturns(pod(api.ConditionFalse), nil)
+		sink = &notifiersfakes.FakeSink{}
+		sent = notifiersfakes.SentTo(sink)
+		clk = clock.NewFakeClock(start)
+
+		t = New(fakePods, sink)
+		t.Clock = clk
+		t.Spawn = func(f func()) { f() }
+	})
+
+	It("should pass on events that aren't problems untouched", func() {
+		n := notification("Pod", "web-1", "Scheduled", "Successfully assigned web-1", "INFO", start)
+		t.SendAll(n)
+		Expect(sent()).To(Equal([]*deps.Notification{n}))
+		Expect(t.problems).To(BeEmpty())
+	})
+
+	It("should give problems an ID shared by every notification about them", func() {
+		t.SendAll(notification("Pod", "web-1", "BackOff", "Back-off restarting failed container", "WARN", start))
+		clk.Step(time.Minute)
+		t.SendAll(notification("Pod", "web-1", "BackOff", "Back-off restarting failed container", "WARN", start))
+
+		Expect(sent()).To(HaveLen(2))
+		Expect(sent()[0].Problem).ToNot(BeNil())
+		Expect(sent()[0].Problem.ID).To(Equal(fmt.Sprintf("pod-web-1-backoff-%d", start.Unix())))
+		Expect(sent()[1].Problem.ID).To(Equal(sent()[0].Problem.ID))
+		Expect(sent()[0].Resolved).To(BeNil())
+	})
+
+	It("should not change the notification it was given", func() {
+		n := notification("Pod", "web-1", "BackOff", "Back-off restarting failed container", "WARN", start)
+		t.SendAll(n)
+		Expect(n.Problem).To(BeNil())
+	})
+
+	It("should resolve a node problem when the node is ready again", func() {
+		t.SendAll(notification("Node", "node-1", "NodeNotReady", "Node node-1 status is now: NodeNotReady", "ERROR", start))
+		clk.Step(5 * time.Minute)
+		t.Observe(event("Node", "node-1", "NodeReady", "Node node-1 status is now: NodeReady", start.Add(5*time.Minute)))
+
+		Expect(sent()).To(HaveLen(2))
+
+		r := sent()[1]
+		Expect(r.Resolved).ToNot(BeNil())
+		Expect(r.Resolved.ID).To(Equal(sent()[0].Problem.ID))
+		Expect(r.Resolved.Reason).To(Equal("NodeNotReady"))
+		Expect(r.Event.Reason).To(Equal(REASON_RESOLVED))
+		Expect(r.Event.Type).To(Equal(api.EventTypeNormal))
+		Expect(r.Event.Source.Component).To(Equal(COMPONENT))
+		Expect(r.Event.InvolvedObject.Name).To(Equal("node-1"))
+		Expect(r.Event.FirstTimestamp.Time).To(Equal(start))
+		Expect(r.Event.LastTimestamp.Time).To(Equal(start.Add(5 * time.Minute)))
+		Expect(r.Event.Message).To(Equal("Node node-1 has recovered from NodeNotReady after 5m0s, NodeReady: Node node-1 status is now: NodeReady"))
+		Expect(r.Level).To(Equal("ERROR"))
+		Expect(r.Cluster).To(Equal("prod"))
+		Expect(r.Mention).To(Equal("payments"))
+		Expect(t.problems).To(BeEmpty())
+	})
+
+	It("should only resolve problems of the same object", func() {
+		t.SendAll(notification("Node", "node-1", "NodeNotReady", "NodeNotReady", "ERROR", start))
+		t.Observe(event("Node", "node-2", "NodeReady", "NodeReady", start))
+		Expect(sent()).To(HaveLen(1))
+		Expect(t.problems).To(HaveLen(1))
+	})
+
+	It("should not resolve problems with events from before their latest notification", func() {
+		t.SendAll(notification("Node", "node-1", "NodeNotReady", "NodeNotReady", "ERROR", start))
+		t.Observe(event("Node", "node-1", "NodeReady", "NodeReady", start.Add(-time.Minute)))
+		Expect(sent()).To(HaveLen(1))
+		Expect(t.problems).To(HaveLen(1))
+	})
+
+	It("should leave resolving to Observe, so recovery that is notified about doesn't resolve twice", func() {
+		t.SendAll(notification("Node", "node-1", "NodeNotReady", "NodeNotReady", "ERROR", start))
+		t.SendAll(notification("Node", "node-1", "NodeReady", "NodeReady", "INFO", start))
+		Expect(sent()).To(HaveLen(2))
+		Expect(t.problems).To(HaveLen(1))
+	})
+
+	It("should be as severe as the worst notification about the problem", func() {
+		t.SendAll(notification("Node", "node-1", "NodeNotReady", "NodeNotReady", "WARN", start))
+		t.SendAll(notification("Node", "node-1", "NodeNotReady", "NodeNotReady", "ERROR", start))
+		t.SendAll(notification("Node", "node-1", "NodeNotReady", "NodeNotReady", "WARN", start))
+		t.Observe(event("Node", "node-1", "NodeReady", "NodeReady", start))
+		Expect(sent()[3].Level).To(Equal("ERROR"))
+	})
+
+	It("should resolve a stalled rollout when it completes", func() {
+		t.SendAll(notification("Deployment", "web", "RolloutStalled", "Rollout of Deployment web has stalled", "ERROR", start))
+		t.Observe(event("Deployment", "web", "RolloutCompleted", "Rollout of Deployment web completed", start))
+
+		Expect(sent()).To(HaveLen(2))
+		Expect(sent()[1].Resolved).ToNot(BeNil())
+		Expect(sent()[1].Resolved.ID).To(Equal(sent()[0].Problem.ID))
+		Expect(sent()[1].Resolved.Reason).To(Equal("RolloutStalled"))
+	})
+
+	It("should resolve an image pull problem when the image is pulled", func() {
+		t.SendAll(notification("Pod", "web-1", "BackOff", "Back-off pulling image \"web:1.2\"", "WARN", start))
+		t.Observe(event("Pod", "web-1", "Pulled", "Successfully pulled image \"web:1.2\"", start))
+
+		Expect(sent()).To(HaveLen(2))
+		Expect(sent()[1].Resolved).ToNot(BeNil())
+		Expect(sent()[1].Resolved.ID).To(Equal(sent()[0].Problem.ID))
+	})
+
+	It("should not resolve a crashing container when an image is pulled", func() {
+		t.SendAll(notification("Pod", "web-1", "BackOff", "Back-off restarting failed container", "WARN", start))
+		t.Observe(event("Pod", "web-1", "Pulled", "Container image \"web:1.2\" already present on machine", start))
+		Expect(sent()).To(HaveLen(1))
+		Expect(t.problems).To(HaveLen(1))
+	})
+
+	Context("when checking pods", func() {
+		BeforeEach(func() {
+			t.SendAll(notification("Pod", "web-1", "BackOff", "Back-off restarting failed container", "WARN", start))
+			clk.Step(2 * time.Minute)
+		})
+
+		It("should resolve the problem once the pod stays ready", func() {
+			t.check()
+			Expect(sent()).To(HaveLen(1))
+
+			fakePods.PodReturns(pod(api.ConditionTrue), nil)
+			t.check()
+			Expect(sent()).To(HaveLen(1))
+			t.check()
+			Expect(sent()).To(HaveLen(2))
+			Expect(fakePods.PodCallCount()).To(Equal(3))
+			ns, name := fakePods.PodArgsForCall(2)
+			Expect(ns).To(Equal("default"))
+			Expect(name).To(Equal("web-1"))
+			Expect(sent()[1].Resolved.ID).To(Equal(sent()[0].Problem.ID))
+			Expect(sent()[1].Event.Message).To(Equal("Pod web-1 has recovered from BackOff after 2m0s, the pod is ready"))
+
+			t.check()
+			Expect(sent()).To(HaveLen(2))
+		})
+
+		It("should not resolve the problem when the pod restarted between checks", func() {
+			fakePods.PodReturns(restarted(pod(api.ConditionTrue), 3), nil)
+			t.check()
+			fakePods.PodReturns(restarted(pod(api.ConditionTrue), 4), nil)
+			t.check()
+			Expect(sent()).To(HaveLen(1))
+
+			t.check()
+			Expect(sent()).To(HaveLen(2))
+		})
+
+		It("should not resolve the problem when the pod wasn't ready in between", func() {
+			fakePods.PodReturns(pod(api.ConditionTrue), nil)
+			t.check()
+			fakePods.PodReturns(pod(api.ConditionFalse), nil)
+			t.check()
+			fakePods.PodReturns(pod(api.ConditionTrue), nil)
+			t.check()
+			Expect(sent()).To(HaveLen(1))
+		})
+
+		It("should keep the problem open when the pod can't be fetched", func() {
+			fakePods.PodReturns(nil, fmt.Errorf("connection refused"))
+			t.check()
+			Expect(sent()).To(HaveLen(1))
+			Expect(t.problems).To(HaveLen(1))
+		})
+
+		It("should forget the problem when the pod is deleted", func() {
+			fakePods.PodReturns(nil, errors.NewNotFound(api.Resource("pods"), "web-1"))
+			t.check()
+			Expect(sent()).To(HaveLen(1))
+			Expect(t.problems).To(BeEmpty())
+		})
+
+		It("should forget problems open for too long", func() {
+			clk.Step(MAX_OPEN)
+			t.check()
+			Expect(fakePods.PodCallCount()).To(Equal(0))
+			Expect(t.problems).To(BeEmpty())
+		})
+
+		It("should forget the problems still open when abandoned, without resolving them", func() {
+			Expect(t.Abandon()).To(Equal(1))
+
+			fakePods.PodReturns(pod(api.ConditionTrue), nil)
+			t.check()
+			Expect(fakePods.PodCallCount()).To(Equal(0))
+			Expect(sent()).To(HaveLen(1))
+		})
+
+		It("should not check problems that only clear with an event", func() {
+			t.SendAll(notification("Node", "node-1", "NodeNotReady", "NodeNotReady", "ERROR", start))
+			t.check()
+			Expect(fakePods.PodCallCount()).To(Equal(1))
+		})
+	})
+})
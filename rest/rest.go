@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"io"
+
+	"k8s.io/kubernetes/pkg/client/restclient"
+)
+
+// Client makes raw GETs against the Kubernetes API, for resources the typed clients
+// don't know about. Error responses are returned as API status errors.
+type Client struct {
+	RESTClient *restclient.RESTClient
+}
+
+func New(c *restclient.RESTClient) *Client {
+	return &Client{
+		RESTClient: c,
+	}
+}
+
+func (c *Client) GetRaw(path string, params map[string]string) ([]byte, error) {
+	return c.request(path, params).Do().Raw()
+}
+
+// Opens a long running GET, eg. a watch, which is read until the body is closed
+func (c *Client) StreamRaw(path string, params map[string]string) (io.ReadCloser, error) {
+	return c.request(path, params).Stream()
+}
+
+func (c *Client) request(path string, params map[string]string) *restclient.Request {
+	req := c.RESTClient.Get().AbsPath(path)
+	for k, v := range params {
+		req = req.Param(k, v)
+	}
+	return req
+}
@@ -0,0 +1,171 @@
+package rollout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+)
+
+// A Deployment, StatefulSet or DaemonSet from whichever API group the cluster
+// serves it in. The client we build against predates most of what rollouts are
+// followed by, so only the fields we use are declared.
+type Workload struct {
+	unversioned.TypeMeta `json:",inline"`
+	ObjectMeta           api.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkloadSpec   `json:"spec,omitempty"`
+	Status WorkloadStatus `json:"status,omitempty"`
+}
+
+type WorkloadSpec struct {
+	Replicas                *int32          `json:"replicas,omitempty"`
+	Template                json.RawMessage `json:"template,omitempty"`
+	UpdateStrategy          UpdateStrategy  `json:"updateStrategy,omitempty"`
+	ProgressDeadlineSeconds *int32          `json:"progressDeadlineSeconds,omitempty"`
+}
+
+// How a StatefulSet or DaemonSet rolls out changes to its template
+type UpdateStrategy struct {
+	Type string `json:"type,omitempty"`
+}
+
+// The status of every kind, each only setting its own fields
+type WorkloadStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Deployments and StatefulSets
+	Replicas          int32  `json:"replicas,omitempty"`
+	UpdatedReplicas   int32  `json:"updatedReplicas,omitempty"`
+	ReadyReplicas     int32  `json:"readyReplicas,omitempty"`
+	AvailableReplicas int32  `json:"availableReplicas,omitempty"`
+	CurrentRevision   string `json:"currentRevision,omitempty"`
+	UpdateRevision    string `json:"updateRevision,omitempty"`
+
+	// DaemonSets
+	DesiredNumberScheduled int32 `json:"desiredNumberScheduled,omitempty"`
+	UpdatedNumberScheduled int32 `json:"updatedNumberScheduled,omitempty"`
+	NumberAvailable        int32 `json:"numberAvailable,omitempty"`
+
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type WorkloadList struct {
+	unversioned.TypeMeta `json:",inline"`
+	unversioned.ListMeta `json:"metadata,omitempty"`
+
+	Items []Workload `json:"items"`
+}
+
+// The containers of a pod template, which is otherwise only compared as a whole
+type template struct {
+	Spec struct {
+		InitContainers []container `json:"initContainers,omitempty"`
+		Containers     []container `json:"containers,omitempty"`
+	} `json:"spec"`
+}
+
+type container struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// API lists and watches workloads over raw REST, from where discovery finds each kind is served
+type API struct {
+	REST    deps.IRESTGetter
+	Locator deps.IResourceLocator
+}
+
+func NewAPI(r deps.IRESTGetter, l deps.IResourceLocator) *API {
+	return &API{
+		REST:    r,
+		Locator: l,
+	}
+}
+
+func (a *API) List(kind, namespace string, opts api.ListOptions) (runtime.Object, error) {
+	p, err := a.Locator.Collection(kind, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := a.REST.GetRaw(p, params(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	list := &WorkloadList{}
+	if err := json.Unmarshal(raw, list); err != nil {
+		return nil, fmt.Errorf("Unable to read %s list: %v", kind, err.Error())
+	}
+	return list, nil
+}
+
+func (a *API) Watch(kind, namespace string, opts api.ListOptions) (watch.Interface, error) {
+	p, err := a.Locator.Collection(kind, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := params(opts)
+	ps["watch"] = "true"
+	body, err := a.REST.StreamRaw(p, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch.NewStreamWatcher(&decoder{
+		body:    body,
+		decoder: json.NewDecoder(body),
+	}), nil
+}
+
+func params(opts api.ListOptions) map[string]string {
+	p := make(map[string]string)
+	if opts.ResourceVersion != "" {
+		p["resourceVersion"] = opts.ResourceVersion
+	}
+	return p
+}
+
+// Decodes the JSON stream of a watch into workloads, or statuses for errors
+type decoder struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+}
+
+func (d *decoder) Decode() (watch.EventType, runtime.Object, error) {
+	var we struct {
+		Type   watch.EventType `json:"type"`
+		Object json.RawMessage `json:"object"`
+	}
+	if err := d.decoder.Decode(&we); err != nil {
+		return "", nil, err
+	}
+
+	var obj runtime.Object = &Workload{}
+	if we.Type == watch.Error {
+		obj = &unversioned.Status{}
+	}
+	if err := json.Unmarshal(we.Object, obj); err != nil {
+		return "", nil, fmt.Errorf("Unable to read %s watch event: %v", we.Type, err.Error())
+	}
+	return we.Type, obj, nil
+}
+
+func (d *decoder) Close() {
+	d.body.Close()
+}
@@ -0,0 +1,355 @@
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/util/clock"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/informer"
+	notifiers "github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+const (
+	REASON_STARTED   = "RolloutStarted"
+	REASON_COMPLETED = "RolloutCompleted"
+	REASON_STALLED   = "RolloutStalled"
+
+	COMPONENT = "kit-overwatch"
+
+	ANNOTATION_CHANGE_CAUSE = "kubernetes.io/change-cause"
+	ANNOTATION_REVISION     = "deployment.kubernetes.io/revision"
+
+	// StatefulSets and DaemonSets with this strategy only update pods as they are deleted
+	STRATEGY_ON_DELETE = "OnDelete"
+)
+
+// The kinds of workload rolled out, each only followed if the cluster serves it.
+// PetSets aren't, as they never update their pods.
+var Kinds = []string{"Deployment", "StatefulSet", "DaemonSet"}
+
+// What we last saw of a workload
+type workload struct {
+	template string
+	images   map[string]string
+	rollout  *notifiers.Rollout
+	stalled  bool
+}
+
+// Monitor watches the workloads of one kind in a namespace (or all of them) and
+// emits a synthetic event on Deltas when a rollout of a new pod template starts,
+// completes or, for Deployments, stalls past its progress deadline. Workloads
+// listed when it starts and those created later are taken as they are; a rollout
+// already under way is still followed to completion.
+//
+// Synthetic events about the same workload and reason share a UID, and are counted
+// by the workload's generation, so they are deduplicated like any other event.
+type Monitor struct {
+	Lister    deps.IObjectLister
+	Kind      string
+	Namespace string
+	Deltas    chan informer.Delta
+	Clock     clock.Clock
+
+	reflector *informer.Reflector
+	lock      sync.Mutex
+	workloads map[string]*workload
+}
+
+func New(l deps.IObjectLister, kind, namespace string) *Monitor {
+	m := &Monitor{
+		Lister:    l,
+		Kind:      kind,
+		Namespace: namespace,
+		Deltas:    make(chan informer.Delta, informer.DELTA_BUFFER),
+		Clock:     clock.RealClock{},
+		workloads: make(map[string]*workload),
+	}
+	m.reflector = &informer.Reflector{
+		Lister:  informer.ForKind(l, kind, namespace),
+		Handler: m,
+		Name:    kind + " rollouts",
+		Stop:    m.unsupported,
+	}
+	return m
+}
+
+// Run watches workloads until the context is done or the cluster doesn't serve
+// the kind, then closes Deltas
+func (m *Monitor) Run(ctx context.Context) {
+	defer close(m.Deltas)
+	m.reflector.Run(ctx)
+}
+
+// Replace is given the listed workloads; those no longer listed were deleted meanwhile
+func (m *Monitor) Replace(ctx context.Context, items []runtime.Object) {
+	listed := make(map[string]bool, len(items))
+	for _, item := range items {
+		wl, ok := item.(*Workload)
+		if !ok {
+			log.Warnf("Skip: unexpected object in %s list: %T", m.Kind, item)
+			continue
+		}
+		listed[key(wl.ObjectMeta.Namespace, wl.ObjectMeta.Name)] = true
+		m.observe(ctx, wl)
+	}
+
+	m.lock.Lock()
+	for k := range m.workloads {
+		if !listed[k] {
+			delete(m.workloads, k)
+		}
+	}
+	m.lock.Unlock()
+
+	log.Debugf("Following the rollouts of %d %s objects in namespace '%s'", len(listed), m.Kind, m.Namespace)
+}
+
+// Update is given each watched change to a workload
+func (m *Monitor) Update(ctx context.Context, t watch.EventType, obj runtime.Object) {
+	wl, ok := obj.(*Workload)
+	if !ok {
+		log.Warnf("Skip: unexpected object in %s watch: %T", m.Kind, obj)
+		return
+	}
+
+	if t == watch.Deleted {
+		m.forget(wl)
+	} else {
+		m.observe(ctx, wl)
+	}
+}
+
+// Stops following rollouts of a kind the cluster doesn't serve
+func (m *Monitor) unsupported(err error) bool {
+	if _, ok := err.(owners.UnsupportedKind); !ok {
+		return false
+	}
+	log.Infof("Not following %s rollouts, the cluster doesn't serve them", m.Kind)
+	return true
+}
+
+// Compares the workload with what we last saw of it, emitting an event for each step its rollout took
+func (m *Monitor) observe(ctx context.Context, wl *Workload) {
+	now := m.Clock.Now()
+	k := key(wl.ObjectMeta.Namespace, wl.ObjectMeta.Name)
+
+	var deltas []informer.Delta
+	m.lock.Lock()
+	prev, known := m.workloads[k]
+	cur := &workload{
+		template: string(wl.Spec.Template),
+		images:   images(wl.Spec.Template),
+	}
+	m.workloads[k] = cur
+
+	switch {
+	case !known:
+		// Follow a rollout already under way, without knowing when it started
+		if !m.complete(wl) {
+			cur.rollout = &notifiers.Rollout{}
+		}
+	case cur.template != prev.template && m.rolling(wl):
+		// A new template supersedes any rollout still under way
+		cur.rollout = &notifiers.Rollout{
+			Images:      diff(prev.images, cur.images),
+			ChangeCause: wl.ObjectMeta.Annotations[ANNOTATION_CHANGE_CAUSE],
+			Revision:    wl.ObjectMeta.Annotations[ANNOTATION_REVISION],
+			Started:     now,
+		}
+		deltas = append(deltas, m.delta(wl, REASON_STARTED, cur.rollout, ""))
+	default:
+		cur.rollout = prev.rollout
+		cur.stalled = prev.stalled
+	}
+
+	if r := cur.rollout; r != nil {
+		if m.complete(wl) {
+			completed := *r
+			if !r.Started.IsZero() {
+				completed.Duration = now.Sub(r.Started)
+			}
+			completed.Revision = wl.ObjectMeta.Annotations[ANNOTATION_REVISION]
+			deltas = append(deltas, m.delta(wl, REASON_COMPLETED, &completed, ""))
+			cur.rollout = nil
+			cur.stalled = false
+		} else if c := stalled(wl); c != nil && !cur.stalled {
+			deltas = append(deltas, m.delta(wl, REASON_STALLED, r, c.Message))
+			cur.stalled = true
+		}
+	}
+	m.lock.Unlock()
+
+	for _, d := range deltas {
+		log.Debugf("%s %s: %s", m.Kind, wl.ObjectMeta.Name, d.Event.Reason)
+		select {
+		case m.Deltas <- d:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Monitor) forget(wl *Workload) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.workloads, key(wl.ObjectMeta.Namespace, wl.ObjectMeta.Name))
+}
+
+// Reports whether a change to the workload's template is rolled out to its pods
+func (m *Monitor) rolling(wl *Workload) bool {
+	return m.Kind == "Deployment" || wl.Spec.UpdateStrategy.Type != STRATEGY_ON_DELETE
+}
+
+// Reports whether every pod runs the latest template and is available, the way
+// kubectl rollout status does. Until the controller has seen the latest
+// generation its status is about an earlier one.
+func (m *Monitor) complete(wl *Workload) bool {
+	s := wl.Status
+	if s.ObservedGeneration < wl.ObjectMeta.Generation {
+		return false
+	}
+
+	replicas := int32(1)
+	if wl.Spec.Replicas != nil {
+		replicas = *wl.Spec.Replicas
+	}
+
+	switch m.Kind {
+	case "Deployment":
+		return s.UpdatedReplicas == replicas && s.Replicas == s.UpdatedReplicas && s.AvailableReplicas == s.UpdatedReplicas
+	case "StatefulSet":
+		return s.UpdateRevision == s.CurrentRevision && s.ReadyReplicas == replicas
+	case "DaemonSet":
+		return s.UpdatedNumberScheduled == s.DesiredNumberScheduled && s.NumberAvailable == s.DesiredNumberScheduled
+	}
+	return true
+}
+
+// Returns the condition saying a Deployment's rollout has exceeded its progress deadline, if it has
+func stalled(wl *Workload) *Condition {
+	for i, c := range wl.Status.Conditions {
+		if c.Type == "Progressing" && c.Status == string(api.ConditionFalse) && c.Reason == "ProgressDeadlineExceeded" {
+			return &wl.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// Builds the synthetic event about a step of a workload's rollout
+func (m *Monitor) delta(wl *Workload, reason string, r *notifiers.Rollout, detail string) informer.Delta {
+	eventType := api.EventTypeNormal
+	if reason == REASON_STALLED {
+		eventType = api.EventTypeWarning
+	}
+
+	now := unversioned.NewTime(m.Clock.Now())
+	e := api.Event{
+		ObjectMeta: api.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%s", wl.ObjectMeta.Name, strings.ToLower(reason)),
+			Namespace: wl.ObjectMeta.Namespace,
+			UID:       types.UID(fmt.Sprintf("%s/%s/%s", m.Kind, key(wl.ObjectMeta.Namespace, wl.ObjectMeta.Name), reason)),
+		},
+		InvolvedObject: api.ObjectReference{
+			Kind:            m.Kind,
+			Namespace:       wl.ObjectMeta.Namespace,
+			Name:            wl.ObjectMeta.Name,
+			UID:             wl.ObjectMeta.UID,
+			APIVersion:      wl.APIVersion,
+			ResourceVersion: wl.ObjectMeta.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        message(m.Kind, wl.ObjectMeta.Name, reason, r, detail),
+		Source:         api.EventSource{Component: COMPONENT},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          int32(wl.ObjectMeta.Generation),
+		Type:           eventType,
+	}
+
+	return informer.Delta{Type: watch.Added, Event: e, Rollout: r}
+}
+
+// Describes a step of a rollout, eg. Rollout of Deployment web started: web nginx:1.10 -> nginx:1.11 (kubectl set image ...)
+func message(kind, name, reason string, r *notifiers.Rollout, detail string) string {
+	msg := fmt.Sprintf("Rollout of %s %s", kind, name)
+	switch reason {
+	case REASON_STARTED:
+		changes := make([]string, len(r.Images))
+		for i, c := range r.Images {
+			from := c.From
+			if from == "" {
+				from = "(new)"
+			}
+			changes[i] = fmt.Sprintf("%s %s -> %s", c.Container, from, c.To)
+		}
+		if len(changes) == 0 {
+			changes = []string{"the pod template changed"}
+		}
+		msg = fmt.Sprintf("%s started: %s", msg, strings.Join(changes, ", "))
+		if r.ChangeCause != "" {
+			msg = fmt.Sprintf("%s (%s)", msg, r.ChangeCause)
+		}
+	case REASON_COMPLETED:
+		msg += " completed"
+		if r.Duration != 0 {
+			msg = fmt.Sprintf("%s after %v", msg, r.Duration)
+		}
+	case REASON_STALLED:
+		msg += " has stalled"
+		if detail != "" {
+			msg = fmt.Sprintf("%s: %s", msg, detail)
+		}
+	}
+	return msg
+}
+
+// The image of each container in a pod template
+func images(raw json.RawMessage) map[string]string {
+	var t template
+	if err := json.Unmarshal(raw, &t); err != nil {
+		log.Debugf("Unable to read pod template: %v", err.Error())
+		return nil
+	}
+
+	images := make(map[string]string)
+	for _, c := range append(t.Spec.InitContainers, t.Spec.Containers...) {
+		images[c.Name] = c.Image
+	}
+	return images
+}
+
+// The containers whose image changed or that were added, by name
+func diff(old, new map[string]string) []notifiers.ImageChange {
+	var changes []notifiers.ImageChange
+	for name, image := range new {
+		if old[name] != image {
+			changes = append(changes, notifiers.ImageChange{Container: name, From: old[name], To: image})
+		}
+	}
+	sort.Sort(byContainer(changes))
+	return changes
+}
+
+type byContainer []notifiers.ImageChange
+
+func (c byContainer) Len() int           { return len(c) }
+func (c byContainer) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c byContainer) Less(i, j int) bool { return c[i].Container < c[j].Container }
+
+func key(namespace, name string) string {
+	return namespace + "/" + name
+}
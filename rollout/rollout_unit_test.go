@@ -0,0 +1,431 @@
+// +build unit
+
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/util/clock"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/informer"
+	notifiers "github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/owners"
+)
+
+func podTemplate(images ...string) json.RawMessage {
+	var containers []string
+	for i, image := range images {
+		containers = append(containers, fmt.Sprintf(`{"name":"c%d","image":"%s"}`, i, image))
+	}
+	return json.RawMessage(fmt.Sprintf(`{"spec":{"containers":[%s]}}`, strings.Join(containers, ",")))
+}
+
+func int32p(i int32) *int32 {
+	return &i
+}
+
+// A Deployment of 3 replicas that has finished rolling out its template
+func newDeployment(generation int64, images ...string) *Workload {
+	return &Workload{
+		ObjectMeta: api.ObjectMeta{
+			Name:        "web",
+			Namespace:   "default",
+			UID:         types.UID("uid-1"),
+			Generation:  generation,
+			Annotations: map[string]string{ANNOTATION_REVISION: fmt.Sprintf("%d", generation)},
+		},
+		Spec: WorkloadSpec{
+			Replicas: int32p(3),
+			Template: podTemplate(images...),
+		},
+		Status: WorkloadStatus{
+			ObservedGeneration: generation,
+			Replicas:           3,
+			UpdatedReplicas:    3,
+			AvailableReplicas:  3,
+		},
+	}
+}
+
+// The same Deployment part way through rolling out
+func rolling(wl *Workload) *Workload {
+	wl.Status.Replicas = 4
+	wl.Status.UpdatedReplicas = 1
+	return wl
+}
+
+var _ = Describe("API", func() {
+	var (
+		fakeREST    *depsfakes.FakeIRESTGetter
+		fakeLocator *depsfakes.FakeIResourceLocator
+		a           *API
+	)
+
+	BeforeEach(func() {
+		fakeREST = &depsfakes.FakeIRESTGetter{}
+		fakeLocator = &depsfakes.FakeIResourceLocator{}
+		fakeLocator.CollectionReturns("/apis/apps/v1/namespaces/default/deployments", nil)
+		a = NewAPI(fakeREST, fakeLocator)
+	})
+
+	It("should list workloads where the kind is served", func() {
+		fakeREST.GetRawReturns([]byte(`{"metadata":{"resourceVersion":"42"},"items":[{"metadata":{"name":"web","generation":2},"spec":{"replicas":3},"status":{"observedGeneration":2,"conditions":[{"type":"Progressing","status":"True"}]}}]}`), nil)
+
+		list, err := a.List("Deployment", "default", api.ListOptions{ResourceVersion: "0"})
+		Expect(err).To(BeNil())
+		Expect(list.(*WorkloadList).ResourceVersion).To(Equal("42"))
+		wl := list.(*WorkloadList).Items[0]
+		Expect(wl.ObjectMeta.Name).To(Equal("web"))
+		Expect(*wl.Spec.Replicas).To(Equal(int32(3)))
+		Expect(wl.Status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(wl.Status.Conditions).To(HaveLen(1))
+
+		kind, namespace := fakeLocator.CollectionArgsForCall(0)
+		Expect(kind).To(Equal("Deployment"))
+		Expect(namespace).To(Equal("default"))
+
+		path, params := fakeREST.GetRawArgsForCall(0)
+		Expect(path).To(Equal("/apis/apps/v1/namespaces/default/deployments"))
+		Expect(params).To(Equal(map[string]string{"resourceVersion": "0"}))
+	})
+
+	It("should return an error for kinds the cluster doesn't serve", func() {
+		fakeLocator.CollectionReturns("", owners.UnsupportedKind{Kind: "StatefulSet"})
+
+		_, err := a.List("StatefulSet", "default", api.ListOptions{})
+		Expect(err).To(Equal(owners.UnsupportedKind{Kind: "StatefulSet"}))
+		Expect(fakeREST.GetRawCallCount()).To(Equal(0))
+	})
+
+	It("should decode watched workloads and errors", func() {
+		stream := `{"type":"MODIFIED","object":{"metadata":{"name":"web","resourceVersion":"43"},"status":{"updatedReplicas":1}}}
+{"type":"ERROR","object":{"kind":"Status","code":410,"reason":"Gone"}}
+`
+		fakeREST.StreamRawStub = func(string, map[string]string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(stream)), nil
+		}
+
+		wi, err := a.Watch("Deployment", "default", api.ListOptions{ResourceVersion: "42"})
+		Expect(err).To(BeNil())
+		defer wi.Stop()
+
+		path, params := fakeREST.StreamRawArgsForCall(0)
+		Expect(path).To(Equal("/apis/apps/v1/namespaces/default/deployments"))
+		Expect(params).To(Equal(map[string]string{"resourceVersion": "42", "watch": "true"}))
+
+		var we watch.Event
+		Eventually(wi.ResultChan()).Should(Receive(&we))
+		Expect(we.Type).To(Equal(watch.Modified))
+		Expect(we.Object.(*Workload).Status.UpdatedReplicas).To(Equal(int32(1)))
+
+		Eventually(wi.ResultChan()).Should(Receive(&we))
+		Expect(we.Type).To(Equal(watch.Error))
+		Expect(we.Object.(*unversioned.Status).Code).To(Equal(int32(410)))
+	})
+})
+
+var _ = Describe("Monitor", func() {
+	var (
+		fakeLister *depsfakes.FakeIObjectLister
+		fakeWatch  *watch.FakeWatcher
+		clk        *clock.FakeClock
+		now        time.Time
+		m          *Monitor
+		ctx        context.Context
+		cancel     context.CancelFunc
+	)
+
+	// Returns the deltas emitted so far
+	emitted := func() []informer.Delta {
+		var deltas []informer.Delta
+		for {
+			select {
+			case d := <-m.Deltas:
+				deltas = append(deltas, d)
+			default:
+				return deltas
+			}
+		}
+	}
+
+	reasons := func(deltas []informer.Delta) []string {
+		var reasons []string
+		for _, d := range deltas {
+			reasons = append(reasons, d.Event.Reason)
+		}
+		return reasons
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		now = time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC)
+		clk = clock.NewFakeClock(now)
+
+		fakeWatch = watch.NewFake()
+		fakeLister = &depsfakes.FakeIObjectLister{}
+		fakeLister.ListReturns(&WorkloadList{ListMeta: unversioned.ListMeta{ResourceVersion: "1"}}, nil)
+		fakeLister.WatchReturns(fakeWatch, nil)
+
+		m = New(fakeLister, "Deployment", "default")
+		m.Clock = clk
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should emit the start of a rollout with the images it changes", func() {
+		m.observe(ctx, newDeployment(1, "nginx:1.10", "sidecar:1"))
+		Expect(emitted()).To(BeEmpty())
+
+		wl := rolling(newDeployment(2, "nginx:1.11", "sidecar:1"))
+		wl.ObjectMeta.Annotations[ANNOTATION_CHANGE_CAUSE] = "kubectl set image deployment/web c0=nginx:1.11"
+		m.observe(ctx, wl)
+
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_STARTED}))
+
+		d := deltas[0]
+		Expect(d.Type).To(Equal(watch.Added))
+		Expect(d.Rollout).To(Equal(&notifiers.Rollout{
+			Images:      []notifiers.ImageChange{{Container: "c0", From: "nginx:1.10", To: "nginx:1.11"}},
+			ChangeCause: "kubectl set image deployment/web c0=nginx:1.11",
+			Revision:    "2",
+			Started:     now,
+		}))
+
+		e := d.Event
+		Expect(string(e.ObjectMeta.UID)).To(Equal("Deployment/default/web/RolloutStarted"))
+		Expect(e.ObjectMeta.Namespace).To(Equal("default"))
+		Expect(e.InvolvedObject.Kind).To(Equal("Deployment"))
+		Expect(e.InvolvedObject.Name).To(Equal("web"))
+		Expect(string(e.InvolvedObject.UID)).To(Equal("uid-1"))
+		Expect(e.Source).To(Equal(api.EventSource{Component: COMPONENT}))
+		Expect(e.Type).To(Equal(api.EventTypeNormal))
+		Expect(e.Count).To(Equal(int32(2)))
+		Expect(e.LastTimestamp.Time).To(Equal(now))
+		Expect(e.Message).To(Equal("Rollout of Deployment web started: c0 nginx:1.10 -> nginx:1.11 (kubectl set image deployment/web c0=nginx:1.11)"))
+	})
+
+	It("should emit the completion of a rollout with how long it took", func() {
+		m.observe(ctx, newDeployment(1, "nginx:1.10"))
+		m.observe(ctx, rolling(newDeployment(2, "nginx:1.11")))
+		emitted()
+
+		clk.Step(150 * time.Second)
+		m.observe(ctx, rolling(newDeployment(2, "nginx:1.11")))
+		Expect(emitted()).To(BeEmpty())
+
+		m.observe(ctx, newDeployment(2, "nginx:1.11"))
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_COMPLETED}))
+		Expect(deltas[0].Rollout.Duration).To(Equal(150 * time.Second))
+		Expect(deltas[0].Rollout.Images).To(Equal([]notifiers.ImageChange{{Container: "c0", From: "nginx:1.10", To: "nginx:1.11"}}))
+		Expect(string(deltas[0].Event.ObjectMeta.UID)).To(Equal("Deployment/default/web/RolloutCompleted"))
+		Expect(deltas[0].Event.Message).To(Equal("Rollout of Deployment web completed after 2m30s"))
+
+		m.observe(ctx, newDeployment(2, "nginx:1.11"))
+		Expect(emitted()).To(BeEmpty())
+	})
+
+	It("should emit a rollout that completes at once", func() {
+		m.observe(ctx, newDeployment(1, "nginx:1.10"))
+		m.observe(ctx, newDeployment(2, "nginx:1.11"))
+
+		Expect(reasons(emitted())).To(Equal([]string{REASON_STARTED, REASON_COMPLETED}))
+	})
+
+	It("should not trust the status until the controller has observed the latest generation", func() {
+		m.observe(ctx, newDeployment(1, "nginx:1.10"))
+
+		wl := newDeployment(2, "nginx:1.11")
+		wl.Status.ObservedGeneration = 1
+		m.observe(ctx, wl)
+
+		Expect(reasons(emitted())).To(Equal([]string{REASON_STARTED}))
+	})
+
+	It("should not emit anything when only the replicas change", func() {
+		m.observe(ctx, newDeployment(1, "nginx:1.10"))
+
+		wl := newDeployment(2, "nginx:1.10")
+		wl.Spec.Replicas = int32p(5)
+		wl.Status.UpdatedReplicas = 3
+		m.observe(ctx, wl)
+		wl.Status.Replicas, wl.Status.UpdatedReplicas, wl.Status.AvailableReplicas = 5, 5, 5
+		m.observe(ctx, wl)
+
+		Expect(emitted()).To(BeEmpty())
+	})
+
+	It("should emit a stalled rollout once, with why", func() {
+		m.observe(ctx, newDeployment(1, "nginx:1.10"))
+		m.observe(ctx, rolling(newDeployment(2, "nginx:1.11")))
+		emitted()
+
+		wl := rolling(newDeployment(2, "nginx:1.11"))
+		wl.Status.Conditions = []Condition{{
+			Type:    "Progressing",
+			Status:  "False",
+			Reason:  "ProgressDeadlineExceeded",
+			Message: `ReplicaSet "web-2" has timed out progressing.`,
+		}}
+		m.observe(ctx, wl)
+		m.observe(ctx, wl)
+
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_STALLED}))
+		Expect(deltas[0].Event.Type).To(Equal(api.EventTypeWarning))
+		Expect(deltas[0].Event.Message).To(Equal(`Rollout of Deployment web has stalled: ReplicaSet "web-2" has timed out progressing.`))
+
+		m.observe(ctx, newDeployment(2, "nginx:1.11"))
+		Expect(reasons(emitted())).To(Equal([]string{REASON_COMPLETED}))
+	})
+
+	It("should follow a rollout already under way when first seen", func() {
+		m.observe(ctx, rolling(newDeployment(2, "nginx:1.11")))
+		Expect(emitted()).To(BeEmpty())
+
+		m.observe(ctx, newDeployment(2, "nginx:1.11"))
+		deltas := emitted()
+		Expect(reasons(deltas)).To(Equal([]string{REASON_COMPLETED}))
+		Expect(deltas[0].Rollout.Duration).To(BeZero())
+		Expect(deltas[0].Event.Message).To(Equal("Rollout of Deployment web completed"))
+	})
+
+	It("should follow StatefulSets by their revisions", func() {
+		m.Kind = "StatefulSet"
+		wl := newDeployment(1, "mysql:5.6")
+		wl.Status = WorkloadStatus{ObservedGeneration: 1, Replicas: 3, ReadyReplicas: 3, CurrentRevision: "db-1", UpdateRevision: "db-1"}
+		m.observe(ctx, wl)
+
+		wl = newDeployment(2, "mysql:5.7")
+		wl.Status = WorkloadStatus{ObservedGeneration: 2, Replicas: 3, ReadyReplicas: 3, CurrentRevision: "db-1", UpdateRevision: "db-2"}
+		m.observe(ctx, wl)
+		Expect(reasons(emitted())).To(Equal([]string{REASON_STARTED}))
+
+		wl.Status.CurrentRevision = "db-2"
+		m.observe(ctx, wl)
+		Expect(reasons(emitted())).To(Equal([]string{REASON_COMPLETED}))
+	})
+
+	It("should follow DaemonSets by the pods scheduled", func() {
+		m.Kind = "DaemonSet"
+		wl := newDeployment(1, "fluentd:1")
+		wl.Status = WorkloadStatus{ObservedGeneration: 1, DesiredNumberScheduled: 2, UpdatedNumberScheduled: 2, NumberAvailable: 2}
+		m.observe(ctx, wl)
+
+		wl = newDeployment(2, "fluentd:2")
+		wl.Status = WorkloadStatus{ObservedGeneration: 2, DesiredNumberScheduled: 2, UpdatedNumberScheduled: 1, NumberAvailable: 2}
+		m.observe(ctx, wl)
+		Expect(reasons(emitted())).To(Equal([]string{REASON_STARTED}))
+
+		wl.Status.UpdatedNumberScheduled = 2
+		m.observe(ctx, wl)
+		Expect(reasons(emitted())).To(Equal([]string{REASON_COMPLETED}))
+	})
+
+	It("should not emit template changes that are only rolled out as pods are deleted", func() {
+		m.Kind = "DaemonSet"
+		wl := newDeployment(1, "fluentd:1")
+		wl.Spec.UpdateStrategy.Type = STRATEGY_ON_DELETE
+		m.observe(ctx, wl)
+
+		wl = newDeployment(2, "fluentd:2")
+		wl.Spec.UpdateStrategy.Type = STRATEGY_ON_DELETE
+		m.observe(ctx, wl)
+
+		Expect(emitted()).To(BeEmpty())
+	})
+
+	Context("when running", func() {
+		BeforeEach(func() {
+			fakeLister.ListReturns(&WorkloadList{
+				ListMeta: unversioned.ListMeta{ResourceVersion: "1"},
+				Items:    []Workload{*newDeployment(1, "nginx:1.10")},
+			}, nil)
+		})
+
+		It("should list then watch the kind in its namespace", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			kind, namespace, opts := fakeLister.ListArgsForCall(0)
+			Expect(kind).To(Equal("Deployment"))
+			Expect(namespace).To(Equal("default"))
+			Expect(opts.ResourceVersion).To(Equal("0"))
+
+			kind, namespace, opts = fakeLister.WatchArgsForCall(0)
+			Expect(kind).To(Equal("Deployment"))
+			Expect(namespace).To(Equal("default"))
+			Expect(opts.ResourceVersion).To(Equal("1"))
+		})
+
+		It("should emit rollouts of watched workloads", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			fakeWatch.Modify(rolling(newDeployment(2, "nginx:1.11")))
+
+			var d informer.Delta
+			Eventually(m.Deltas).Should(Receive(&d))
+			Expect(d.Event.Reason).To(Equal(REASON_STARTED))
+		})
+
+		It("should forget deleted workloads", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			fakeWatch.Delete(newDeployment(1, "nginx:1.10"))
+			Eventually(func() int {
+				m.lock.Lock()
+				defer m.lock.Unlock()
+				return len(m.workloads)
+			}).Should(Equal(0))
+		})
+
+		It("should forget workloads that are no longer listed when relisting", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			fakeLister.ListReturns(&WorkloadList{ListMeta: unversioned.ListMeta{ResourceVersion: "2"}}, nil)
+			fakeWatch.Error(&errors.NewGone("too old resource version").ErrStatus)
+			Eventually(fakeLister.ListCallCount).Should(Equal(2))
+			Eventually(func() int {
+				m.lock.Lock()
+				defer m.lock.Unlock()
+				return len(m.workloads)
+			}).Should(Equal(0))
+		})
+
+		It("should stop and close Deltas when the cluster doesn't serve the kind", func() {
+			fakeLister.ListReturns(nil, owners.UnsupportedKind{Kind: "Deployment"})
+
+			go m.Run(ctx)
+			Eventually(m.Deltas).Should(BeClosed())
+			Expect(fakeLister.WatchCallCount()).To(Equal(0))
+		})
+
+		It("should close Deltas once stopped", func() {
+			go m.Run(ctx)
+			Eventually(fakeLister.WatchCallCount).Should(Equal(1))
+
+			cancel()
+			Eventually(m.Deltas).Should(BeClosed())
+		})
+	})
+})
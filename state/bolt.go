@@ -0,0 +1,304 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+)
+
+const (
+	BOLT_OPEN_TIMEOUT = time.Second
+)
+
+var (
+	eventsBucket      = []byte("events")
+	seenBucket        = []byte("seen")
+	expiryBucket      = []byte("expiry")
+	metaBucket        = []byte("meta")
+	checkpointsBucket = []byte("checkpoints")
+	countKey          = []byte("count")
+)
+
+// Bolt is a StateStore persisted to a single file on disk. Like Memory, entries
+// expire once the event has expired in the cluster and at most MaxEvents are kept.
+// Besides the state by uid it keeps when each uid was last seen, an index of uids
+// ordered by that time so the oldest can be found without reading them all, how
+// many there are and each cluster's checkpoint.
+type Bolt struct {
+	DB        *bolt.DB
+	MaxEvents int
+	EventTTL  time.Duration
+	Overflow  string
+	Clock     clock.Clock
+}
+
+// A zero MaxEvents or EventTTL disables the cap or the expiry
+func NewBolt(cfg *config.Config) (*Bolt, error) {
+	db, err := bolt.Open(cfg.StateStorePath, 0600, &bolt.Options{
+		Timeout: BOLT_OPEN_TIMEOUT,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open bolt state store '%s': %v", cfg.StateStorePath, err.Error())
+	}
+
+	b := &Bolt{
+		DB:        db,
+		MaxEvents: cfg.StateStoreMaxEvents,
+		EventTTL:  time.Duration(cfg.EventTTL) * time.Second,
+		Overflow:  cfg.StateStoreOverflow,
+		Clock:     clock.RealClock{},
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{eventsBucket, seenBucket, expiryBucket, metaBucket, checkpointsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return b.index(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Unable to create bolt state store buckets: %v", err.Error())
+	}
+
+	return b, nil
+}
+
+func (b *Bolt) Get(uid string) (*EventState, error) {
+	var s *EventState
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(eventsBucket).Get([]byte(uid))
+		if raw == nil || b.expired(tx, uid) {
+			return nil
+		}
+
+		s = &EventState{}
+		return json.Unmarshal(raw, s)
+	})
+
+	return s, err
+}
+
+func (b *Bolt) CompareAndSet(uid string, old, new *EventState) (bool, error) {
+	raw, err := json.Marshal(new)
+	if err != nil {
+		return false, err
+	}
+
+	// Bolt only allows one read-write transaction at a time, so the compare and the put are atomic
+	swapped := false
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		if err := b.expire(tx); err != nil {
+			return err
+		}
+		bucket := tx.Bucket(eventsBucket)
+
+		var current *EventState
+		stored := bucket.Get([]byte(uid))
+		if stored != nil {
+			current = &EventState{}
+			if err := json.Unmarshal(stored, current); err != nil {
+				return err
+			}
+		}
+		if !current.Equal(old) {
+			return nil
+		}
+
+		if stored == nil && b.MaxEvents > 0 && b.count(tx) >= b.MaxEvents {
+			if b.Overflow == OVERFLOW_REJECT {
+				return ErrStoreFull
+			}
+			k, _ := tx.Bucket(expiryBucket).Cursor().First()
+			if k != nil {
+				if err := b.remove(tx, string(k[8:])); err != nil {
+					return err
+				}
+			}
+		}
+
+		seen := new.LastTimestamp
+		if seen.IsZero() {
+			seen = b.Clock.Now()
+		}
+		if err := b.see(tx, uid, seen); err != nil {
+			return err
+		}
+
+		swapped = true
+		return bucket.Put([]byte(uid), raw)
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return swapped, nil
+}
+
+func (b *Bolt) Checkpoint(cluster string) (time.Time, error) {
+	var t time.Time
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(checkpointsBucket).Get([]byte(cluster))
+		if raw == nil {
+			return nil
+		}
+		return t.UnmarshalText(raw)
+	})
+
+	return t, err
+}
+
+func (b *Bolt) SetCheckpoint(cluster string, t time.Time) error {
+	raw, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(checkpointsBucket).Put([]byte(cluster), raw)
+	})
+}
+
+func (b *Bolt) Len() (int, error) {
+	var n int
+	err := b.DB.Update(func(tx *bolt.Tx) error {
+		if err := b.expire(tx); err != nil {
+			return err
+		}
+		n = b.count(tx)
+		return nil
+	})
+
+	return n, err
+}
+
+func (b *Bolt) Close() error {
+	return b.DB.Close()
+}
+
+// Records when a uid was last seen, moving it in the expiry index
+func (b *Bolt) see(tx *bolt.Tx, uid string, seen time.Time) error {
+	seenB, expiry := tx.Bucket(seenBucket), tx.Bucket(expiryBucket)
+	if prev := seenB.Get([]byte(uid)); prev != nil {
+		if err := expiry.Delete(expiryKey(uid, prev)); err != nil {
+			return err
+		}
+	} else if err := b.setCount(tx, b.count(tx)+1); err != nil {
+		return err
+	}
+
+	at := timeKey(seen)
+	if err := seenB.Put([]byte(uid), at); err != nil {
+		return err
+	}
+	return expiry.Put(expiryKey(uid, at), nil)
+}
+
+// Drops entries for events that have expired in the cluster, oldest first
+func (b *Bolt) expire(tx *bolt.Tx) error {
+	if b.EventTTL <= 0 {
+		return nil
+	}
+
+	cutoff := expiryKey("", timeKey(b.Clock.Now().Add(-b.EventTTL)))
+	c := tx.Bucket(expiryBucket).Cursor()
+	for k, _ := c.First(); k != nil && bytes.Compare(k, cutoff) < 0; k, _ = c.First() {
+		if err := b.remove(tx, string(k[8:])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reports whether a uid was last seen before the event would have expired in the cluster
+func (b *Bolt) expired(tx *bolt.Tx, uid string) bool {
+	if b.EventTTL <= 0 {
+		return false
+	}
+	at := tx.Bucket(seenBucket).Get([]byte(uid))
+	return at != nil && bytes.Compare(at, timeKey(b.Clock.Now().Add(-b.EventTTL))) < 0
+}
+
+func (b *Bolt) remove(tx *bolt.Tx, uid string) error {
+	log.Debugf("Evicting state for %s", uid)
+
+	if at := tx.Bucket(seenBucket).Get([]byte(uid)); at != nil {
+		if err := tx.Bucket(expiryBucket).Delete(expiryKey(uid, at)); err != nil {
+			return err
+		}
+		if err := b.setCount(tx, b.count(tx)-1); err != nil {
+			return err
+		}
+	}
+	if err := tx.Bucket(seenBucket).Delete([]byte(uid)); err != nil {
+		return err
+	}
+	return tx.Bucket(eventsBucket).Delete([]byte(uid))
+}
+
+// Indexes state stored before entries expired, as last seen at its LastTimestamp,
+// and counts it
+func (b *Bolt) index(tx *bolt.Tx) error {
+	n := 0
+	tx.Bucket(expiryBucket).ForEach(func(k, v []byte) error {
+		n++
+		return nil
+	})
+	if err := b.setCount(tx, n); err != nil {
+		return err
+	}
+
+	seenB := tx.Bucket(seenBucket)
+	return tx.Bucket(eventsBucket).ForEach(func(k, v []byte) error {
+		if seenB.Get(k) != nil {
+			return nil
+		}
+
+		s := &EventState{}
+		if err := json.Unmarshal(v, s); err != nil {
+			return err
+		}
+		seen := s.LastTimestamp
+		if seen.IsZero() {
+			seen = b.Clock.Now()
+		}
+		return b.see(tx, string(k), seen)
+	})
+}
+
+// The number of uids state is kept for. Bucket stats don't count what the
+// transaction has changed, so it is kept alongside.
+func (b *Bolt) count(tx *bolt.Tx) int {
+	raw := tx.Bucket(metaBucket).Get(countKey)
+	if raw == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(raw))
+}
+
+func (b *Bolt) setCount(tx *bolt.Tx, n int) error {
+	raw := make([]byte, 8)
+	binary.BigEndian.PutUint64(raw, uint64(n))
+	return tx.Bucket(metaBucket).Put(countKey, raw)
+}
+
+// Sorts like the time it encodes
+func timeKey(t time.Time) []byte {
+	at := make([]byte, 8)
+	binary.BigEndian.PutUint64(at, uint64(t.UnixNano()))
+	return at
+}
+
+// Orders the expiry index by when each uid was last seen
+func expiryKey(uid string, at []byte) []byte {
+	return append(append([]byte{}, at...), uid...)
+}
@@ -0,0 +1,162 @@
+package state
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+)
+
+const (
+	OVERFLOW_EVICT  = "evict"
+	OVERFLOW_REJECT = "reject"
+)
+
+// Returned when a new uid cannot be tracked because the store is full
+var ErrStoreFull = errors.New("state store is full")
+
+// Memory is a StateStore that only lives as long as the process. Entries are kept
+// ordered by the event's LastTimestamp so the ones that have expired in the cluster,
+// or the oldest ones once MaxEvents is reached, can be evicted cheaply.
+type Memory struct {
+	MaxEvents int
+	EventTTL  time.Duration
+	Overflow  string
+	Clock     clock.Clock
+
+	lock        sync.Mutex
+	events      map[string]*list.Element
+	order       *list.List
+	checkpoints map[string]time.Time
+}
+
+type memoryEntry struct {
+	uid   string
+	state EventState
+	seen  time.Time
+}
+
+// A zero MaxEvents or EventTTL disables the cap or the expiry
+func NewMemory(cfg *config.Config) *Memory {
+	return &Memory{
+		MaxEvents:   cfg.StateStoreMaxEvents,
+		EventTTL:    time.Duration(cfg.EventTTL) * time.Second,
+		Overflow:    cfg.StateStoreOverflow,
+		Clock:       clock.RealClock{},
+		events:      make(map[string]*list.Element),
+		order:       list.New(),
+		checkpoints: make(map[string]time.Time),
+	}
+}
+
+func (m *Memory) Get(uid string) (*EventState, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.expire()
+	el, ok := m.events[uid]
+	if !ok {
+		return nil, nil
+	}
+	s := el.Value.(*memoryEntry).state
+	return &s, nil
+}
+
+func (m *Memory) CompareAndSet(uid string, old, new *EventState) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.expire()
+
+	var current *EventState
+	el, ok := m.events[uid]
+	if ok {
+		current = &el.Value.(*memoryEntry).state
+	}
+	if !current.Equal(old) {
+		return false, nil
+	}
+
+	if ok {
+		m.order.Remove(el)
+	} else if m.MaxEvents > 0 && len(m.events) >= m.MaxEvents {
+		if m.Overflow == OVERFLOW_REJECT {
+			return false, ErrStoreFull
+		}
+		m.remove(m.order.Back())
+	}
+
+	entry := &memoryEntry{
+		uid:   uid,
+		state: *new,
+		seen:  new.LastTimestamp,
+	}
+	if entry.seen.IsZero() {
+		entry.seen = m.Clock.Now()
+	}
+	m.events[uid] = m.insert(entry)
+	return true, nil
+}
+
+func (m *Memory) Checkpoint(cluster string) (time.Time, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.checkpoints[cluster], nil
+}
+
+func (m *Memory) SetCheckpoint(cluster string, t time.Time) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.checkpoints[cluster] = t
+	return nil
+}
+
+func (m *Memory) Len() (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.expire()
+	return len(m.events), nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Keeps the most recently seen entries at the front. Events usually arrive in
+// order so this rarely walks past the first element.
+func (m *Memory) insert(entry *memoryEntry) *list.Element {
+	for el := m.order.Front(); el != nil; el = el.Next() {
+		if !entry.seen.Before(el.Value.(*memoryEntry).seen) {
+			return m.order.InsertBefore(entry, el)
+		}
+	}
+	return m.order.PushBack(entry)
+}
+
+// Drops entries for events that have expired in the cluster, oldest first
+func (m *Memory) expire() {
+	if m.EventTTL <= 0 {
+		return
+	}
+
+	cutoff := m.Clock.Now().Add(-m.EventTTL)
+	for el := m.order.Back(); el != nil && el.Value.(*memoryEntry).seen.Before(cutoff); el = m.order.Back() {
+		m.remove(el)
+	}
+}
+
+func (m *Memory) remove(el *list.Element) {
+	entry := el.Value.(*memoryEntry)
+	log.Debugf("Evicting state for %s last seen %v", entry.uid, entry.seen)
+
+	m.order.Remove(el)
+	delete(m.events, entry.uid)
+}
@@ -0,0 +1,192 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+)
+
+const (
+	REDIS_MAX_IDLE     = 3
+	REDIS_IDLE_TIMEOUT = 4 * time.Minute
+	REDIS_MIN_TTL      = time.Second
+	REDIS_SCAN_COUNT   = 1000
+)
+
+// Redis is a StateStore shared by every instance pointed at the same server, so
+// several replicas or several clusters can share dedup and throttle state.
+// Event keys expire once the event would have expired in the cluster.
+type Redis struct {
+	Pool     *redis.Pool
+	Prefix   string
+	EventTTL time.Duration
+}
+
+func NewRedis(cfg *config.Config) (*Redis, error) {
+	pool := &redis.Pool{
+		MaxIdle:     REDIS_MAX_IDLE,
+		IdleTimeout: REDIS_IDLE_TIMEOUT,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", cfg.StateStoreRedisAddress,
+				redis.DialPassword(cfg.StateStoreRedisPassword),
+				redis.DialDatabase(cfg.StateStoreRedisDB),
+			)
+		},
+	}
+
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("Unable to connect to redis state store '%s': %v", cfg.StateStoreRedisAddress, err.Error())
+	}
+
+	return &Redis{
+		Pool:     pool,
+		Prefix:   cfg.StateStoreRedisPrefix,
+		EventTTL: time.Duration(cfg.EventTTL) * time.Second,
+	}, nil
+}
+
+func (r *Redis) Get(uid string) (*EventState, error) {
+	conn := r.Pool.Get()
+	defer conn.Close()
+
+	return r.get(conn, uid)
+}
+
+// Uses WATCH so the SET is discarded if another instance changes the key between our compare and the EXEC
+func (r *Redis) CompareAndSet(uid string, old, new *EventState) (bool, error) {
+	raw, err := json.Marshal(new)
+	if err != nil {
+		return false, err
+	}
+
+	conn := r.Pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("WATCH", r.eventKey(uid)); err != nil {
+		return false, err
+	}
+
+	current, err := r.get(conn, uid)
+	if err != nil {
+		conn.Do("UNWATCH")
+		return false, err
+	}
+	if !current.Equal(old) {
+		_, err := conn.Do("UNWATCH")
+		return false, err
+	}
+
+	conn.Send("MULTI")
+	conn.Send("SET", r.eventKey(uid), raw, "PX", int64(r.ttl(new)/time.Millisecond))
+	reply, err := conn.Do("EXEC")
+	if err != nil {
+		return false, err
+	}
+
+	// EXEC replies nil when the watched key changed
+	return reply != nil, nil
+}
+
+func (r *Redis) Checkpoint(cluster string) (time.Time, error) {
+	conn := r.Pool.Get()
+	defer conn.Close()
+
+	var t time.Time
+	raw, err := redis.Bytes(conn.Do("GET", r.checkpointKey(cluster)))
+	if err == redis.ErrNil {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+
+	err = t.UnmarshalText(raw)
+	return t, err
+}
+
+func (r *Redis) SetCheckpoint(cluster string, t time.Time) error {
+	raw, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	conn := r.Pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("SET", r.checkpointKey(cluster), raw)
+	return err
+}
+
+// Counts the event keys with SCAN so the server is never blocked, which makes it
+// only an estimate while keys are being written or expiring
+func (r *Redis) Len() (int, error) {
+	conn := r.Pool.Get()
+	defer conn.Close()
+
+	n := 0
+	cursor := 0
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", r.eventKey("*"), "COUNT", REDIS_SCAN_COUNT))
+		if err != nil {
+			return 0, err
+		}
+
+		var keys []string
+		if _, err := redis.Scan(values, &cursor, &keys); err != nil {
+			return 0, err
+		}
+		n += len(keys)
+
+		if cursor == 0 {
+			return n, nil
+		}
+	}
+}
+
+func (r *Redis) Close() error {
+	return r.Pool.Close()
+}
+
+func (r *Redis) get(conn redis.Conn, uid string) (*EventState, error) {
+	raw, err := redis.Bytes(conn.Do("GET", r.eventKey(uid)))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &EventState{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Keep the state as long as the event itself lives in the cluster
+func (r *Redis) ttl(s *EventState) time.Duration {
+	if s.LastTimestamp.IsZero() {
+		return r.EventTTL
+	}
+
+	ttl := s.LastTimestamp.Add(r.EventTTL).Sub(time.Now())
+	if ttl < REDIS_MIN_TTL {
+		return REDIS_MIN_TTL
+	}
+	return ttl
+}
+
+func (r *Redis) eventKey(uid string) string {
+	return fmt.Sprintf("%s:event:%s", r.Prefix, uid)
+}
+
+func (r *Redis) checkpointKey(cluster string) string {
+	return fmt.Sprintf("%s:checkpoint:%s", r.Prefix, cluster)
+}
@@ -0,0 +1,79 @@
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+)
+
+const (
+	STORE_MEMORY = "memory"
+	STORE_BOLT   = "bolt"
+	STORE_REDIS  = "redis"
+)
+
+// Dedup and throttle state tracked per event UID
+type EventState struct {
+	Count         int32     `json:"count"`
+	LastTimestamp time.Time `json:"last_timestamp"`
+	LastSent      time.Time `json:"last_sent"`
+	SendCount     int       `json:"send_count"`
+
+	// Kept by the throttle strategies that need them
+	WindowStart time.Time `json:"window_start"`
+	WindowCount int       `json:"window_count"`
+	Tokens      float64   `json:"tokens"`
+	TokensAt    time.Time `json:"tokens_at"`
+}
+
+// Reports whether two states are the same; nil states are only equal to nil
+func (s *EventState) Equal(o *EventState) bool {
+	if s == nil || o == nil {
+		return s == o
+	}
+
+	return s.Count == o.Count &&
+		s.LastTimestamp.Equal(o.LastTimestamp) &&
+		s.LastSent.Equal(o.LastSent) &&
+		s.SendCount == o.SendCount &&
+		s.WindowStart.Equal(o.WindowStart) &&
+		s.WindowCount == o.WindowCount &&
+		s.Tokens == o.Tokens &&
+		s.TokensAt.Equal(o.TokensAt)
+}
+
+// StateStore keeps EventState around so restarts don't cause duplicate or lost notifications
+type StateStore interface {
+	// Returns nil if nothing is stored for the uid
+	Get(uid string) (*EventState, error)
+
+	// Stores new only if the stored state still equals old (nil meaning nothing is
+	// stored), so instances sharing a store never both act on the same change.
+	// Reports whether new was stored.
+	CompareAndSet(uid string, old, new *EventState) (bool, error)
+
+	// The last time the watcher of a cluster was known to be processing events.
+	// Each cluster has its own since each is watched (and goes down) on its own.
+	Checkpoint(cluster string) (time.Time, error)
+	SetCheckpoint(cluster string, t time.Time) error
+
+	// The number of uids state is currently kept for
+	Len() (int, error)
+
+	Close() error
+}
+
+// Creates the StateStore selected in the config
+func New(cfg *config.Config) (StateStore, error) {
+	switch cfg.StateStore {
+	case STORE_MEMORY:
+		return NewMemory(cfg), nil
+	case STORE_BOLT:
+		return NewBolt(cfg)
+	case STORE_REDIS:
+		return NewRedis(cfg)
+	}
+
+	return nil, fmt.Errorf("Unknown state store: %s", cfg.StateStore)
+}
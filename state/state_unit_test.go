@@ -0,0 +1,396 @@
+// +build unit
+
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	bolt "go.etcd.io/bbolt"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+)
+
+// Behaviour every StateStore implementation must have
+func behavesLikeAStateStore(newStore func() StateStore) {
+	var store StateStore
+
+	BeforeEach(func() {
+		store = newStore()
+	})
+
+	AfterEach(func() {
+		store.Close()
+	})
+
+	It("should return nil for unknown uids", func() {
+		s, err := store.Get("unknown")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s).To(BeNil())
+	})
+
+	It("should return what was set", func() {
+		lastSent := time.Date(2016, 11, 29, 1, 2, 3, 0, time.UTC)
+		Expect(store.CompareAndSet("a", nil, &EventState{Count: 3, LastSent: lastSent, SendCount: 2})).To(BeTrue())
+
+		s, err := store.Get("a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Count).To(Equal(int32(3)))
+		Expect(s.LastSent).To(BeTemporally("==", lastSent))
+		Expect(s.SendCount).To(Equal(2))
+	})
+
+	It("should not share state with callers", func() {
+		s := &EventState{Count: 1}
+		Expect(store.CompareAndSet("a", nil, s)).To(BeTrue())
+		s.Count = 5
+
+		stored, _ := store.Get("a")
+		Expect(stored.Count).To(Equal(int32(1)))
+	})
+
+	It("should set when the stored state still matches", func() {
+		old := &EventState{Count: 1, LastSent: time.Now()}
+		Expect(store.CompareAndSet("a", nil, old)).To(BeTrue())
+
+		swapped, err := store.CompareAndSet("a", old, &EventState{Count: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapped).To(BeTrue())
+
+		s, _ := store.Get("a")
+		Expect(s.Count).To(Equal(int32(2)))
+	})
+
+	It("should not set when the stored state has changed", func() {
+		Expect(store.CompareAndSet("a", nil, &EventState{Count: 1})).To(BeTrue())
+		Expect(store.CompareAndSet("a", &EventState{Count: 1}, &EventState{Count: 2})).To(BeTrue())
+
+		swapped, err := store.CompareAndSet("a", &EventState{Count: 1}, &EventState{Count: 3})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapped).To(BeFalse())
+
+		s, _ := store.Get("a")
+		Expect(s.Count).To(Equal(int32(2)))
+	})
+
+	It("should not set when nothing was expected but state is stored", func() {
+		Expect(store.CompareAndSet("a", nil, &EventState{Count: 1})).To(BeTrue())
+
+		swapped, err := store.CompareAndSet("a", nil, &EventState{Count: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapped).To(BeFalse())
+	})
+
+	It("should not set when state was expected but nothing is stored", func() {
+		swapped, err := store.CompareAndSet("a", &EventState{Count: 1}, &EventState{Count: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapped).To(BeFalse())
+	})
+
+	It("should count the uids state is kept for", func() {
+		Expect(store.Len()).To(Equal(0))
+		Expect(store.CompareAndSet("a", nil, &EventState{Count: 1})).To(BeTrue())
+		Expect(store.CompareAndSet("b", nil, &EventState{Count: 1})).To(BeTrue())
+		Expect(store.CompareAndSet("a", &EventState{Count: 1}, &EventState{Count: 2})).To(BeTrue())
+
+		Expect(store.Len()).To(Equal(2))
+	})
+
+	It("should have a zero checkpoint until one is set", func() {
+		t, err := store.Checkpoint("prod")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.IsZero()).To(BeTrue())
+
+		now := time.Now()
+		Expect(store.SetCheckpoint("prod", now)).To(Succeed())
+		t, err = store.Checkpoint("prod")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t).To(BeTemporally("==", now))
+	})
+
+	It("should keep a checkpoint per cluster", func() {
+		Expect(store.SetCheckpoint("prod", time.Now())).To(Succeed())
+
+		t, err := store.Checkpoint("staging")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.IsZero()).To(BeTrue())
+	})
+}
+
+// Behaviour of the StateStore implementations that expire state and cap how much they keep
+func behavesLikeABoundedStateStore(newStore func(cfg *config.Config, clk clock.Clock) StateStore) {
+	var (
+		cfg   *config.Config
+		now   time.Time
+		clk   *clock.FakeClock
+		store StateStore
+	)
+
+	BeforeEach(func() {
+		cfg = config.New()
+		cfg.EventTTL = 3600
+		cfg.StateStoreMaxEvents = 2
+		cfg.StateStoreOverflow = OVERFLOW_EVICT
+		now = time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC)
+		clk = clock.NewFakeClock(now)
+	})
+
+	JustBeforeEach(func() {
+		store = newStore(cfg, clk)
+	})
+
+	AfterEach(func() {
+		store.Close()
+	})
+
+	It("should evict state once the event has expired in the cluster", func() {
+		Expect(store.CompareAndSet("a", nil, &EventState{Count: 1, LastTimestamp: now.Add(-30 * time.Minute)})).To(BeTrue())
+		Expect(store.CompareAndSet("b", nil, &EventState{Count: 1, LastTimestamp: now})).To(BeTrue())
+
+		clk.Step(31 * time.Minute)
+		Expect(store.Get("a")).To(BeNil())
+		Expect(store.Get("b")).ToNot(BeNil())
+		Expect(store.Len()).To(Equal(1))
+	})
+
+	It("should expire state without a LastTimestamp from when it was stored", func() {
+		Expect(store.CompareAndSet("a", nil, &EventState{Count: 1})).To(BeTrue())
+
+		clk.Step(59 * time.Minute)
+		Expect(store.Get("a")).ToNot(BeNil())
+		clk.Step(2 * time.Minute)
+		Expect(store.Get("a")).To(BeNil())
+	})
+
+	It("should keep refreshed state", func() {
+		old := &EventState{Count: 1, LastTimestamp: now.Add(-50 * time.Minute)}
+		Expect(store.CompareAndSet("a", nil, old)).To(BeTrue())
+		Expect(store.CompareAndSet("a", old, &EventState{Count: 2, LastTimestamp: now})).To(BeTrue())
+
+		clk.Step(30 * time.Minute)
+		Expect(store.Get("a")).ToNot(BeNil())
+	})
+
+	Context("when full and evicting", func() {
+		It("should evict the event that happened longest ago", func() {
+			Expect(store.CompareAndSet("a", nil, &EventState{Count: 1, LastTimestamp: now.Add(-time.Minute)})).To(BeTrue())
+			Expect(store.CompareAndSet("b", nil, &EventState{Count: 1, LastTimestamp: now.Add(-2 * time.Minute)})).To(BeTrue())
+			Expect(store.CompareAndSet("c", nil, &EventState{Count: 1, LastTimestamp: now})).To(BeTrue())
+
+			Expect(store.Len()).To(Equal(2))
+			Expect(store.Get("a")).ToNot(BeNil())
+			Expect(store.Get("b")).To(BeNil())
+			Expect(store.Get("c")).ToNot(BeNil())
+		})
+
+		It("should still update uids it already tracks", func() {
+			Expect(store.CompareAndSet("a", nil, &EventState{Count: 1})).To(BeTrue())
+			Expect(store.CompareAndSet("b", nil, &EventState{Count: 1})).To(BeTrue())
+			Expect(store.CompareAndSet("a", &EventState{Count: 1}, &EventState{Count: 2})).To(BeTrue())
+
+			Expect(store.Get("b")).ToNot(BeNil())
+		})
+	})
+
+	Context("when full and rejecting", func() {
+		BeforeEach(func() {
+			cfg.StateStoreOverflow = OVERFLOW_REJECT
+		})
+
+		It("should refuse to track new uids", func() {
+			Expect(store.CompareAndSet("a", nil, &EventState{Count: 1})).To(BeTrue())
+			Expect(store.CompareAndSet("b", nil, &EventState{Count: 1})).To(BeTrue())
+
+			swapped, err := store.CompareAndSet("c", nil, &EventState{Count: 1})
+			Expect(err).To(Equal(ErrStoreFull))
+			Expect(swapped).To(BeFalse())
+			Expect(store.Len()).To(Equal(2))
+		})
+
+		It("should track new uids again once others expire", func() {
+			Expect(store.CompareAndSet("a", nil, &EventState{Count: 1})).To(BeTrue())
+			Expect(store.CompareAndSet("b", nil, &EventState{Count: 1})).To(BeTrue())
+
+			clk.Step(2 * time.Hour)
+			Expect(store.CompareAndSet("c", nil, &EventState{Count: 1})).To(BeTrue())
+		})
+	})
+}
+
+var _ = Describe("Memory", func() {
+	behavesLikeAStateStore(func() StateStore {
+		return NewMemory(config.New())
+	})
+
+	behavesLikeABoundedStateStore(func(cfg *config.Config, clk clock.Clock) StateStore {
+		store := NewMemory(cfg)
+		store.Clock = clk
+		return store
+	})
+})
+
+var _ = Describe("Bolt", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir, _ = ioutil.TempDir("", "kit-overwatch-state")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	// Opens a store at a path in dir
+	open := func(cfg *config.Config, name string) (*Bolt, error) {
+		cfg.StateStorePath = filepath.Join(dir, name)
+		return NewBolt(cfg)
+	}
+
+	behavesLikeAStateStore(func() StateStore {
+		store, err := open(config.New(), "state.db")
+		Expect(err).ToNot(HaveOccurred())
+		return store
+	})
+
+	behavesLikeABoundedStateStore(func(cfg *config.Config, clk clock.Clock) StateStore {
+		store, err := open(cfg, "bounded.db")
+		Expect(err).ToNot(HaveOccurred())
+		store.Clock = clk
+		return store
+	})
+
+	It("should keep state across restarts", func() {
+		store, err := open(config.New(), "restart.db")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.CompareAndSet("a", nil, &EventState{Count: 7, SendCount: 1, LastTimestamp: time.Now()})).To(BeTrue())
+		Expect(store.Close()).To(Succeed())
+
+		store, err = open(config.New(), "restart.db")
+		Expect(err).ToNot(HaveOccurred())
+		defer store.Close()
+
+		s, err := store.Get("a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Count).To(Equal(int32(7)))
+	})
+
+	It("should expire state stored before entries expired", func() {
+		cfg := config.New()
+		cfg.EventTTL = 3600
+		store, err := open(cfg, "old.db")
+		Expect(err).ToNot(HaveOccurred())
+		raw := []byte(`{"count":1,"last_timestamp":"2016-11-29T10:00:00Z"}`)
+		Expect(store.DB.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(eventsBucket).Put([]byte("a"), raw)
+		})).To(Succeed())
+		Expect(store.DB.Update(func(tx *bolt.Tx) error {
+			for _, b := range [][]byte{seenBucket, expiryBucket} {
+				if err := tx.DeleteBucket(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		})).To(Succeed())
+		Expect(store.Close()).To(Succeed())
+
+		store, err = open(cfg, "old.db")
+		Expect(err).ToNot(HaveOccurred())
+		defer store.Close()
+		store.Clock = clock.NewFakeClock(time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC))
+
+		Expect(store.Get("a")).To(BeNil())
+		Expect(store.Len()).To(Equal(0))
+	})
+
+	It("should return an error when the file cannot be opened", func() {
+		_, err := open(config.New(), filepath.Join("missing", "state.db"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Redis", func() {
+	var (
+		server *miniredis.Miniredis
+		cfg    *config.Config
+	)
+
+	BeforeEach(func() {
+		var err error
+		server, err = miniredis.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg = config.New()
+		cfg.StateStoreRedisAddress = server.Addr()
+		cfg.StateStoreRedisPrefix = "kit-overwatch"
+		cfg.EventTTL = 3600
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	behavesLikeAStateStore(func() StateStore {
+		store, err := NewRedis(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		return store
+	})
+
+	It("should expire state when the event expires in the cluster", func() {
+		store, err := NewRedis(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer store.Close()
+
+		s := &EventState{Count: 1, LastTimestamp: time.Now().Add(-30 * time.Minute)}
+		Expect(store.CompareAndSet("a", nil, s)).To(BeTrue())
+		Expect(server.TTL("kit-overwatch:event:a")).To(BeNumerically("~", 30*time.Minute, time.Minute))
+
+		server.FastForward(31 * time.Minute)
+		stored, err := store.Get("a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(BeNil())
+	})
+
+	It("should not set when another instance changed the state first", func() {
+		store, err := NewRedis(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer store.Close()
+		Expect(store.CompareAndSet("a", nil, &EventState{Count: 1})).To(BeTrue())
+
+		// Another instance with the same view of the state wins the race
+		other, err := NewRedis(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer other.Close()
+		Expect(other.CompareAndSet("a", &EventState{Count: 1}, &EventState{Count: 2, SendCount: 1})).To(BeTrue())
+
+		Expect(store.CompareAndSet("a", &EventState{Count: 1}, &EventState{Count: 2, SendCount: 1})).To(BeFalse())
+	})
+
+	It("should return an error when redis cannot be reached", func() {
+		server.Close()
+		_, err := NewRedis(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("New", func() {
+	It("should create the configured store", func() {
+		cfg := config.New()
+		cfg.StateStore = STORE_MEMORY
+		store, err := New(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store).To(BeAssignableToTypeOf(&Memory{}))
+	})
+
+	It("should return an error for unknown stores", func() {
+		cfg := config.New()
+		cfg.StateStore = "etcd"
+		_, err := New(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,305 @@
+package storm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	dependencies "github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+const (
+	REASON_STARTED   = "EventStorm"
+	REASON_CONTINUES = "EventStormContinues"
+	REASON_ENDED     = "EventStormOver"
+	COMPONENT        = "kit-overwatch"
+	CHECK_INTERVAL   = 10 * time.Second
+	MAX_COUNTS       = 5
+)
+
+// What a rate is tracked for: a reason in a namespace, and on a node if the event says which
+type key struct {
+	Namespace string
+	Reason    string
+	Node      string
+}
+
+// Describes the key, eg. BackOff in namespace default on node node-1
+func (k key) String() string {
+	s := k.Reason
+	if k.Namespace != "" {
+		s = fmt.Sprintf("%s in namespace %s", s, k.Namespace)
+	}
+	if k.Node != "" {
+		s = fmt.Sprintf("%s on node %s", s, k.Node)
+	}
+	return s
+}
+
+// An ongoing storm and the events it has swallowed
+type storm struct {
+	started time.Time
+	updated time.Time
+	level   string
+	total   int
+	objects map[string]int
+	latest  api.Event
+}
+
+// Detector tracks the rate of Warning notifications per namespace, reason and node
+// together. Once more than Threshold happen within Window for one of them, a single
+// storm notification is sent and further notifications matching it are held back.
+// While it continues an update with the running counts is sent every Update, and
+// when the rate falls back below half the threshold an all clear summary is sent.
+// Normal events and those below Level are never part of a storm, so a busy but
+// healthy namespace, eg. during a rolling update, doesn't hide its errors. Storm
+// notifications mention whoever the latest event held back would have.
+type Detector struct {
+	Threshold int
+	Window    time.Duration
+	Update    time.Duration
+	Level     string
+	Mentions  dependencies.IMentionResolver
+	Sink      deps.Sink
+	Cluster   string
+	Clock     clock.Clock
+
+	// Runs sends in the background; the watcher sets it so Shutdown waits for them
+	Spawn func(func())
+
+	lock   sync.Mutex
+	seen   map[key][]time.Time
+	storms map[key]*storm
+}
+
+func New(cfg *config.Config, m dependencies.IMentionResolver, sink deps.Sink) *Detector {
+	return &Detector{
+		Threshold: cfg.StormThreshold,
+		Window:    time.Duration(cfg.StormWindow) * time.Second,
+		Update:    time.Duration(cfg.StormUpdate) * time.Second,
+		Level:     cfg.NotificationLevel,
+		Mentions:  m,
+		Sink:      sink,
+		Cluster:   cfg.ClusterName,
+		Clock:     clock.RealClock{},
+		Spawn:     func(f func()) { go f() },
+		seen:      make(map[key][]time.Time),
+		storms:    make(map[key]*storm),
+	}
+}
+
+// Records an event about to be notified at the given level and reports whether it
+// is part of a storm, in which case it should not be notified about on its own
+func (d *Detector) Observe(e api.Event, level string) bool {
+	if e.Type != api.EventTypeWarning || deps.Severity(level) < deps.Severity(d.Level) {
+		return false
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.Clock.Now()
+	k := key{Namespace: e.ObjectMeta.Namespace, Reason: e.Reason, Node: e.Source.Host}
+	d.seen[k] = append(d.trim(d.seen[k], now), now)
+
+	s, ok := d.storms[k]
+	if !ok {
+		if len(d.seen[k]) <= d.Threshold {
+			return false
+		}
+		s = d.start(k, now, level)
+	}
+	s.add(e, level)
+
+	if !ok {
+		log.Warnf("Event storm of %s: %d events in %v, holding back notifications", k, len(d.seen[k]), d.Window)
+		n := d.notification(k, s, now, REASON_STARTED)
+		d.Spawn(func() { d.send(n, e) })
+	}
+	return true
+}
+
+// Periodically updates on storms that continue and ends those that have passed,
+// until the context is done
+func (d *Detector) Run(ctx context.Context) {
+	tick := d.Clock.Tick(CHECK_INTERVAL)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			d.check()
+		}
+	}
+}
+
+func (d *Detector) check() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.Clock.Now()
+	for k, times := range d.seen {
+		times = d.trim(times, now)
+		if len(times) == 0 {
+			delete(d.seen, k)
+		} else {
+			d.seen[k] = times
+		}
+
+		s, ok := d.storms[k]
+		if !ok {
+			continue
+		}
+
+		var n *deps.Notification
+		switch {
+		case len(times)*2 < d.Threshold:
+			log.Infof("Event storm of %s is over after %v, %d events were held back", k, now.Sub(s.started), s.total)
+			delete(d.storms, k)
+			n = d.notification(k, s, now, REASON_ENDED)
+		case d.Update > 0 && now.Sub(s.updated) >= d.Update:
+			log.Infof("Event storm of %s continues after %v, %d events held back so far", k, now.Sub(s.started), s.total)
+			s.updated = now
+			n = d.notification(k, s, now, REASON_CONTINUES)
+		default:
+			continue
+		}
+		latest := s.latest
+		d.Spawn(func() { d.send(n, latest) })
+	}
+}
+
+func (d *Detector) start(k key, now time.Time, level string) *storm {
+	s := &storm{
+		started: now,
+		updated: now,
+		level:   "WARN",
+		objects: make(map[string]int),
+	}
+	if deps.Severity(level) > deps.Severity(s.level) {
+		s.level = level
+	}
+	d.storms[k] = s
+	return s
+}
+
+// Drops times that are no longer within the window
+func (d *Detector) trim(times []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-d.Window)
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// Resolves the mention for the event outside the lock, as it may look its object up
+func (d *Detector) send(n *deps.Notification, e api.Event) {
+	n.Mention = d.Mentions.ResolveEvent(e)
+	d.Sink.SendAll(n)
+}
+
+func (s *storm) add(e api.Event, level string) {
+	s.total++
+	s.latest = e
+	if o := e.InvolvedObject; o.Name != "" {
+		s.objects[fmt.Sprintf("%s %s", o.Kind, o.Name)]++
+	}
+	if deps.Severity(level) > deps.Severity(s.level) {
+		s.level = level
+	}
+}
+
+// Builds the storm started, update or all clear notification, carrying a synthetic event
+func (d *Detector) notification(k key, s *storm, now time.Time, reason string) *deps.Notification {
+	var message string
+	switch reason {
+	case REASON_STARTED:
+		message = fmt.Sprintf("Event storm of %s: more than %d events in %v, holding back notifications until it passes.", k, d.Threshold, d.Window)
+	case REASON_CONTINUES:
+		message = fmt.Sprintf("Event storm of %s continues after %v: %d events held back so far.", k, now.Sub(s.started), s.total)
+	default:
+		message = fmt.Sprintf("Event storm of %s is over after %v: %d events were held back.", k, now.Sub(s.started), s.total)
+	}
+	if len(s.objects) != 0 {
+		message = fmt.Sprintf("%s Objects: %s.", message, topCounts(s.objects))
+	}
+
+	e := api.Event{
+		ObjectMeta: api.ObjectMeta{
+			Name:      COMPONENT,
+			Namespace: k.Namespace,
+		},
+		Reason:         reason,
+		Message:        message,
+		Source:         api.EventSource{Component: COMPONENT},
+		FirstTimestamp: unversioned.NewTime(s.started),
+		LastTimestamp:  unversioned.NewTime(now),
+		Count:          int32(s.total),
+		Type:           api.EventTypeWarning,
+	}
+	if k.Node != "" {
+		e.Source.Host = k.Node
+		e.InvolvedObject = api.ObjectReference{Kind: "Node", Name: k.Node}
+	}
+	if reason == REASON_ENDED {
+		e.Type = api.EventTypeNormal
+	}
+
+	return &deps.Notification{
+		Cluster:   d.Cluster,
+		Namespace: e.ObjectMeta.Namespace,
+		Event:     e,
+		Level:     s.level,
+	}
+}
+
+// Lists the largest counts first, eg. `5 Pod web-1, 2 Pod web-2 and 3 more`
+func topCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Sort(byCount{names, counts})
+
+	var parts []string
+	more := 0
+	for i, name := range names {
+		if i >= MAX_COUNTS {
+			more += counts[name]
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", counts[name], name))
+	}
+
+	summary := strings.Join(parts, ", ")
+	if more > 0 {
+		summary = fmt.Sprintf("%s and %d more", summary, more)
+	}
+	return summary
+}
+
+type byCount struct {
+	names  []string
+	counts map[string]int
+}
+
+func (b byCount) Len() int      { return len(b.names) }
+func (b byCount) Swap(i, j int) { b.names[i], b.names[j] = b.names[j], b.names[i] }
+func (b byCount) Less(i, j int) bool {
+	ci, cj := b.counts[b.names[i]], b.counts[b.names[j]]
+	if ci != cj {
+		return ci > cj
+	}
+	return b.names[i] < b.names[j]
+}
@@ -0,0 +1,13 @@
+package storm
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestStormSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Storm Suite")
+}
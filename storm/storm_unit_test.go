@@ -0,0 +1,228 @@
+// +build unit
+
+package storm
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/fakes/notifiersfakes"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+)
+
+func newEvent(namespace, node, reason string) api.Event {
+	return api.Event{
+		ObjectMeta:     api.ObjectMeta{Namespace: namespace},
+		InvolvedObject: api.ObjectReference{Kind: "Pod", Namespace: namespace, Name: "web-1"},
+		Source:         api.EventSource{Host: node},
+		Reason:         reason,
+		Type:           api.EventTypeWarning,
+	}
+}
+
+var _ = Describe("Detector", func() {
+	var (
+		mentions *depsfakes.FakeIMentionResolver
+		sink     *notifiersfakes.FakeSink
+		sent     func() []*deps.Notification
+		clk      *clock.FakeClock
+		d        *Detector
+	)
+
+	// Observes n BackOff events a second apart, reporting how many were held back
+	observe := func(n int, namespace, node string) int {
+		held := 0
+		for i := 0; i < n; i++ {
+			if d.Observe(newEvent(namespace, node, "BackOff"), "ERROR") {
+				held++
+			}
+			clk.Step(time.Second)
+		}
+		return held
+	}
+
+	BeforeEach(func() {
+		cfg := config.New()
+		cfg.StormThreshold = 10
+		cfg.StormWindow = 60
+		cfg.StormUpdate = 300
+		cfg.ClusterName = "prod"
+		cfg.NotificationLevel = "WARN"
+
+		mentions = &depsfakes.FakeIMentionResolver{}
+		mentions.ResolveEventReturns("payments")
+		sink = &notifiersfakes.FakeSink{}
+		sent = notifiersfakes.SentTo(sink)
+		clk = clock.NewFakeClock(time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC))
+		d = New(cfg, mentions, sink)
+		d.Clock = clk
+	})
+
+	It("should not hold back anything below the threshold", func() {
+		Expect(observe(10, "default", "node-1")).To(Equal(0))
+		Consistently(sent).Should(BeEmpty())
+	})
+
+	It("should not count events that fell out of the window", func() {
+		observe(10, "default", "")
+		clk.Step(time.Minute)
+		Expect(observe(10, "default", "")).To(Equal(0))
+	})
+
+	It("should count each reason, namespace and node on its own", func() {
+		for i := 0; i < 30; i++ {
+			e := newEvent("default", fmt.Sprintf("node-%d", i%3), "BackOff")
+			if i%2 == 1 {
+				e.Reason = "FailedSync"
+			}
+			Expect(d.Observe(e, "ERROR")).To(BeFalse())
+		}
+		Consistently(sent).Should(BeEmpty())
+	})
+
+	It("should not count Normal events, like those of a rolling update", func() {
+		for i := 0; i < 30; i++ {
+			e := newEvent("default", "node-1", "Scheduled")
+			e.Type = api.EventTypeNormal
+			Expect(d.Observe(e, "ERROR")).To(BeFalse())
+		}
+		Consistently(sent).Should(BeEmpty())
+	})
+
+	It("should not count events below the notification level", func() {
+		for i := 0; i < 30; i++ {
+			Expect(d.Observe(newEvent("default", "node-1", "BackOff"), "INFO")).To(BeFalse())
+		}
+		Expect(d.Observe(newEvent("default", "node-1", "BackOff"), "ERROR")).To(BeFalse())
+		Consistently(sent).Should(BeEmpty())
+	})
+
+	Context("when the rate crosses the threshold", func() {
+		It("should send a single storm notification and hold back the rest", func() {
+			Expect(observe(30, "default", "node-1")).To(Equal(20))
+			Eventually(sent).Should(HaveLen(1))
+
+			n := sent()[0]
+			Expect(n.Event.Reason).To(Equal(REASON_STARTED))
+			Expect(n.Level).To(Equal("ERROR"))
+			Expect(n.Cluster).To(Equal("prod"))
+			Expect(n.Namespace).To(Equal("default"))
+			Expect(n.Event.InvolvedObject).To(Equal(api.ObjectReference{Kind: "Node", Name: "node-1"}))
+			Expect(n.Event.Message).To(HavePrefix("Event storm of BackOff in namespace default on node node-1: more than 10 events in 1m0s"))
+		})
+
+		It("should mention whoever the events held back would have", func() {
+			observe(30, "default", "")
+			clk.Step(time.Minute)
+			d.check()
+			Eventually(sent).Should(HaveLen(2))
+
+			Expect(sent()[0].Mention).To(Equal("payments"))
+			Expect(sent()[1].Mention).To(Equal("payments"))
+			Expect(mentions.ResolveEventArgsForCall(1).InvolvedObject.Name).To(Equal("web-1"))
+		})
+
+		It("should only hold back events that are part of the storm", func() {
+			observe(11, "default", "")
+			Eventually(sent).Should(HaveLen(1))
+
+			Expect(d.Observe(newEvent("kube-system", "", "BackOff"), "ERROR")).To(BeFalse())
+			Expect(d.Observe(newEvent("default", "", "Other"), "ERROR")).To(BeFalse())
+			Expect(d.Observe(newEvent("default", "node-1", "BackOff"), "ERROR")).To(BeFalse())
+			Expect(d.Observe(newEvent("default", "", "BackOff"), "ERROR")).To(BeTrue())
+		})
+
+		It("should send an all clear summary once the rate falls back", func() {
+			observe(30, "default", "")
+			Eventually(sent).Should(HaveLen(1))
+
+			d.check()
+			Consistently(sent).Should(HaveLen(1))
+
+			clk.Step(time.Minute)
+			d.check()
+			Eventually(sent).Should(HaveLen(2))
+
+			n := sent()[1]
+			Expect(n.Event.Reason).To(Equal(REASON_ENDED))
+			Expect(n.Event.Count).To(Equal(int32(20)))
+			Expect(n.Namespace).To(Equal("default"))
+			Expect(n.Event.Message).To(ContainSubstring("20 events were held back"))
+			Expect(n.Event.Message).To(ContainSubstring("Objects: 20 Pod web-1"))
+		})
+
+		It("should send in the background through Spawn", func() {
+			var spawned []func()
+			d.Spawn = func(f func()) { spawned = append(spawned, f) }
+
+			observe(11, "default", "")
+			Expect(sink.SendAllCallCount()).To(Equal(0))
+			Expect(spawned).To(HaveLen(1))
+
+			spawned[0]()
+			Expect(sent()[0].Event.Reason).To(Equal(REASON_STARTED))
+		})
+
+		It("should keep the storm going while the rate stays above half the threshold", func() {
+			observe(30, "default", "")
+			Eventually(sent).Should(HaveLen(1))
+			for i := 0; i < 10; i++ {
+				observe(1, "default", "")
+				clk.Step(9 * time.Second)
+				d.check()
+			}
+			Consistently(sent).Should(HaveLen(1))
+		})
+
+		It("should send updates with the running counts while the storm continues", func() {
+			observe(30, "default", "")
+			Eventually(sent).Should(HaveLen(1))
+			for i := 0; i < 28; i++ {
+				observe(1, "default", "")
+				clk.Step(9 * time.Second)
+				d.check()
+			}
+			Eventually(sent).Should(HaveLen(2))
+
+			n := sent()[1]
+			Expect(n.Event.Reason).To(Equal(REASON_CONTINUES))
+			Expect(n.Event.Type).To(Equal(api.EventTypeWarning))
+			Expect(n.Event.Count).To(Equal(int32(48)))
+			Expect(n.Level).To(Equal("ERROR"))
+			Expect(n.Mention).To(Equal("payments"))
+			Expect(n.Event.Message).To(ContainSubstring("48 events held back so far"))
+			Expect(n.Event.Message).To(ContainSubstring("Objects: 48 Pod web-1"))
+		})
+
+		It("should not send updates when they are disabled", func() {
+			d.Update = 0
+			observe(30, "default", "")
+			Eventually(sent).Should(HaveLen(1))
+			for i := 0; i < 30; i++ {
+				observe(1, "default", "")
+				clk.Step(9 * time.Second)
+				d.check()
+			}
+			Consistently(sent).Should(HaveLen(1))
+		})
+	})
+})
+
+var _ = Describe("topCounts", func() {
+	It("should list the largest counts first", func() {
+		counts := map[string]int{"Pod web-1": 1, "Pod web-2": 3, "Node node-1": 2}
+		Expect(topCounts(counts)).To(Equal("3 Pod web-2, 2 Node node-1, 1 Pod web-1"))
+	})
+
+	It("should sum up what isn't listed", func() {
+		counts := map[string]int{"a": 9, "b": 8, "c": 7, "d": 6, "e": 5, "f": 2, "g": 1}
+		Expect(topCounts(counts)).To(Equal("9 a, 8 b, 7 c, 6 d, 5 e and 3 more"))
+	})
+})
@@ -0,0 +1,274 @@
+package throttle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/state"
+)
+
+const (
+	STRATEGY_NONE        = "none"
+	STRATEGY_LINEAR      = "linear"
+	STRATEGY_EXPONENTIAL = "exponential"
+	STRATEGY_WINDOW      = "window"
+	STRATEGY_BUCKET      = "bucket"
+)
+
+// A Throttler decides whether another notification may be sent for an event.
+// Strategies that need more than the send count and time keep it in the state too.
+type Throttler interface {
+	Allow(st *state.EventState, now time.Time) bool
+}
+
+// Never throttles
+type None struct{}
+
+func (None) Allow(st *state.EventState, now time.Time) bool {
+	return true
+}
+
+// Waits Interval after the first notification, twice that after the second and so on
+type Linear struct {
+	Interval time.Duration
+}
+
+func (l *Linear) Allow(st *state.EventState, now time.Time) bool {
+	if st.SendCount == 0 {
+		return true
+	}
+	return !now.Before(st.LastSent.Add(l.Interval * time.Duration(st.SendCount)))
+}
+
+// Waits Base after the first notification and doubles the wait after each one, up to Max
+type Exponential struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+func (x *Exponential) Allow(st *state.EventState, now time.Time) bool {
+	if st.SendCount == 0 {
+		return true
+	}
+
+	wait := x.Base
+	for i := 1; i < st.SendCount && wait < x.Max; i++ {
+		wait *= 2
+	}
+	if wait > x.Max {
+		wait = x.Max
+	}
+	return !now.Before(st.LastSent.Add(wait))
+}
+
+// Sends at most Limit notifications per Window, the window starting with the first one
+type FixedWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (f *FixedWindow) Allow(st *state.EventState, now time.Time) bool {
+	if st.WindowStart.IsZero() || !now.Before(st.WindowStart.Add(f.Window)) {
+		st.WindowStart = now
+		st.WindowCount = 0
+	}
+
+	if st.WindowCount >= f.Limit {
+		return false
+	}
+	st.WindowCount++
+	return true
+}
+
+// Allows bursts of up to Burst notifications, then one per Refill
+type TokenBucket struct {
+	Burst  int
+	Refill time.Duration
+}
+
+func (b *TokenBucket) Allow(st *state.EventState, now time.Time) bool {
+	if st.TokensAt.IsZero() {
+		st.Tokens = float64(b.Burst)
+	} else if elapsed := now.Sub(st.TokensAt); elapsed > 0 {
+		st.Tokens += float64(elapsed) / float64(b.Refill)
+	}
+	if st.Tokens > float64(b.Burst) {
+		st.Tokens = float64(b.Burst)
+	}
+	st.TokensAt = now
+
+	if st.Tokens < 1 {
+		return false
+	}
+	st.Tokens--
+	return true
+}
+
+// Parses a strategy spec: `none`, `linear:<interval>`, `exponential:<base>:<max>`,
+// `window:<limit>:<window>` or `bucket:<burst>:<refill>`, durations like `1m` or `1h`.
+func Parse(spec string) (Throttler, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	args := parts[1:]
+
+	arity := map[string]int{
+		STRATEGY_NONE:        0,
+		STRATEGY_LINEAR:      1,
+		STRATEGY_EXPONENTIAL: 2,
+		STRATEGY_WINDOW:      2,
+		STRATEGY_BUCKET:      2,
+	}
+	n, ok := arity[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown throttle strategy '%s'", parts[0])
+	}
+	if len(args) != n {
+		return nil, fmt.Errorf("throttle strategy '%s' takes %d arguments, got '%s'", parts[0], n, spec)
+	}
+
+	switch parts[0] {
+	case STRATEGY_LINEAR:
+		interval, err := parseDuration(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return &Linear{Interval: interval}, nil
+	case STRATEGY_EXPONENTIAL:
+		base, err := parseDuration(args[0])
+		if err != nil {
+			return nil, err
+		}
+		max, err := parseDuration(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if max < base {
+			return nil, fmt.Errorf("exponential throttle max '%s' is less than its base '%s'", args[1], args[0])
+		}
+		return &Exponential{Base: base, Max: max}, nil
+	case STRATEGY_WINDOW:
+		limit, err := parseLimit(args[0])
+		if err != nil {
+			return nil, err
+		}
+		window, err := parseDuration(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return &FixedWindow{Limit: limit, Window: window}, nil
+	case STRATEGY_BUCKET:
+		burst, err := parseLimit(args[0])
+		if err != nil {
+			return nil, err
+		}
+		refill, err := parseDuration(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return &TokenBucket{Burst: burst, Refill: refill}, nil
+	}
+
+	return None{}, nil
+}
+
+// Throttles picks the throttler for each event: by reason first, then by the
+// involved object's kind, falling back to the default.
+type Throttles struct {
+	Default Throttler
+	Reasons map[string]Throttler
+	Kinds   map[string]Throttler
+	Clock   clock.Clock
+}
+
+func New(cfg *config.Config) (*Throttles, error) {
+	def, err := Parse(cfg.Throttle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid 'KIT_OVERWATCH_THROTTLE': %v", err.Error())
+	}
+
+	reasons, err := parseOverrides(cfg.ThrottleReasons)
+	if err != nil {
+		return nil, fmt.Errorf("invalid 'KIT_OVERWATCH_THROTTLE_REASON': %v", err.Error())
+	}
+
+	kinds, err := parseOverrides(cfg.ThrottleKinds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid 'KIT_OVERWATCH_THROTTLE_KIND': %v", err.Error())
+	}
+
+	return &Throttles{
+		Default: def,
+		Reasons: reasons,
+		Kinds:   kinds,
+		Clock:   clock.RealClock{},
+	}, nil
+}
+
+// Returns the throttler that applies to the event
+func (t *Throttles) For(e api.Event) Throttler {
+	if th, ok := t.Reasons[e.Reason]; ok {
+		return th
+	}
+	if th, ok := t.Kinds[e.InvolvedObject.Kind]; ok {
+		return th
+	}
+	return t.Default
+}
+
+// Reports whether a notification may be sent for the event now, recording the send in its state if so
+func (t *Throttles) Allow(e api.Event, st *state.EventState) bool {
+	now := t.Clock.Now()
+	if !t.For(e).Allow(st, now) {
+		return false
+	}
+
+	st.LastSent = now
+	st.SendCount++
+	return true
+}
+
+// Parses `<name>=<spec>` pairs
+func parseOverrides(overrides []string) (map[string]Throttler, error) {
+	throttlers := make(map[string]Throttler, len(overrides))
+	for _, o := range overrides {
+		if o == "" {
+			continue
+		}
+
+		parts := strings.SplitN(o, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("expected '<name>=<strategy>', got '%s'", o)
+		}
+
+		th, err := Parse(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		throttlers[parts[0]] = th
+	}
+	return throttlers, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration '%s' must be greater than 0", s)
+	}
+	return d, nil
+}
+
+func parseLimit(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid limit '%s', must be a number greater than 0", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,13 @@
+package throttle
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestThrottleSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Throttle Suite")
+}
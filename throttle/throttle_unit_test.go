@@ -0,0 +1,199 @@
+// +build unit
+
+package throttle
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/clock"
+
+	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/state"
+)
+
+// Sends as many notifications as the throttles allow, one every step, returning how many were sent
+func sendEvery(t *Throttles, clk *clock.FakeClock, st *state.EventState, step time.Duration, steps int) int {
+	sent := 0
+	for i := 0; i < steps; i++ {
+		if t.Allow(api.Event{}, st) {
+			sent++
+		}
+		clk.Step(step)
+	}
+	return sent
+}
+
+var _ = Describe("Throttles", func() {
+	var (
+		cfg *config.Config
+		clk *clock.FakeClock
+		st  *state.EventState
+	)
+
+	BeforeEach(func() {
+		cfg = config.New()
+		cfg.Throttle = "linear:1m"
+		clk = clock.NewFakeClock(time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC))
+		st = &state.EventState{}
+	})
+
+	newThrottles := func() *Throttles {
+		t, err := New(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		t.Clock = clk
+		return t
+	}
+
+	Context("with the linear strategy", func() {
+		It("should delay the second notification by the interval", func() {
+			t := newThrottles()
+			Expect(t.Allow(api.Event{}, st)).To(BeTrue())
+			Expect(t.Allow(api.Event{}, st)).To(BeFalse())
+
+			clk.Step(59 * time.Second)
+			Expect(t.Allow(api.Event{}, st)).To(BeFalse())
+			clk.Step(time.Second)
+			Expect(t.Allow(api.Event{}, st)).To(BeTrue())
+		})
+
+		It("should wait one more interval after each notification", func() {
+			t := newThrottles()
+			Expect(t.Allow(api.Event{}, st)).To(BeTrue())
+			clk.Step(time.Minute)
+			Expect(t.Allow(api.Event{}, st)).To(BeTrue())
+
+			clk.Step(time.Minute)
+			Expect(t.Allow(api.Event{}, st)).To(BeFalse())
+			clk.Step(time.Minute)
+			Expect(t.Allow(api.Event{}, st)).To(BeTrue())
+			Expect(st.SendCount).To(Equal(3))
+			Expect(st.LastSent).To(Equal(clk.Now()))
+		})
+	})
+
+	Context("with the exponential strategy", func() {
+		BeforeEach(func() {
+			cfg.Throttle = "exponential:1m:4m"
+		})
+
+		It("should double the wait after each notification up to the cap", func() {
+			t := newThrottles()
+			var waits []time.Duration
+			last := clk.Now()
+			for i := 0; i < 5; i++ {
+				for !t.Allow(api.Event{}, st) {
+					clk.Step(time.Second)
+				}
+				waits = append(waits, clk.Now().Sub(last))
+				last = clk.Now()
+			}
+
+			Expect(waits).To(Equal([]time.Duration{0, time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute}))
+		})
+	})
+
+	Context("with the fixed window strategy", func() {
+		BeforeEach(func() {
+			cfg.Throttle = "window:3:10m"
+		})
+
+		It("should send at most the limit per window", func() {
+			t := newThrottles()
+			Expect(sendEvery(t, clk, st, time.Minute, 10)).To(Equal(3))
+			Expect(sendEvery(t, clk, st, time.Minute, 10)).To(Equal(3))
+		})
+	})
+
+	Context("with the token bucket strategy", func() {
+		BeforeEach(func() {
+			cfg.Throttle = "bucket:2:5m"
+		})
+
+		It("should allow a burst then one per refill", func() {
+			t := newThrottles()
+			Expect(sendEvery(t, clk, st, 0, 5)).To(Equal(2))
+			Expect(sendEvery(t, clk, st, time.Minute, 20)).To(Equal(3))
+		})
+
+		It("should not store more than the burst", func() {
+			t := newThrottles()
+			Expect(t.Allow(api.Event{}, st)).To(BeTrue())
+			clk.Step(time.Hour)
+			Expect(sendEvery(t, clk, st, 0, 5)).To(Equal(2))
+		})
+	})
+
+	Context("with the none strategy", func() {
+		BeforeEach(func() {
+			cfg.Throttle = "none"
+		})
+
+		It("should never throttle", func() {
+			Expect(sendEvery(newThrottles(), clk, st, 0, 10)).To(Equal(10))
+		})
+	})
+
+	Context("with overrides", func() {
+		BeforeEach(func() {
+			cfg.ThrottleReasons = []string{"BackOff=none"}
+			cfg.ThrottleKinds = []string{"Pod=window:1:1h", "Node=exponential:1m:1h"}
+		})
+
+		It("should prefer the reason over the kind", func() {
+			t := newThrottles()
+			e := api.Event{Reason: "BackOff", InvolvedObject: api.ObjectReference{Kind: "Pod"}}
+			Expect(t.For(e)).To(Equal(None{}))
+		})
+
+		It("should use the kind when the reason has no override", func() {
+			t := newThrottles()
+			e := api.Event{Reason: "Killing", InvolvedObject: api.ObjectReference{Kind: "Node"}}
+			Expect(t.For(e)).To(Equal(&Exponential{Base: time.Minute, Max: time.Hour}))
+		})
+
+		It("should fall back to the default", func() {
+			t := newThrottles()
+			e := api.Event{Reason: "Killing", InvolvedObject: api.ObjectReference{Kind: "Service"}}
+			Expect(t.For(e)).To(Equal(&Linear{Interval: time.Minute}))
+		})
+	})
+
+	Context("with an invalid override", func() {
+		It("should return an error", func() {
+			cfg.ThrottleKinds = []string{"Pod"}
+			_, err := New(cfg)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid 'KIT_OVERWATCH_THROTTLE_KIND'"))
+		})
+	})
+})
+
+var _ = Describe("Parse", func() {
+	It("should parse each strategy", func() {
+		Expect(Parse("none")).To(Equal(None{}))
+		Expect(Parse("linear:30s")).To(Equal(&Linear{Interval: 30 * time.Second}))
+		Expect(Parse("exponential:1m:1h")).To(Equal(&Exponential{Base: time.Minute, Max: time.Hour}))
+		Expect(Parse("window:5:10m")).To(Equal(&FixedWindow{Limit: 5, Window: 10 * time.Minute}))
+		Expect(Parse("bucket:3:5m")).To(Equal(&TokenBucket{Burst: 3, Refill: 5 * time.Minute}))
+	})
+
+	It("should return an error for unknown strategies", func() {
+		_, err := Parse("random:1m")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error for the wrong number of arguments", func() {
+		_, err := Parse("exponential:1m")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error for invalid arguments", func() {
+		for _, spec := range []string{"linear:soon", "linear:0s", "window:0:1m", "bucket:x:1m", "exponential:1h:1m"} {
+			_, err := Parse(spec)
+			Expect(err).To(HaveOccurred(), spec)
+		}
+	})
+})
@@ -1,148 +1,661 @@
 package watcher
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/cenkalti/backoff"
 	"k8s.io/kubernetes/pkg/api"
-	"k8s.io/kubernetes/pkg/apis/batch"
-	"k8s.io/kubernetes/pkg/apis/extensions"
-	"k8s.io/kubernetes/pkg/client/restclient"
 	client "k8s.io/kubernetes/pkg/client/unversioned"
-	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/util/clock"
 	"k8s.io/kubernetes/pkg/watch"
 
+	"github.com/InVisionApp/kit-overwatch/aggregate"
+	"github.com/InVisionApp/kit-overwatch/catchup"
 	"github.com/InVisionApp/kit-overwatch/config"
+	"github.com/InVisionApp/kit-overwatch/containers"
 	dependencies "github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/events"
+	"github.com/InVisionApp/kit-overwatch/filter"
+	"github.com/InVisionApp/kit-overwatch/flap"
+	"github.com/InVisionApp/kit-overwatch/incident"
+	"github.com/InVisionApp/kit-overwatch/informer"
+	"github.com/InVisionApp/kit-overwatch/kubeconfig"
+	"github.com/InVisionApp/kit-overwatch/mention"
 	"github.com/InVisionApp/kit-overwatch/notifiers"
 	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/objects"
+	"github.com/InVisionApp/kit-overwatch/owners"
+	"github.com/InVisionApp/kit-overwatch/recovery"
+	"github.com/InVisionApp/kit-overwatch/rest"
+	"github.com/InVisionApp/kit-overwatch/rollout"
+	"github.com/InVisionApp/kit-overwatch/state"
+	"github.com/InVisionApp/kit-overwatch/storm"
+	"github.com/InVisionApp/kit-overwatch/throttle"
 )
 
+const (
+	CHECKPOINT_INTERVAL = 10 * time.Second
+	DEFAULT_STATSD_RATE = 1.0
+
+	// How long to collect events for the catch up summary after starting; the initial lists are through well before then
+	CATCH_UP_DELAY = 30 * time.Second
+)
+
+// Watcher turns a cluster's events into notifications. Events come from Events,
+// mentions from Mentions and notifications go to Sink, ahead of the Notifiers they
+// end up at. The optional stages (Storm, Flap, Aggregate, Incidents, Recovery),
+// caches (Objects, Filter) and the pod and rollout monitors (Pods, Workloads) are
+// left out when nil.
 type Watcher struct {
-	Client       client.Client
-	ClientConfig restclient.Config
 	Config       config.Config
 	Dependencies *dependencies.Dependencies
-}
+	Events       dependencies.IEventSources
+	Mentions     dependencies.IMentionResolver
+	Pods         dependencies.IObjectLister
+	Workloads    dependencies.IObjectLister
+	Clock        clock.Clock
+	Throttles    *throttle.Throttles
+	Sink         deps.Sink
+	Storm        *storm.Detector
+	Flap         *flap.Detector
+	Incidents    *incident.Tracker
+	Objects      *objects.Cache
+	Notifiers    deps.Drainer
+	Aggregate    *aggregate.Aggregator
+	Recovery     *recovery.Tracker
+	Filter       *filter.Filter
 
-type WatcherEvent struct {
-	Event      api.Event
-	WatchEvent watch.Event
-}
+	// When taking over from a previous leader, the last time it renewed its lock.
+	// Events that last happened before then were already notified by that leader.
+	HandoverTime time.Time
+
+	catchUp *catchup.Summary
 
-type SentEvent struct {
-	LastSent time.Time
-	Count    int
+	lock      sync.Mutex
+	stopping  bool
+	inflight  sync.WaitGroup
+	err       error
+	informers map[string]*informer.Informer
 }
 
-func New(cfg *config.Config, d *dependencies.Dependencies) *Watcher {
-	var c *client.Client
-	var cErr error
-	var clientConfig *restclient.Config
+// New sets up watching the cluster the config points at. Failing to reach the
+// cluster isn't an error here, it is retried once watching and reported by Health.
+func New(cfg *config.Config, d *dependencies.Dependencies) (*Watcher, error) {
+	clientConfig, err := kubeconfig.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to configure kube client: %v", err.Error())
+	}
+	c, err := client.New(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to instantiate kube client: %v", err.Error())
+	}
 
-	if cfg.InCluster {
-		var confErr error
-		clientConfig, confErr = restclient.InClusterConfig()
-		if confErr != nil {
-			log.Fatalf("Unable to instantiate in cluster config: %v", cErr.Error())
-		}
-		c, cErr = client.New(clientConfig)
-		if cErr != nil {
-			log.Fatalf("Unable to instantiate kube client in cluster: %v", cErr.Error())
-		}
-	} else {
-		clientConfig = &restclient.Config{
-			Host: cfg.ClusterHost,
-		}
-		c, cErr = client.New(clientConfig)
-		if cErr != nil {
-			log.Fatalf("Unable to instantiate kube client: %v", cErr.Error())
-		}
+	throttles, err := throttle.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to instantiate throttles: %v", err.Error())
+	}
+
+	nsFilter, err := filter.New(cfg, c.Namespaces())
+	if err != nil {
+		return nil, fmt.Errorf("Unable to instantiate namespace filter: %v", err.Error())
+	}
+
+	// Involved objects and their owners are looked up in a local cache of the cluster
+	kube := owners.NewKube(c)
+	dynamic := owners.NewDynamic(c.Discovery(), rest.New(c.RESTClient))
+	kube.Dynamic = dynamic
+	objectCache := objects.New(cfg, kube, kube, namespaces(cfg), d)
+	resolver := owners.New(objectCache)
+	mentions := mention.New(cfg, resolver)
+
+	// Optionally group notifications into incidents, and collapse notifications about
+	// pods of the same workload before that
+	notifier := notifiers.New(cfg, d)
+	var sink deps.Sink = notifier
+	var tracker *incident.Tracker
+	if cfg.Incidents {
+		tracker = incident.New(cfg, resolver, sink)
+		sink = tracker
+	}
+	var aggregator *aggregate.Aggregator
+	if cfg.AggregateWindow > 0 {
+		aggregator = aggregate.New(cfg, resolver, sink)
+		sink = aggregator
+	}
+
+	// Follow up problems with a notification once they clear
+	var recoveryTracker *recovery.Tracker
+	if cfg.Resolutions {
+		recoveryTracker = recovery.New(kube, sink)
+		sink = recoveryTracker
+	}
+
+	var stormDetector *storm.Detector
+	if cfg.StormThreshold > 0 {
+		stormDetector = storm.New(cfg, mentions, sink)
+	}
+
+	var flapDetector *flap.Detector
+	if cfg.FlapThreshold > 0 {
+		flapDetector = flap.New(cfg, mentions, sink)
+	}
+
+	// Optionally watch pods for what happens to their containers
+	var pods dependencies.IObjectLister
+	if cfg.PodMonitor {
+		pods = kube
+	}
+
+	// Optionally follow rollouts, over REST as the client predates most of what they're followed by
+	var workloads dependencies.IObjectLister
+	if cfg.RolloutMonitor {
+		workloads = rollout.NewAPI(rest.New(c.RESTClient), dynamic)
 	}
 
-	return &Watcher{
-		Client:       *c,
-		ClientConfig: *clientConfig,
+	w := &Watcher{
 		Config:       *cfg,
 		Dependencies: d,
+		Events:       events.NewSources(cfg, c),
+		Mentions:     mentions,
+		Pods:         pods,
+		Workloads:    workloads,
+		Clock:        clock.RealClock{},
+		Throttles:    throttles,
+		Sink:         sink,
+		Storm:        stormDetector,
+		Flap:         flapDetector,
+		Incidents:    tracker,
+		Objects:      objectCache,
+		Notifiers:    notifier,
+		Aggregate:    aggregator,
+		Recovery:     recoveryTracker,
+		Filter:       nsFilter,
+		informers:    make(map[string]*informer.Informer),
+	}
+
+	// What the detectors, the recovery tracker and the aggregator send in the background is waited for by Shutdown
+	if stormDetector != nil {
+		stormDetector.Spawn = w.spawn
+	}
+	if flapDetector != nil {
+		flapDetector.Spawn = w.spawn
 	}
+	if recoveryTracker != nil {
+		recoveryTracker.Spawn = w.spawn
+	}
+	if aggregator != nil {
+		aggregator.Spawn = w.spawn
+	}
+	return w, nil
 }
 
-func (w *Watcher) Watch() {
-	startTime := time.Now()
-	pastEvents := make(map[types.UID]WatcherEvent)
-	sentEvents := make(map[types.UID]SentEvent)
+// Watch processes events until the context is done. Call Shutdown afterwards to
+// send the notifications still in flight and save the state.
+func (w *Watcher) Watch(ctx context.Context) {
+	startTime := w.Clock.Now()
 
-	opts := api.ListOptions{
-		ResourceVersion: "0",
-	}
-	cw, err := w.Client.Events(w.Config.Namespace).Watch(opts)
+	// Events that happened after our last checkpoint were missed while we were down, so don't skip them
+	checkpoint, err := w.Dependencies.State.Checkpoint(w.Config.ClusterName)
 	if err != nil {
-		log.Fatalf("Unable to instantiate events watcher: %v", err.Error())
+		log.Errorf("Unable to get state checkpoint: %v", err.Error())
 	}
+	go w.checkpoint(ctx)
 
-	// Get the events channel
-	ec := cw.ResultChan()
-	log.Info("Watching for events...")
+	// The cluster may not be reachable yet, which mustn't stop any other cluster being watched
+	if w.Filter != nil {
+		if !w.refreshFilter(ctx) {
+			return
+		}
+		go w.Filter.Run(ctx)
+	}
+	if w.Objects != nil {
+		w.Objects.Run(ctx)
+	}
 
-	// Process event channel
-	for we := range ec {
-		log.Infof("%s event detected", we.Type)
+	// The stages stop with the context, and Shutdown waits for whatever they are sending
+	if w.Storm != nil {
+		w.spawn(func() { w.Storm.Run(ctx) })
+	}
+	if w.Flap != nil {
+		w.spawn(func() { w.Flap.Run(ctx) })
+	}
+	if w.Incidents != nil {
+		w.spawn(func() { w.Incidents.Run(ctx) })
+	}
+	if w.Recovery != nil {
+		w.spawn(func() { w.Recovery.Run(ctx) })
+	}
+	if w.Aggregate != nil {
+		w.spawn(func() { w.Aggregate.Run(ctx) })
+	}
 
-		// When an event occurs, get list of events
-		list, err := w.Client.Events(w.Config.Namespace).List(api.ListOptions{
-			ResourceVersion: "0",
-		})
-		if err != nil {
-			log.Fatalf("Unable to get events: %v", err.Error())
-		}
-		for _, e := range list.Items {
-			// Only log if we haven't logged before
-			past, ok := pastEvents[e.ObjectMeta.UID]
-			if ok {
-				// Only skip if the count hasn't increased
-				if e.Count == past.Event.Count {
-					log.Debugf("Skip: already notified for %s / %s / %s", e.ObjectMeta.UID, e.Reason, e.Message)
-					continue
-				}
+	// An informer per namespace lists once and then feeds us every change exactly once
+	deltas := make(chan informer.Delta, informer.DELTA_BUFFER)
+	forward := func(in <-chan informer.Delta) {
+		// Keep draining once stopped so the sender isn't blocked from closing
+		for d := range in {
+			select {
+			case deltas <- d:
+			case <-ctx.Done():
 			}
+		}
+	}
+	for _, ns := range namespaces(&w.Config) {
+		inf := informer.New(w.Events.Events(ns), w.Dependencies)
+		w.lock.Lock()
+		w.informers[ns] = inf
+		w.lock.Unlock()
+		go inf.Run(ctx)
+		go forward(inf.Deltas)
+	}
+
+	// The pod monitor's synthetic events about containers are handled like any other
+	if w.Pods != nil {
+		for _, ns := range namespaces(&w.Config) {
+			mon := containers.New(w.Pods, ns)
+			go mon.Run(ctx)
+			go forward(mon.Deltas)
+		}
+	}
 
-			// Remember this event so we don't send duplicate notifications
-			pastEvents[e.ObjectMeta.UID] = WatcherEvent{
-				Event:      e,
-				WatchEvent: we,
+	// So are the rollout monitor's, about each kind of workload the cluster serves
+	if w.Workloads != nil {
+		for _, ns := range namespaces(&w.Config) {
+			for _, kind := range rollout.Kinds {
+				mon := rollout.New(w.Workloads, kind, ns)
+				go mon.Run(ctx)
+				go forward(mon.Deltas)
 			}
+		}
+	}
+
+	// In catch up mode events from before the lookback window are summarised once the initial lists are through
+	var catchUp <-chan time.Time
+	if w.Config.CatchUp {
+		w.catchUp = catchup.New()
+		catchUp = w.Clock.After(CATCH_UP_DELAY)
+	}
+
+	// Process the delta pipeline
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("Stopped watching events")
+			return
+		case <-catchUp:
+			catchUp = nil
+			w.sendCatchUp()
+		case d := <-deltas:
+			e := d.Event
 
-			// Only log events that have happened since the service started
-			diff := startTime.Sub(e.LastTimestamp.Time)
-			if int(diff.Minutes()) > 1 {
-				log.Debugf("Skip: %s / %s / %s - %s happened more than a minute before service started", e.ObjectMeta.UID, e.Reason, e.Message, e.LastTimestamp)
+			// Deleted events have expired in the cluster, there is nothing to notify about
+			if d.Type == watch.Deleted {
+				log.Debugf("Skip: event deleted %s / %s / %s", e.ObjectMeta.UID, e.Reason, e.Message)
 				continue
 			}
 
-			// Throttle duplicate events so we don't notify too many times
-			sent, ok := sentEvents[e.ObjectMeta.UID]
-			var count int
-			if ok {
-				canSendAfter := sent.LastSent.Add(time.Minute * time.Duration(sent.Count))
-				if time.Now().After(canSendAfter) {
-					count = sent.Count + 1
-				} else {
-					log.Debugf("Skip: throttle back notifications %v minutes for %s / %s / %s", sent.Count, e.ObjectMeta.UID, e.Reason, e.Message)
-					continue
-				}
+			if w.Filter != nil && !w.Filter.Allowed(e.ObjectMeta.Namespace) {
+				log.Debugf("Skip: namespace %s is filtered out for %s / %s / %s", e.ObjectMeta.Namespace, e.ObjectMeta.UID, e.Reason, e.Message)
+				continue
+			}
+
+			// Recovery resolves problems even when it isn't notified about itself
+			if w.Recovery != nil {
+				w.Recovery.Observe(e)
+			}
+
+			if !w.record(e, startTime, checkpoint) {
+				continue
 			}
-			sentEvents[e.ObjectMeta.UID] = SentEvent{
-				LastSent: time.Now(),
-				Count:    count,
+
+			// A flapping object gets a single notification until it is stable again
+			if w.Flap != nil && w.Flap.Observe(e, w.getLevel(e)) {
+				log.Debugf("Skip: %s / %s / %s is about a flapping %s", e.ObjectMeta.UID, e.Reason, e.Message, e.InvolvedObject.Kind)
+				continue
+			}
+
+			// During a storm a single summary is sent instead
+			if w.Storm != nil && w.Storm.Observe(e, w.getLevel(e)) {
+				log.Debugf("Skip: %s / %s / %s is part of an event storm", e.ObjectMeta.UID, e.Reason, e.Message)
+				continue
 			}
 
 			// Generate and send the notification
-			go w.notify(e)
+			w.spawn(func() {
+				w.notify(d)
+			})
+		}
+	}
+}
+
+// Unavailable stands in for a cluster that couldn't be set up to be watched, eg.
+// because its credentials couldn't be loaded, so it still shows in health checks
+type Unavailable struct {
+	Cluster string
+	Err     error
+}
+
+func (u *Unavailable) Name() string {
+	return u.Cluster
+}
+
+func (u *Unavailable) Health() error {
+	return u.Err
+}
+
+// Current reports on whichever watcher is set up for a cluster. A watcher can't be
+// restarted once shut down, so a fresh one is set up each time the cluster's leader
+// lock is won again.
+type Current struct {
+	lock   sync.RWMutex
+	status dependencies.IClusterStatus
+}
+
+func NewCurrent(status dependencies.IClusterStatus) *Current {
+	return &Current{status: status}
+}
+
+// Set replaces what is reported on
+func (c *Current) Set(status dependencies.IClusterStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.status = status
+}
+
+func (c *Current) Name() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.status.Name()
+}
+
+func (c *Current) Health() error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.status.Health()
+}
+
+// Lists the namespaces to filter by, retrying with backoff until it works or the
+// context is done. Reports whether it worked.
+func (w *Watcher) refreshFilter(ctx context.Context) bool {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 0
+
+	for {
+		err := w.Filter.Refresh()
+		w.lock.Lock()
+		w.err = err
+		w.lock.Unlock()
+		if err == nil {
+			return true
+		}
+
+		wait := b.NextBackOff()
+		log.Errorf("Unable to list namespaces of cluster %s, retrying in %v: %v", w.Config.ClusterName, wait, err.Error())
+		select {
+		case <-ctx.Done():
+			return false
+		case <-w.Clock.After(wait):
+		}
+	}
+}
+
+// Name returns the display name of the cluster watched
+func (w *Watcher) Name() string {
+	return w.Config.ClusterName
+}
+
+// Health returns why the cluster can't currently be watched, or nil if it can or
+// watching hasn't started, eg. while waiting to become the leader
+func (w *Watcher) Health() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	for ns, inf := range w.informers {
+		if err := inf.Err(); err != nil {
+			if ns == api.NamespaceAll {
+				return err
+			}
+			return fmt.Errorf("namespace %s: %v", ns, err.Error())
+		}
+	}
+	return nil
+}
+
+// Reports whether every informer has listed the cluster's events and is watching them
+func (w *Watcher) synced() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.err != nil || len(w.informers) == 0 {
+		return false
+	}
+	for _, inf := range w.informers {
+		if !inf.Synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Records the event in the state store and reports whether to notify about it.
+// Other instances may share the store, so the state is only written if it hasn't
+// changed since we read it; otherwise we re-read it and decide again.
+func (w *Watcher) record(e api.Event, startTime, checkpoint time.Time) bool {
+	uid := string(e.ObjectMeta.UID)
+	for {
+		old, err := w.Dependencies.State.Get(uid)
+		if err != nil {
+			log.Errorf("Unable to get state for %s: %v", uid, err.Error())
+		}
+
+		// Only skip if the count hasn't increased since we (or another instance) last saw it
+		st := &state.EventState{}
+		if old != nil {
+			if e.Count == old.Count {
+				log.Debugf("Skip: already notified for %s / %s / %s", e.ObjectMeta.UID, e.Reason, e.Message)
+				return false
+			}
+			*st = *old
+		}
+
+		// Remember this event so we don't send duplicate notifications
+		st.Count = e.Count
+		st.LastTimestamp = e.LastTimestamp.Time
+		send, catchUp := w.shouldSend(e, st, startTime, checkpoint)
+
+		swapped, err := w.Dependencies.State.CompareAndSet(uid, old, st)
+		if err == state.ErrStoreFull {
+			// Without state we can't dedup, so don't risk notifying about it on every update
+			log.Warnf("Skip: state store is full, not tracking %s / %s / %s", e.ObjectMeta.UID, e.Reason, e.Message)
+			go w.Dependencies.StatsD.Inc("state.rejected", 1, DEFAULT_STATSD_RATE)
+			return false
+		}
+		if err != nil {
+			log.Errorf("Unable to save state for %s: %v", uid, err.Error())
+			return send
 		}
+		if swapped {
+			// Only once, as another instance may have recorded it while we retried
+			if catchUp {
+				w.catchUp.Add(e, w.getLevel(e))
+			}
+			return send
+		}
+		log.Debugf("State for %s changed while processing %s / %s, retrying", uid, e.Reason, e.Message)
+	}
+}
+
+// Decides whether to notify about an event, recording the send in its state if so,
+// or whether to add it to the catch up summary once its state is recorded
+func (w *Watcher) shouldSend(e api.Event, st *state.EventState, startTime, checkpoint time.Time) (send, catchUp bool) {
+	// Don't re-send what a previous leader already sent
+	if !w.HandoverTime.IsZero() && !e.LastTimestamp.Time.After(w.HandoverTime) {
+		log.Debugf("Skip: %s / %s / %s - %s happened before taking over from the previous leader", e.ObjectMeta.UID, e.Reason, e.Message, e.LastTimestamp)
+		return false, false
 	}
 
-	log.Fatalf("Event watching has ended")
+	// Only log events that have happened within the lookback window before the service started, or since we last checkpointed before a restart.
+	// Without a checkpoint we don't know what was missed.
+	lookback := time.Duration(w.Config.Lookback) * time.Second
+	if startTime.Sub(e.LastTimestamp.Time) > lookback {
+		if checkpoint.IsZero() || !e.LastTimestamp.Time.After(checkpoint) {
+			log.Debugf("Skip: %s / %s / %s - %s happened more than %v before service started", e.ObjectMeta.UID, e.Reason, e.Message, e.LastTimestamp, lookback)
+			return false, false
+		}
+		if w.catchUp != nil {
+			log.Debugf("Catch up: %s / %s / %s - %s happened before the lookback window, adding it to the summary", e.ObjectMeta.UID, e.Reason, e.Message, e.LastTimestamp)
+			return false, true
+		}
+	}
+
+	// Throttle duplicate events so we don't notify too many times
+	if !w.Throttles.Allow(e, st) {
+		log.Debugf("Skip: throttle back notifications after %d sent for %s / %s / %s", st.SendCount, e.ObjectMeta.UID, e.Reason, e.Message)
+		return false, false
+	}
+
+	return true, false
+}
+
+// Sends a single notification summarising the events that happened before the lookback window
+func (w *Watcher) sendCatchUp() {
+	summary := w.catchUp
+	w.catchUp = nil
+
+	if summary.Len() == 0 {
+		log.Infof("Catch up: no events happened while not watching")
+		return
+	}
+
+	log.Infof("Catch up: sending a summary of %d events that happened while not watching", summary.Len())
+	n := summary.Notification(w.Config.ClusterName, w.Config.MentionDefault)
+	w.spawn(func() {
+		w.send(n)
+	})
+}
+
+// Shutdown waits for the stages and the notifications being generated to be sent,
+// passes on the ones held back for aggregation, then saves a final checkpoint. The
+// state store is shared between clusters so is left open. Watch's context must be
+// done first, which stops the stages. Incidents and problems still open can't be
+// followed up any more, so they are logged and forgotten. Gives up waiting when ctx
+// is done, returning its error, and then doesn't checkpoint as not everything up to
+// now was processed.
+func (w *Watcher) Shutdown(ctx context.Context) error {
+	w.lock.Lock()
+	w.stopping = true
+	w.lock.Unlock()
+
+	var err error
+	log.Infof("Waiting for notifications in flight to be sent...")
+	if err = within(ctx, w.inflight.Wait); err != nil {
+		log.Errorf("Gave up waiting for notifications in flight: %v", err.Error())
+	}
+
+	if w.Aggregate != nil && err == nil {
+		if err = within(ctx, w.Aggregate.Flush); err != nil {
+			log.Errorf("Gave up sending aggregated notifications: %v", err.Error())
+		}
+	}
+
+	if nErr := w.Notifiers.Shutdown(ctx); nErr != nil {
+		log.Errorf("Gave up waiting for notifiers: %v", nErr.Error())
+		err = nErr
+	}
+
+	if w.Incidents != nil {
+		w.Incidents.Abandon()
+	}
+	if w.Recovery != nil {
+		w.Recovery.Abandon()
+	}
+
+	switch {
+	case err != nil:
+		log.Warnf("Not saving a checkpoint for cluster %s: not every notification was sent", w.Config.ClusterName)
+	case !w.synced():
+		log.Warnf("Not saving a checkpoint for cluster %s: its events weren't being watched", w.Config.ClusterName)
+	default:
+		if sErr := w.Dependencies.State.SetCheckpoint(w.Config.ClusterName, w.Clock.Now()); sErr != nil {
+			log.Errorf("Unable to save state checkpoint: %v", sErr.Error())
+		}
+	}
+
+	return err
+}
+
+// Runs f in the background so Shutdown can wait for it, unless already shutting down
+func (w *Watcher) spawn(f func()) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.stopping {
+		log.Debugf("Skip: shutting down")
+		return
+	}
+
+	w.inflight.Add(1)
+	go func() {
+		defer w.inflight.Done()
+		f()
+	}()
+}
+
+// Runs f, returning early with the context's error if it is done first
+func within(ctx context.Context, f func()) error {
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Periodically records that we are alive and processing events, and how many events we track.
+// The checkpoint only advances while every informer is watching, so events that
+// happen while the cluster is unreachable are caught up on after a restart.
+func (w *Watcher) checkpoint(ctx context.Context) {
+	for {
+		if !w.synced() {
+			log.Debugf("Not advancing the checkpoint for cluster %s: its events aren't being watched", w.Config.ClusterName)
+		} else if err := w.Dependencies.State.SetCheckpoint(w.Config.ClusterName, w.Clock.Now()); err != nil {
+			log.Errorf("Unable to save state checkpoint: %v", err.Error())
+		}
+
+		if n, err := w.Dependencies.State.Len(); err != nil {
+			log.Errorf("Unable to count tracked events: %v", err.Error())
+		} else {
+			go w.Dependencies.StatsD.Gauge("state.tracked", int64(n), DEFAULT_STATSD_RATE)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.Clock.After(CHECKPOINT_INTERVAL):
+		}
+	}
+}
+
+// The namespaces to watch events in; a single cluster wide watch when watching all namespaces
+func namespaces(cfg *config.Config) []string {
+	if cfg.WatchAllNamespaces() {
+		return []string{api.NamespaceAll}
+	}
+
+	return cfg.Namespaces
 }
 
 func (w *Watcher) getLevel(e api.Event) string {
@@ -181,6 +694,13 @@ func (w *Watcher) getLevel(e api.Event) string {
 		"DeletingAllPods":         "WARN",
 		"DeletingNode":            "WARN",
 		"UpdatedLoadBalancer":     "INFO",
+		"CrashLoopBackOff":        "ERROR",
+		"OOMKilled":               "ERROR",
+		"ContainerFailed":         "WARN",
+		"ContainerRestarted":      "WARN",
+		"RolloutStarted":          "INFO",
+		"RolloutCompleted":        "INFO",
+		"RolloutStalled":          "ERROR",
 	}
 
 	var ok bool
@@ -191,65 +711,28 @@ func (w *Watcher) getLevel(e api.Event) string {
 	return reasonLevels[e.Reason]
 }
 
-func (w *Watcher) notify(e api.Event) {
+func (w *Watcher) notify(d informer.Delta) {
+	e := d.Event
+
 	// Determine notification level
 	level := w.getLevel(e)
 
-	// Get label to use as mention in notification
-	var mention string
-	var rErr error
-	var rOk bool
-	ec, err := client.NewExtensions(&w.ClientConfig)
-	if err != nil {
-		log.Fatalf("Unable to instantiate new ExtensionsClient: %v", err.Error())
-	}
-	switch e.InvolvedObject.Kind {
-	case "Pod":
-		var resource *api.Pod
-		resource, rErr = w.Client.Pods(w.Config.Namespace).Get(e.InvolvedObject.Name)
-		mention, rOk = resource.ObjectMeta.Labels[w.Config.MentionLabel]
-	case "Service":
-		var resource *api.Service
-		resource, rErr = w.Client.Services(w.Config.Namespace).Get(e.InvolvedObject.Name)
-		mention, rOk = resource.ObjectMeta.Labels[w.Config.MentionLabel]
-	case "Node":
-		var resource *api.Node
-		resource, rErr = w.Client.Nodes().Get(e.InvolvedObject.Name)
-		mention, rOk = resource.ObjectMeta.Labels[w.Config.MentionLabel]
-	case "Deployment":
-		var resource *extensions.Deployment
-		resource, rErr = ec.Deployments(w.Config.Namespace).Get(e.InvolvedObject.Name)
-		mention, rOk = resource.ObjectMeta.Labels[w.Config.MentionLabel]
-	case "ReplicaSet":
-		var resource *extensions.ReplicaSet
-		resource, rErr = ec.ReplicaSets(w.Config.Namespace).Get(e.InvolvedObject.Name)
-		mention, rOk = resource.ObjectMeta.Labels[w.Config.MentionLabel]
-	case "Job":
-		var resource *batch.Job
-		resource, rErr = ec.Jobs(w.Config.Namespace).Get(e.InvolvedObject.Name)
-		mention, rOk = resource.ObjectMeta.Labels[w.Config.MentionLabel]
-	case "DaemonSet":
-		var resource *extensions.DaemonSet
-		resource, rErr = ec.DaemonSets(w.Config.Namespace).Get(e.InvolvedObject.Name)
-		mention, rOk = resource.ObjectMeta.Labels[w.Config.MentionLabel]
-	default:
-		log.Debugf("Cannot retrieve label for unsported Kind: %s", e.InvolvedObject.Kind)
-	}
-	if rErr != nil {
-		log.Warnf("Unable to get %s: %v", e.InvolvedObject.Kind, rErr.Error())
-	}
-	if !rOk {
-		log.Warnf("Mention label not found for %s: %s, using default: %s", e.InvolvedObject.Kind, e.InvolvedObject.Name, w.Config.MentionDefault)
-		mention = w.Config.MentionDefault
-	}
+	// Get label to use as mention in notification, from the object or whatever owns it
+	mention := w.Mentions.ResolveEvent(e)
 
 	// Send notifications
-	n := notifiers.New(&w.Config, w.Dependencies)
-	notification := deps.Notification{
-		Cluster: w.Config.ClusterName,
-		Event:   e,
-		Level:   level,
-		Mention: mention,
-	}
-	n.SendAll(&notification)
+	w.send(&deps.Notification{
+		Cluster:   w.Config.ClusterName,
+		Namespace: e.ObjectMeta.Namespace,
+		Event:     e,
+		Level:     level,
+		Mention:   mention,
+		Details:   d.Details,
+		Container: d.Container,
+		Rollout:   d.Rollout,
+	})
+}
+
+func (w *Watcher) send(notification *deps.Notification) {
+	w.Sink.SendAll(notification)
 }
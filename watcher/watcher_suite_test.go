@@ -0,0 +1,13 @@
+package watcher
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestWatcherSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Watcher Suite")
+}
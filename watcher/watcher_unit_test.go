@@ -0,0 +1,600 @@
+// +build unit
+
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cactus/go-statsd-client/statsd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/util/clock"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/InVisionApp/kit-overwatch/catchup"
+	"github.com/InVisionApp/kit-overwatch/config"
+	dependencies "github.com/InVisionApp/kit-overwatch/deps"
+	"github.com/InVisionApp/kit-overwatch/fakes/depsfakes"
+	"github.com/InVisionApp/kit-overwatch/fakes/notifiersfakes"
+	"github.com/InVisionApp/kit-overwatch/filter"
+	"github.com/InVisionApp/kit-overwatch/informer"
+	"github.com/InVisionApp/kit-overwatch/notifiers/deps"
+	"github.com/InVisionApp/kit-overwatch/owners"
+	"github.com/InVisionApp/kit-overwatch/recovery"
+	"github.com/InVisionApp/kit-overwatch/rollout"
+	"github.com/InVisionApp/kit-overwatch/state"
+	"github.com/InVisionApp/kit-overwatch/throttle"
+)
+
+func newEvent(uid, namespace, reason string, count int32, last time.Time) api.Event {
+	return api.Event{
+		ObjectMeta: api.ObjectMeta{
+			UID:             types.UID(uid),
+			Name:            uid,
+			Namespace:       namespace,
+			ResourceVersion: fmt.Sprintf("%d", count),
+		},
+		InvolvedObject: api.ObjectReference{Kind: "Pod", Name: "web-1"},
+		Reason:         reason,
+		Message:        reason + " for web-1",
+		Count:          count,
+		FirstTimestamp: unversioned.NewTime(last),
+		LastTimestamp:  unversioned.NewTime(last),
+	}
+}
+
+func ptr(e api.Event) *api.Event {
+	return &e
+}
+
+// A store where another instance records the state first, the first time it is set
+type racingStore struct {
+	state.StateStore
+	other *state.EventState
+}
+
+func (s *racingStore) CompareAndSet(uid string, old, new *state.EventState) (bool, error) {
+	if s.other != nil {
+		other := s.other
+		s.other = nil
+		if _, err := s.StateStore.CompareAndSet(uid, old, other); err != nil {
+			return false, err
+		}
+	}
+	return s.StateStore.CompareAndSet(uid, old, new)
+}
+
+var _ = Describe("Watcher", func() {
+	var (
+		cfg          *config.Config
+		clk          *clock.FakeClock
+		start        time.Time
+		store        *state.Memory
+		fakeSources  *depsfakes.FakeIEventSources
+		fakeSource   *depsfakes.FakeIEventSource
+		fakeWatch    *watch.FakeWatcher
+		fakeMentions *depsfakes.FakeIMentionResolver
+		fakeSink     *notifiersfakes.FakeDrainer
+		w            *Watcher
+	)
+
+	BeforeEach(func() {
+		start = time.Date(2016, 11, 29, 12, 0, 0, 0, time.UTC)
+		clk = clock.NewFakeClock(start)
+
+		cfg = config.New()
+		cfg.ClusterName = "prod"
+		cfg.Namespaces = []string{"default"}
+		cfg.Lookback = 60
+		cfg.Throttle = "none"
+		cfg.MentionDefault = "here"
+
+		store = state.NewMemory(cfg)
+		store.Clock = clk
+
+		fakeWatch = watch.NewFake()
+		fakeSource = &depsfakes.FakeIEventSource{}
+		fakeSource.ListReturns(&api.EventList{ListMeta: unversioned.ListMeta{ResourceVersion: "1"}}, nil)
+		fakeSource.WatchReturns(fakeWatch, nil)
+		fakeSources = &depsfakes.FakeIEventSources{}
+		fakeSources.EventsReturns(fakeSource)
+
+		fakeMentions = &depsfakes.FakeIMentionResolver{}
+		fakeMentions.ResolveEventReturns("payments")
+		fakeSink = &notifiersfakes.FakeDrainer{}
+	})
+
+	JustBeforeEach(func() {
+		throttles, err := throttle.New(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		throttles.Clock = clk
+
+		w = &Watcher{
+			Config: *cfg,
+			Dependencies: &dependencies.Dependencies{
+				StatsD: &statsd.NoopClient{},
+				State:  store,
+			},
+			Events:    fakeSources,
+			Mentions:  fakeMentions,
+			Clock:     clk,
+			Throttles: throttles,
+			Sink:      fakeSink,
+			Notifiers: fakeSink,
+			informers: make(map[string]*informer.Informer),
+		}
+	})
+
+	Describe("getLevel", func() {
+		It("should use the level of known reasons", func() {
+			Expect(w.getLevel(api.Event{Reason: "Scheduled"})).To(Equal("INFO"))
+			Expect(w.getLevel(api.Event{Reason: "NodeNotReady"})).To(Equal("WARN"))
+			Expect(w.getLevel(api.Event{Reason: "BackOff"})).To(Equal("ERROR"))
+		})
+
+		It("should treat unknown reasons as errors", func() {
+			Expect(w.getLevel(api.Event{Reason: "SomethingNew"})).To(Equal("ERROR"))
+		})
+	})
+
+	Describe("notify", func() {
+		It("should send a notification at the event's level with its mention and details", func() {
+			e := newEvent("a", "billing", "NodeNotReady", 1, start)
+			details := &deps.EventDetails{Action: "Binding"}
+			w.notify(informer.Delta{Event: e, Details: details})
+
+			Expect(fakeMentions.ResolveEventCallCount()).To(Equal(1))
+			Expect(fakeMentions.ResolveEventArgsForCall(0)).To(Equal(e))
+			Expect(fakeSink.SendAllCallCount()).To(Equal(1))
+			Expect(fakeSink.SendAllArgsForCall(0)).To(Equal(&deps.Notification{
+				Cluster:   "prod",
+				Namespace: "billing",
+				Event:     e,
+				Level:     "WARN",
+				Mention:   "payments",
+				Details:   details,
+			}))
+		})
+	})
+
+	Describe("record", func() {
+		var checkpoint time.Time
+
+		BeforeEach(func() {
+			checkpoint = time.Time{}
+		})
+
+		record := func(e api.Event) bool {
+			return w.record(e, start, checkpoint)
+		}
+
+		It("should notify about a new event", func() {
+			Expect(record(newEvent("a", "default", "BackOff", 1, start))).To(BeTrue())
+
+			st, err := store.Get("a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(st.Count).To(Equal(int32(1)))
+			Expect(st.SendCount).To(Equal(1))
+			Expect(st.LastSent).To(Equal(start))
+		})
+
+		It("should not notify about the same count twice", func() {
+			Expect(record(newEvent("a", "default", "BackOff", 1, start))).To(BeTrue())
+			Expect(record(newEvent("a", "default", "BackOff", 1, start))).To(BeFalse())
+		})
+
+		It("should notify again when the count goes up", func() {
+			Expect(record(newEvent("a", "default", "BackOff", 1, start))).To(BeTrue())
+			Expect(record(newEvent("a", "default", "BackOff", 2, start))).To(BeTrue())
+
+			st, _ := store.Get("a")
+			Expect(st.Count).To(Equal(int32(2)))
+			Expect(st.SendCount).To(Equal(2))
+		})
+
+		It("should notify about events within the lookback window", func() {
+			Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-time.Minute)))).To(BeTrue())
+		})
+
+		It("should skip events from before the lookback window but still track them", func() {
+			Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-time.Minute-time.Second)))).To(BeFalse())
+
+			st, _ := store.Get("a")
+			Expect(st).ToNot(BeNil())
+			Expect(st.SendCount).To(Equal(0))
+		})
+
+		Context("after a restart", func() {
+			BeforeEach(func() {
+				checkpoint = start.Add(-time.Hour)
+			})
+
+			It("should notify about events missed since the checkpoint", func() {
+				Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-30*time.Minute)))).To(BeTrue())
+			})
+
+			It("should skip events from before the checkpoint", func() {
+				Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-2*time.Hour)))).To(BeFalse())
+			})
+		})
+
+		Context("when catching up", func() {
+			BeforeEach(func() {
+				checkpoint = start.Add(-2 * time.Hour)
+			})
+
+			JustBeforeEach(func() {
+				w.catchUp = catchup.New()
+			})
+
+			It("should summarise events missed before the lookback window instead", func() {
+				Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-time.Hour)))).To(BeFalse())
+				Expect(w.catchUp.Len()).To(Equal(1))
+			})
+
+			It("should not summarise events from before the checkpoint", func() {
+				Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-3*time.Hour)))).To(BeFalse())
+				Expect(w.catchUp.Len()).To(Equal(0))
+			})
+
+			It("should not summarise anything without a checkpoint", func() {
+				checkpoint = time.Time{}
+				Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-time.Hour)))).To(BeFalse())
+				Expect(w.catchUp.Len()).To(Equal(0))
+			})
+
+			It("should not summarise an event another instance recorded first", func() {
+				w.Dependencies.State = &racingStore{StateStore: store, other: &state.EventState{Count: 1}}
+				Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-time.Hour)))).To(BeFalse())
+				Expect(w.catchUp.Len()).To(Equal(0))
+			})
+
+			It("should still notify about events within the window", func() {
+				Expect(record(newEvent("a", "default", "BackOff", 1, start))).To(BeTrue())
+				Expect(w.catchUp.Len()).To(Equal(0))
+			})
+		})
+
+		It("should skip what the previous leader already notified about", func() {
+			w.HandoverTime = start.Add(-10 * time.Second)
+			Expect(record(newEvent("a", "default", "BackOff", 1, start.Add(-20*time.Second)))).To(BeFalse())
+			Expect(record(newEvent("b", "default", "BackOff", 1, start.Add(-5*time.Second)))).To(BeTrue())
+		})
+
+		Context("when throttling", func() {
+			BeforeEach(func() {
+				cfg.Throttle = "linear:1m"
+			})
+
+			It("should hold back repeats until the throttle allows them", func() {
+				Expect(record(newEvent("a", "default", "BackOff", 1, start))).To(BeTrue())
+				Expect(record(newEvent("a", "default", "BackOff", 2, start))).To(BeFalse())
+
+				clk.Step(time.Minute)
+				Expect(record(newEvent("a", "default", "BackOff", 3, start))).To(BeTrue())
+			})
+
+			It("should still track the count of throttled repeats", func() {
+				record(newEvent("a", "default", "BackOff", 1, start))
+				record(newEvent("a", "default", "BackOff", 2, start))
+
+				st, _ := store.Get("a")
+				Expect(st.Count).To(Equal(int32(2)))
+				Expect(st.SendCount).To(Equal(1))
+			})
+		})
+
+		Context("when the state store is full", func() {
+			BeforeEach(func() {
+				cfg.StateStoreMaxEvents = 1
+				cfg.StateStoreOverflow = state.OVERFLOW_REJECT
+				store = state.NewMemory(cfg)
+				store.Clock = clk
+			})
+
+			It("should not notify about events it can't track", func() {
+				Expect(record(newEvent("a", "default", "BackOff", 1, start))).To(BeTrue())
+				Expect(record(newEvent("b", "default", "BackOff", 1, start))).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Watch", func() {
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+			done   chan struct{}
+		)
+
+		sent := func() []*deps.Notification {
+			var sent []*deps.Notification
+			for i := 0; i < fakeSink.SendAllCallCount(); i++ {
+				sent = append(sent, fakeSink.SendAllArgsForCall(i))
+			}
+			return sent
+		}
+
+		reasons := func() []string {
+			var reasons []string
+			for _, n := range sent() {
+				reasons = append(reasons, n.Event.Reason)
+			}
+			return reasons
+		}
+
+		run := func() {
+			ctx, cancel = context.WithCancel(context.Background())
+			done = make(chan struct{})
+			go func() {
+				defer close(done)
+				w.Watch(ctx)
+			}()
+		}
+
+		// Runs the watcher until it is watching the fake
+		watchEvents := func() {
+			run()
+			Eventually(fakeSource.WatchCallCount).Should(Equal(1))
+		}
+
+		AfterEach(func() {
+			if cancel != nil {
+				cancel()
+				Eventually(done).Should(BeClosed())
+			}
+		})
+
+		It("should watch each configured namespace", func() {
+			w.Config.Namespaces = []string{"default", "billing"}
+			fakeSources.EventsStub = func(namespace string) dependencies.IEventSource {
+				s := &depsfakes.FakeIEventSource{}
+				s.ListReturns(&api.EventList{}, nil)
+				s.WatchReturns(watch.NewFake(), nil)
+				return s
+			}
+			run()
+
+			Eventually(fakeSources.EventsCallCount).Should(Equal(2))
+			Expect([]string{fakeSources.EventsArgsForCall(0), fakeSources.EventsArgsForCall(1)}).To(ConsistOf("default", "billing"))
+		})
+
+		It("should notify about listed and watched events", func() {
+			fakeSource.ListReturns(&api.EventList{
+				ListMeta: unversioned.ListMeta{ResourceVersion: "1"},
+				Items:    []api.Event{newEvent("a", "default", "Scheduled", 1, start)},
+			}, nil)
+			watchEvents()
+
+			fakeWatch.Add(ptr(newEvent("b", "default", "BackOff", 1, start)))
+
+			Eventually(reasons).Should(ConsistOf("Scheduled", "BackOff"))
+			Expect(sent()[0].Mention).To(Equal("payments"))
+		})
+
+		It("should notify about an event again when it repeats", func() {
+			watchEvents()
+
+			fakeWatch.Add(ptr(newEvent("a", "default", "BackOff", 1, start)))
+			Eventually(fakeSink.SendAllCallCount).Should(Equal(1))
+
+			fakeWatch.Modify(ptr(newEvent("a", "default", "BackOff", 2, start)))
+			Eventually(fakeSink.SendAllCallCount).Should(Equal(2))
+		})
+
+		It("should not notify about deleted events", func() {
+			watchEvents()
+
+			fakeWatch.Delete(ptr(newEvent("a", "default", "BackOff", 1, start)))
+			fakeWatch.Add(ptr(newEvent("b", "default", "Scheduled", 1, start)))
+
+			Eventually(reasons).Should(ConsistOf("Scheduled"))
+			Consistently(reasons).Should(ConsistOf("Scheduled"))
+		})
+
+		It("should not notify about events in namespaces filtered out", func() {
+			cfg.NamespaceExclude = []string{"kube-*"}
+			fakeNamespaces := &depsfakes.FakeINamespaceClient{}
+			fakeNamespaces.ListReturns(&api.NamespaceList{}, nil)
+
+			f, err := filter.New(cfg, fakeNamespaces)
+			Expect(err).ToNot(HaveOccurred())
+			w.Filter = f
+			watchEvents()
+
+			fakeWatch.Add(ptr(newEvent("a", "kube-system", "BackOff", 1, start)))
+			fakeWatch.Add(ptr(newEvent("b", "default", "Scheduled", 1, start)))
+
+			Eventually(reasons).Should(ConsistOf("Scheduled"))
+			Consistently(reasons).Should(ConsistOf("Scheduled"))
+		})
+
+		It("should resolve problems with events that aren't notified about", func() {
+			cfg.Throttle = "linear:1m"
+			throttles, err := throttle.New(cfg)
+			Expect(err).ToNot(HaveOccurred())
+			throttles.Clock = clk
+			w.Throttles = throttles
+
+			tracker := recovery.New(&depsfakes.FakeIPodGetter{}, fakeSink)
+			tracker.Spawn = w.spawn
+			w.Recovery = tracker
+			w.Sink = tracker
+			watchEvents()
+
+			node := api.ObjectReference{Kind: "Node", Name: "node-1"}
+			ready := newEvent("r", "default", "NodeReady", 1, start)
+			ready.InvolvedObject = node
+			notReady := newEvent("n", "default", "NodeNotReady", 1, start)
+			notReady.InvolvedObject = node
+
+			fakeWatch.Add(ptr(ready))
+			Eventually(reasons).Should(ConsistOf("NodeReady"))
+			fakeWatch.Add(ptr(notReady))
+			Eventually(reasons).Should(ConsistOf("NodeReady", "NodeNotReady"))
+
+			// Ready again so soon is throttled, but still resolves the problem
+			ready.Count = 2
+			ready.ResourceVersion = "2"
+			fakeWatch.Modify(ptr(ready))
+			Eventually(reasons).Should(ConsistOf("NodeReady", "NodeNotReady", recovery.REASON_RESOLVED))
+			Consistently(reasons).Should(HaveLen(3))
+		})
+
+		It("should notify about what the pod monitor sees happen to containers", func() {
+			podWatch := watch.NewFake()
+			fakePods := &depsfakes.FakeIObjectLister{}
+			fakePods.ListReturns(&api.PodList{}, nil)
+			fakePods.WatchReturns(podWatch, nil)
+			w.Pods = fakePods
+			watchEvents()
+			Eventually(fakePods.WatchCallCount).Should(Equal(1))
+
+			podWatch.Modify(&api.Pod{
+				ObjectMeta: api.ObjectMeta{Name: "web-1", Namespace: "default", UID: types.UID("uid-1")},
+				Status: api.PodStatus{ContainerStatuses: []api.ContainerStatus{{
+					Name:  "web",
+					State: api.ContainerState{Terminated: &api.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+				}}},
+			})
+
+			Eventually(reasons).Should(ConsistOf("OOMKilled"))
+			n := sent()[0]
+			Expect(n.Level).To(Equal("ERROR"))
+			Expect(n.Mention).To(Equal("payments"))
+			Expect(n.Container.Name).To(Equal("web"))
+			Expect(n.Container.ExitCode).To(Equal(int32(137)))
+		})
+
+		It("should notify about rollouts of the kinds the cluster serves", func() {
+			deployments := watch.NewFake()
+			fakeWorkloads := &depsfakes.FakeIObjectLister{}
+			fakeWorkloads.ListStub = func(kind, namespace string, opts api.ListOptions) (runtime.Object, error) {
+				if kind != "Deployment" {
+					return nil, owners.UnsupportedKind{Kind: kind}
+				}
+				return &rollout.WorkloadList{Items: []rollout.Workload{{
+					ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Generation: 1},
+					Spec:       rollout.WorkloadSpec{Template: json.RawMessage(`{"spec":{"containers":[{"name":"web","image":"nginx:1.10"}]}}`)},
+					Status:     rollout.WorkloadStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+				}}}, nil
+			}
+			fakeWorkloads.WatchReturns(deployments, nil)
+			w.Workloads = fakeWorkloads
+			watchEvents()
+			Eventually(fakeWorkloads.WatchCallCount).Should(Equal(1))
+
+			deployments.Modify(&rollout.Workload{
+				ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Generation: 2},
+				Spec:       rollout.WorkloadSpec{Template: json.RawMessage(`{"spec":{"containers":[{"name":"web","image":"nginx:1.11"}]}}`)},
+				Status:     rollout.WorkloadStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1},
+			})
+
+			Eventually(reasons).Should(ConsistOf("RolloutStarted"))
+			n := sent()[0]
+			Expect(n.Level).To(Equal("INFO"))
+			Expect(n.Rollout.Images).To(Equal([]deps.ImageChange{{Container: "web", From: "nginx:1.10", To: "nginx:1.11"}}))
+		})
+
+		It("should send a single summary of the events it catches up on", func() {
+			w.Config.CatchUp = true
+			Expect(store.SetCheckpoint("prod", start.Add(-3*time.Hour))).To(Succeed())
+			fakeSource.ListReturns(&api.EventList{
+				ListMeta: unversioned.ListMeta{ResourceVersion: "1"},
+				Items: []api.Event{
+					newEvent("a", "default", "BackOff", 1, start.Add(-time.Hour)),
+					newEvent("b", "default", "BackOff", 1, start.Add(-2*time.Hour)),
+				},
+			}, nil)
+			watchEvents()
+
+			Eventually(func() int {
+				clk.Step(CATCH_UP_DELAY)
+				return fakeSink.SendAllCallCount()
+			}).Should(Equal(1))
+			Expect(sent()[0].Event.Message).To(HavePrefix("2 events happened"))
+		})
+
+		It("should report the cluster unhealthy while events can't be watched", func() {
+			fakeSource.ListReturns(nil, fmt.Errorf("connection refused"))
+			run()
+
+			Eventually(w.Health).Should(MatchError(ContainSubstring("connection refused")))
+			Expect(w.Name()).To(Equal("prod"))
+		})
+
+		It("should drain the notifiers and save a checkpoint when shut down", func() {
+			watchEvents()
+			cancel()
+			Eventually(done).Should(BeClosed())
+
+			clk.Step(time.Minute)
+			Expect(w.Shutdown(context.Background())).To(Succeed())
+			Expect(fakeSink.ShutdownCallCount()).To(Equal(1))
+
+			checkpoint, err := store.Checkpoint("prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checkpoint).To(Equal(start.Add(time.Minute)))
+		})
+
+		It("should not checkpoint when giving up on notifications in flight", func() {
+			watchEvents()
+			cancel()
+			Eventually(done).Should(BeClosed())
+
+			release := make(chan struct{})
+			defer close(release)
+			w.spawn(func() { <-release })
+
+			clk.Step(time.Minute)
+			ctx, giveUp := context.WithCancel(context.Background())
+			giveUp()
+			Expect(w.Shutdown(ctx)).To(MatchError(context.Canceled))
+			checkpoint, err := store.Checkpoint("prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checkpoint).To(BeTemporally("<", start.Add(time.Minute)))
+		})
+
+		It("should not checkpoint while events can't be watched", func() {
+			fakeSource.ListReturns(nil, fmt.Errorf("connection refused"))
+			run()
+			Eventually(w.Health).Should(HaveOccurred())
+			cancel()
+			Eventually(done).Should(BeClosed())
+
+			Expect(w.Shutdown(context.Background())).To(Succeed())
+			checkpoint, err := store.Checkpoint("prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checkpoint.IsZero()).To(BeTrue())
+		})
+
+		It("should not notify once shutting down", func() {
+			watchEvents()
+			cancel()
+			Eventually(done).Should(BeClosed())
+			Expect(w.Shutdown(context.Background())).To(Succeed())
+
+			w.spawn(func() {
+				w.notify(informer.Delta{Event: newEvent("a", "default", "BackOff", 1, start)})
+			})
+			Consistently(fakeSink.SendAllCallCount).Should(Equal(0))
+		})
+	})
+})
+
+var _ = Describe("Current", func() {
+	It("should report on whichever watcher was set last", func() {
+		c := NewCurrent(&Unavailable{Cluster: "prod", Err: fmt.Errorf("Unable to load kubeconfig")})
+		Expect(c.Name()).To(Equal("prod"))
+		Expect(c.Health()).To(MatchError("Unable to load kubeconfig"))
+
+		c.Set(&Unavailable{Cluster: "prod"})
+		Expect(c.Health()).To(BeNil())
+	})
+})